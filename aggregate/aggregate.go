@@ -0,0 +1,186 @@
+// Package aggregate summarizes components over many reports
+//
+// Components are taken from the tables of the export package and grouped by their columns (e.g. model and firmware).
+// A column of the parent component is given as parent.<column> (e.g. parent.deviceName of a NIC).
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moxspec/moxspec/export"
+	"github.com/moxspec/moxspec/model"
+)
+
+// ParentPrefix refers to a column of the parent component
+const ParentPrefix = "parent."
+
+// Group represents components sharing the values of the keys
+type Group struct {
+	Values []string `json:"values"`
+	Count  int      `json:"count"` // number of components
+	Hosts  []string `json:"hosts"`
+}
+
+// Result represents groups of components in a table
+type Result struct {
+	Table  string   `json:"table"`
+	By     []string `json:"by"`
+	Hosts  int      `json:"hosts"` // number of reports read
+	Groups []*Group `json:"groups"`
+}
+
+// Aggregator groups components of reports added
+type Aggregator struct {
+	table  string
+	by     []string
+	hosts  int
+	groups map[string]*group
+}
+
+type group struct {
+	values []string
+	count  int
+	hosts  map[string]bool
+}
+
+// Tables returns names of the tables and their columns
+func Tables() map[string][]string {
+	m := make(map[string][]string)
+	for _, t := range export.Tables(new(model.Report)) {
+		m[t.Name] = t.Header
+	}
+	return m
+}
+
+// NewAggregator creates an aggregator grouping components in the table by the columns
+func NewAggregator(table string, by []string) (*Aggregator, error) {
+	tables := Tables()
+	header, ok := tables[table]
+	if !ok {
+		var names []string
+		for name := range tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown table: %s (available: %s)", table, strings.Join(names, ", "))
+	}
+	if len(by) == 0 {
+		return nil, fmt.Errorf("no columns to group by")
+	}
+
+	for _, col := range by {
+		if !strings.HasPrefix(col, ParentPrefix) && !contains(header, col) {
+			return nil, fmt.Errorf("%s has no column %s (available: %s)", table, col, strings.Join(header, ", "))
+		}
+	}
+
+	a := new(Aggregator)
+	a.table = table
+	a.by = append([]string{}, by...)
+	a.groups = make(map[string]*group)
+	return a, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Add counts components in the report
+func (a *Aggregator) Add(r *model.Report) {
+	a.hosts++
+
+	tables := export.Tables(r)
+	rows := index(tables)
+
+	for _, t := range tables {
+		if t.Name != a.table {
+			continue
+		}
+		for _, row := range t.Rows {
+			var values []string
+			for _, col := range a.by {
+				values = append(values, lookup(t.Header, row, col, rows))
+			}
+
+			k := strings.Join(values, "\x00")
+			g, ok := a.groups[k]
+			if !ok {
+				g = &group{values: values, hosts: make(map[string]bool)}
+				a.groups[k] = g
+			}
+			g.count++
+			g.hosts[row[0]] = true
+		}
+	}
+}
+
+// entry is a row with the header of its table
+type entry struct {
+	header []string
+	row    []string
+}
+
+// index keys rows of all tables by the component key
+// a controller is in pci_devices as well as in nvme or gpus, pci_devices comes last and is taken as the parent
+func index(tables []*export.Table) map[string]entry {
+	m := make(map[string]entry)
+	for _, t := range tables {
+		for _, row := range t.Rows {
+			m[row[1]] = entry{header: t.Header, row: row}
+		}
+	}
+	return m
+}
+
+// lookup returns the value of the column in the row, a parent column is looked up in the parent row
+func lookup(header, row []string, col string, rows map[string]entry) string {
+	if strings.HasPrefix(col, ParentPrefix) {
+		p, ok := rows[row[2]]
+		if !ok {
+			return ""
+		}
+		return lookup(p.header, p.row, strings.TrimPrefix(col, ParentPrefix), rows)
+	}
+
+	for i, h := range header {
+		if h == col {
+			return row[i]
+		}
+	}
+	return ""
+}
+
+// Result returns groups sorted by the number of components
+// the slices of the result are its own, so that appending to them leaves the aggregator intact
+func (a *Aggregator) Result() *Result {
+	res := new(Result)
+	res.Table = a.table
+	res.By = append([]string{}, a.by...)
+	res.Hosts = a.hosts
+
+	for _, g := range a.groups {
+		var hosts []string
+		for h := range g.hosts {
+			hosts = append(hosts, h)
+		}
+		sort.Strings(hosts)
+		res.Groups = append(res.Groups, &Group{Values: append([]string{}, g.values...), Count: g.count, Hosts: hosts})
+	}
+
+	sort.Slice(res.Groups, func(i, j int) bool {
+		gi, gj := res.Groups[i], res.Groups[j]
+		if gi.Count != gj.Count {
+			return gi.Count > gj.Count
+		}
+		return strings.Join(gi.Values, "\x00") < strings.Join(gj.Values, "\x00")
+	})
+
+	return res
+}
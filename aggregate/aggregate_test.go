@@ -0,0 +1,135 @@
+package aggregate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func host(serial, nicFirmware string, dimms ...string) *model.Report {
+	nic := new(model.EthController)
+	nic.DeviceName = "MT27800 Family [ConnectX-5]"
+	nic.Interfaces = []*model.NetInterface{
+		{Name: "eth0", FirmwareVersion: nicFirmware},
+		{Name: "eth1", FirmwareVersion: nicFirmware},
+	}
+
+	r := &model.Report{
+		System:    &model.System{SerialNumber: serial},
+		Network:   &model.NetworkReport{EthControllers: []*model.EthController{nic}},
+		Memory:    new(model.MemoryReport),
+		PCIDevice: []*model.PCIBaseSpec{&nic.PCIBaseSpec},
+	}
+	for i, pn := range dimms {
+		r.Memory.Modules = append(r.Memory.Modules, &model.MemoryModule{Locator: fmt.Sprintf("A%d", i), PartNumber: pn})
+	}
+	return r
+}
+
+func TestAggregate(t *testing.T) {
+	reports := []*model.Report{
+		host("H1", "16.26.1040", "M393A4K40DB3", "M393A4K40DB3"),
+		host("H2", "16.26.1040", "M393A4K40DB3"),
+		host("H3", "16.23.1020", "HMA84GR7CJR4"),
+	}
+
+	tests := []struct {
+		table string
+		by    []string
+		want  []*Group
+	}{
+		{
+			"nics", []string{"firmwareVersion"},
+			[]*Group{
+				{Values: []string{"16.26.1040"}, Count: 4, Hosts: []string{"H1", "H2"}},
+				{Values: []string{"16.23.1020"}, Count: 2, Hosts: []string{"H3"}},
+			},
+		},
+		{
+			"nics", []string{"parent.deviceName", "firmwareVersion"},
+			[]*Group{
+				{Values: []string{"MT27800 Family [ConnectX-5]", "16.26.1040"}, Count: 4, Hosts: []string{"H1", "H2"}},
+				{Values: []string{"MT27800 Family [ConnectX-5]", "16.23.1020"}, Count: 2, Hosts: []string{"H3"}},
+			},
+		},
+		{
+			"dimms", []string{"partNumber"},
+			[]*Group{
+				{Values: []string{"M393A4K40DB3"}, Count: 3, Hosts: []string{"H1", "H2"}},
+				{Values: []string{"HMA84GR7CJR4"}, Count: 1, Hosts: []string{"H3"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s by %v", tt.table, tt.by), func(t *testing.T) {
+			a, err := NewAggregator(tt.table, tt.by)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range reports {
+				a.Add(r)
+			}
+
+			res := a.Result()
+			if res.Hosts != 3 {
+				t.Errorf("got %d hosts, want 3", res.Hosts)
+			}
+			if !reflect.DeepEqual(res.Groups, tt.want) {
+				for _, g := range res.Groups {
+					t.Logf("got: %+v", g)
+				}
+				t.Errorf("unexpected groups")
+			}
+		})
+	}
+}
+
+func TestNewAggregator(t *testing.T) {
+	tests := []struct {
+		table string
+		by    []string
+		err   bool
+	}{
+		{"nics", []string{"firmwareVersion"}, false},
+		{"nics", []string{"parent.deviceName"}, false},
+		{"dimms", []string{"manufacturer", "partNumber"}, false},
+		{"nic", []string{"firmwareVersion"}, true},
+		{"nics", []string{"firmware"}, true},
+		{"nics", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			_, err := NewAggregator(tt.table, tt.by)
+			if (err != nil) != tt.err {
+				t.Errorf("got: %v, expect error: %t", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestResultIsolated(t *testing.T) {
+	by := append(make([]string, 0, 4), "partNumber")
+	a, err := NewAggregator("dimms", by)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Add(host("H1", "16.26.1040", "M393A4K40DB3"))
+
+	// neither the columns given nor the result share their slices with the aggregator
+	res := a.Result()
+	res.By[0] = "manufacturer"
+	res.Groups[0].Values[0] = "HMA84GR7CJR4"
+	by[0] = "manufacturer"
+
+	res = a.Result()
+	if !reflect.DeepEqual(res.By, []string{"partNumber"}) {
+		t.Errorf("by got: %v, want: [partNumber]", res.By)
+	}
+	if !reflect.DeepEqual(res.Groups[0].Values, []string{"M393A4K40DB3"}) {
+		t.Errorf("values got: %v, want: [M393A4K40DB3]", res.Groups[0].Values)
+	}
+}
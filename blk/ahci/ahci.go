@@ -1,7 +1,6 @@
 package ahci
 
 import (
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -90,7 +89,7 @@ func newDisk(diskPath string) *Disk {
 	d.CommonSpec = *blk.NewCommonSpec(d.blkDir())
 	d.SCSIAddress = *blk.NewSCSIAddress(diskPath)
 
-	drvLink, err := os.Readlink(filepath.Join(diskPath, "driver"))
+	drvLink, err := util.Readlink(filepath.Join(diskPath, "driver"))
 	if err == nil {
 		d.Driver = filepath.Base(drvLink)
 	}
@@ -2,7 +2,6 @@ package raid
 
 import (
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -70,7 +69,7 @@ func (e *Expander) Decode() error {
 				log.Debugf("this device has sas_address: %s", d.SASAddress)
 			}
 
-			drvLink, err := os.Readlink(filepath.Join(diskPath, "driver"))
+			drvLink, err := util.Readlink(filepath.Join(diskPath, "driver"))
 			if err == nil {
 				d.Driver = filepath.Base(drvLink)
 			}
@@ -90,7 +89,7 @@ func (e *Expander) Decode() error {
 func readExpandedBlockPaths() []string {
 	var bpaths []string
 
-	syspath := "/sys/class/block"
+	syspath := util.RootPath("/sys/class/block")
 	files, err := ioutil.ReadDir(syspath)
 	if err != nil {
 		log.Warnf("could not read dir: %s", syspath)
@@ -99,7 +98,7 @@ func readExpandedBlockPaths() []string {
 
 	for _, file := range files {
 		lpath := filepath.Join(syspath, file.Name())
-		bpath, err := filepath.EvalSymlinks(lpath)
+		bpath, err := util.EvalSymlinks(lpath)
 		if err != nil {
 			log.Warnf("could not read link: %s", lpath)
 			continue
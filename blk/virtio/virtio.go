@@ -1,7 +1,6 @@
 package virtio
 
 import (
-	"os"
 	"path/filepath"
 
 	"github.com/moxspec/moxspec/blk"
@@ -48,7 +47,7 @@ func (c *Controller) Decode() error {
 		d.Path = vioDir
 		d.CommonSpec = *blk.NewCommonSpec(d.blkDir())
 
-		drvLink, err := os.Readlink(filepath.Join(vioDir, "driver"))
+		drvLink, err := util.Readlink(filepath.Join(vioDir, "driver"))
 		if err == nil {
 			d.Driver = filepath.Base(drvLink)
 		}
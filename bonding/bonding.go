@@ -3,6 +3,7 @@ package bonding
 import (
 	"net"
 
+	"github.com/moxspec/moxspec/util"
 	"github.com/vishvananda/netlink"
 )
 
@@ -48,6 +49,9 @@ type BondAttrs struct {
 
 // Decode make BondInterface satisfy the mox.Decoder interface
 func (intf *BondInterface) Decode() error {
+	if util.Captured() {
+		return intf.decodeSysfs()
+	}
 
 	bli, err := netlink.LinkByName(intf.Name)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
 	"github.com/vishvananda/netlink"
 )
 
@@ -34,6 +35,10 @@ func findBondSlaves(index int) ([]string, error) {
 
 // GetBondDevices returns a list of bonding devices
 func GetBondDevices() []string {
+	if util.Captured() {
+		return getBondDevicesSysfs()
+	}
+
 	var bonds []string
 
 	linklist, err := netlink.LinkList()
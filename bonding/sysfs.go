@@ -0,0 +1,92 @@
+package bonding
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/moxspec/moxspec/util"
+	"github.com/vishvananda/netlink"
+)
+
+// netlink is not available in a captured root, so bonding devices are read from sysfs there
+const sysNetDir = "/sys/class/net"
+
+var operStates = map[string]netlink.LinkOperState{
+	"not-present":      netlink.OperNotPresent,
+	"down":             netlink.OperDown,
+	"lower-layer-down": netlink.OperLowerLayerDown,
+	"testing":          netlink.OperTesting,
+	"dormant":          netlink.OperDormant,
+	"up":               netlink.OperUp,
+}
+
+func getBondDevicesSysfs() []string {
+	masters, err := util.LoadString(util.RootPath(filepath.Join(sysNetDir, "bonding_masters")))
+	if err != nil {
+		log.Debug(err)
+		return nil
+	}
+	return strings.Fields(masters)
+}
+
+func (intf *BondInterface) decodeSysfs() error {
+	dir := util.RootPath(filepath.Join(sysNetDir, intf.Name))
+	bdir := filepath.Join(dir, "bonding")
+	if !util.Exists(bdir) {
+		return util.ErrLiveOnly
+	}
+
+	// e.g. "802.3ad 4", the first field is the name
+	loadName := func(name string) string {
+		s, _ := util.LoadString(filepath.Join(bdir, name))
+		flds := strings.Fields(s)
+		if len(flds) == 0 {
+			return ""
+		}
+		return flds[0]
+	}
+	loadInt := func(path string) int {
+		s, _ := util.LoadString(path)
+		i, _ := strconv.Atoi(s)
+		return i
+	}
+
+	slaves, _ := util.LoadString(filepath.Join(bdir, "slaves"))
+	intf.Slaves = strings.Fields(slaves)
+
+	intf.LinkAttrs.Name = intf.Name
+	intf.LinkAttrs.MTU = loadInt(filepath.Join(dir, "mtu"))
+	intf.LinkAttrs.TxQLen = loadInt(filepath.Join(dir, "tx_queue_len"))
+	state, _ := util.LoadString(filepath.Join(dir, "operstate"))
+	intf.LinkAttrs.OperState = operStates[state]
+	addr, _ := util.LoadString(filepath.Join(dir, "address"))
+	intf.LinkAttrs.HardwareAddr, _ = net.ParseMAC(addr)
+
+	b := &intf.BondAttrs
+	b.Mode = netlink.StringToBondMode(loadName("mode"))
+	b.ActiveSlave = loadName("active_slave")
+	b.Miimon = loadInt(filepath.Join(bdir, "miimon"))
+	b.UpDelay = loadInt(filepath.Join(bdir, "updelay"))
+	b.DownDelay = loadInt(filepath.Join(bdir, "downdelay"))
+	b.UseCarrier = loadInt(filepath.Join(bdir, "use_carrier"))
+	b.ArpInterval = loadInt(filepath.Join(bdir, "arp_interval"))
+	b.ArpValidate = netlink.StringToBondArpValidateMap[loadName("arp_validate")]
+	b.ArpAllTargets = netlink.StringToBondArpAllTargetsMap[loadName("arp_all_targets")]
+	b.Primary = loadName("primary")
+	b.PrimaryReselect = netlink.StringToBondPrimaryReselectMap[loadName("primary_reselect")]
+	b.FailOverMac = netlink.StringToBondFailOverMacMap[loadName("fail_over_mac")]
+	b.XmitHashPolicy = netlink.StringToBondXmitHashPolicy(loadName("xmit_hash_policy"))
+	b.LacpRate = netlink.StringToBondLacpRate(loadName("lacp_rate"))
+	b.MinLinks = loadInt(filepath.Join(bdir, "min_links"))
+
+	targets, _ := util.LoadString(filepath.Join(bdir, "arp_ip_target"))
+	for _, t := range strings.Fields(targets) {
+		if ip := net.ParseIP(t); ip != nil {
+			b.ArpIPTargets = append(b.ArpIPTargets, ip)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package capture
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ManifestFormat is the version of the bundle layout
+const ManifestFormat = 1
+
+// Manifest represents manifest.json in a bundle
+type Manifest struct {
+	Format  int       `json:"format"`
+	Created time.Time `json:"created"`
+	Items   []*Item   `json:"items"`
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Manifest returns the manifest of the bundle
+func (b *Bundle) Manifest() *Manifest {
+	return &Manifest{
+		Format:  ManifestFormat,
+		Created: time.Now(),
+		Items:   b.Items(),
+	}
+}
+
+// WriteTarGz writes the bundle as a gzipped tar archive
+func (b *Bundle) WriteTarGz(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	m := b.Manifest()
+	mb, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(tw, "manifest.json", mb, m.Created)
+	if err != nil {
+		return err
+	}
+
+	dirs := make(map[string]bool)
+	for _, it := range m.Items {
+		err = writeDirs(tw, it.Path, dirs, it.Time)
+		if err != nil {
+			return err
+		}
+
+		if it.Kind == SymlinkKind {
+			err = tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeSymlink,
+				Name:     it.Path,
+				Linkname: it.Target,
+				Mode:     0777,
+				ModTime:  it.Time,
+			})
+		} else {
+			err = writeFile(tw, it.Path, it.data, it.Time)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// writeDirs writes the parent directories of p which are not written yet
+func writeDirs(tw *tar.Writer, p string, dirs map[string]bool, tm time.Time) error {
+	var parents []string
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' {
+			parents = append(parents, p[:i+1])
+		}
+	}
+
+	for _, d := range parents {
+		if dirs[d] {
+			continue
+		}
+		dirs[d] = true
+
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     d,
+			Mode:     0755,
+			ModTime:  tm,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, tm time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  tm,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	return err
+}
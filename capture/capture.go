@@ -0,0 +1,286 @@
+// Package capture keeps raw hardware data read by the decoders and writes it out as a support bundle
+//
+// The layout of a bundle is stable:
+//
+//	manifest.json                  metadata of every item
+//	report.json                    the report decoded along with the capture
+//	root/<path>                    files and symlinks read from sysfs/procfs, usable with mox show -root
+//	cpuid/<eax>-<ecx>.bin          CPUID leaves (eax, ebx, ecx, edx)
+//	msr/cpu<n>/<register>.bin      MSR values
+//	ioctl/<device>/<request>.bin   buffers returned by ioctl (SG_IO, NVMe admin commands, ethtool)
+//	cmd/<command>/<args>.txt       stdout of external commands
+package capture
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind represents a kind of captured item
+type Kind string
+
+// These are kinds of items
+const (
+	FileKind    Kind = "file"
+	SymlinkKind Kind = "symlink"
+	CPUIDKind   Kind = "cpuid"
+	MSRKind     Kind = "msr"
+	IoctlKind   Kind = "ioctl"
+	CommandKind Kind = "command"
+	ReportKind  Kind = "report"
+)
+
+// Item represents a captured piece of raw data
+type Item struct {
+	Path    string    `json:"path"`
+	Kind    Kind      `json:"kind"`
+	Source  string    `json:"source,omitempty"`
+	Request string    `json:"request,omitempty"`
+	Command []string  `json:"command,omitempty"`
+	Target  string    `json:"target,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Size    int       `json:"size"`
+	SHA256  string    `json:"sha256,omitempty"`
+	Time    time.Time `json:"time"`
+
+	data []byte
+}
+
+// Bundle represents a set of captured items
+type Bundle struct {
+	mu    sync.Mutex
+	items map[string]*Item
+}
+
+// NewBundle creates and initializes a Bundle
+func NewBundle() *Bundle {
+	b := new(Bundle)
+	b.items = make(map[string]*Item)
+	return b
+}
+
+// Add stores an item, an item already stored at the same path is replaced
+func (b *Bundle) Add(it *Item, data []byte) {
+	it.data = data
+	it.Size = len(data)
+	if it.Kind != SymlinkKind {
+		it.SHA256 = checksum(data)
+	}
+	if it.Time.IsZero() {
+		it.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.items[it.Path] = it
+}
+
+// Items returns the items sorted by path
+func (b *Bundle) Items() []*Item {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var items []*Item
+	for _, it := range b.items {
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// Data returns the content of the item at the path
+func (b *Bundle) Data(p string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	it, ok := b.items[p]
+	if !ok {
+		return nil, false
+	}
+	return it.data, true
+}
+
+var (
+	mu      sync.RWMutex
+	current *Bundle
+)
+
+// Start starts capturing into a new bundle
+func Start() *Bundle {
+	mu.Lock()
+	defer mu.Unlock()
+	current = NewBundle()
+	return current
+}
+
+// Stop stops capturing and returns the bundle
+func Stop() *Bundle {
+	mu.Lock()
+	defer mu.Unlock()
+	b := current
+	current = nil
+	return b
+}
+
+// Enabled returns true while capturing
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current != nil
+}
+
+func add(it *Item, data []byte) {
+	mu.RLock()
+	b := current
+	mu.RUnlock()
+
+	if b == nil {
+		return
+	}
+	b.Add(it, data)
+}
+
+// File captures a file read from sysfs/procfs, the source should be an absolute path
+func File(source string, data []byte) {
+	if !Enabled() {
+		return
+	}
+	add(&Item{
+		Path:   rootPath(source),
+		Kind:   FileKind,
+		Source: source,
+	}, data)
+}
+
+// Symlink captures a symlink in sysfs
+func Symlink(source, target string) {
+	if !Enabled() {
+		return
+	}
+	add(&Item{
+		Path:   rootPath(source),
+		Kind:   SymlinkKind,
+		Source: source,
+		Target: target,
+	}, nil)
+}
+
+// CPUID captures the registers returned by a CPUID leaf
+func CPUID(eax, ecx uint32, data []byte) {
+	if !Enabled() {
+		return
+	}
+	add(&Item{
+		Path:    fmt.Sprintf("cpuid/%08x-%08x.bin", eax, ecx),
+		Kind:    CPUIDKind,
+		Request: fmt.Sprintf("eax=0x%08x ecx=0x%08x", eax, ecx),
+	}, data)
+}
+
+// MSR captures a model specific register
+func MSR(cpu uint16, reg int64, data []byte) {
+	if !Enabled() {
+		return
+	}
+	add(&Item{
+		Path:    fmt.Sprintf("msr/cpu%d/%x.bin", cpu, reg),
+		Kind:    MSRKind,
+		Source:  fmt.Sprintf("/dev/cpu/%d/msr", cpu),
+		Request: fmt.Sprintf("0x%x", reg),
+	}, data)
+}
+
+// Ioctl captures a buffer returned by an ioctl request to the device
+func Ioctl(device, request string, data []byte) {
+	if !Enabled() {
+		return
+	}
+	add(&Item{
+		Path:    fmt.Sprintf("ioctl/%s/%s.bin", sanitize(filepath.Base(device)), sanitize(request)),
+		Kind:    IoctlKind,
+		Source:  device,
+		Request: request,
+	}, data)
+}
+
+// Command captures the stdout of an external command
+func Command(name string, args []string, out string, err error) {
+	if !Enabled() {
+		return
+	}
+	it := &Item{
+		Path:    CommandPath(name, args...),
+		Kind:    CommandKind,
+		Command: append([]string{name}, args...),
+	}
+	if err != nil {
+		it.Error = err.Error()
+	}
+	add(it, []byte(out))
+}
+
+// CommandPath returns the path an external command is stored at
+// arguments are joined with "_" after escaping, so that different arguments never share a path
+func CommandPath(name string, args ...string) string {
+	var a string
+	switch {
+	case len(args) == 0:
+		a = "noargs"
+	case len(args) == 1 && args[0] == "noargs":
+		// not to be taken for no arguments
+		a = "%6Eoargs"
+	default:
+		ea := make([]string, len(args))
+		for i, arg := range args {
+			ea[i] = escapeArg(arg)
+		}
+		a = strings.Join(ea, "_")
+	}
+	return fmt.Sprintf("cmd/%s/%s.txt", sanitize(filepath.Base(name)), a)
+}
+
+// escapeArg escapes bytes other than safe ones as %XX like a url
+// an empty argument is %00 since an argument never contains NUL
+func escapeArg(arg string) string {
+	if arg == "" {
+		return "%00"
+	}
+	var sb strings.Builder
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		if isSafe(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+func isSafe(c byte) bool {
+	switch {
+	case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9':
+		return true
+	case c == '.', c == '+', c == '=', c == '-':
+		return true
+	}
+	return false
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._+=-]+`)
+
+func sanitize(s string) string {
+	return strings.Trim(unsafeChars.ReplaceAllString(s, "_"), "_")
+}
+
+func rootPath(source string) string {
+	return path.Join("root", filepath.ToSlash(filepath.Clean("/"+source)))
+}
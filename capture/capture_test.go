@@ -0,0 +1,167 @@
+package capture
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCommandPath(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		ex   string
+	}{
+		{"ipmitool", []string{"mc", "info"}, "cmd/ipmitool/mc_info.txt"},
+		{"/opt/MegaRAID/MegaCli/MegaCli64", []string{"-AdpAllInfo", "-a0", "-NoLog"}, "cmd/MegaCli64/-AdpAllInfo_-a0_-NoLog.txt"},
+		{"hpacucli", []string{"ctrl", "slot=0", "pd", "all", "show", "detail"}, "cmd/hpacucli/ctrl_slot=0_pd_all_show_detail.txt"},
+		{"nvidia-smi", []string{"-q", "-x"}, "cmd/nvidia-smi/-q_-x.txt"},
+		{"sas3ircu", []string{"LIST"}, "cmd/sas3ircu/LIST.txt"},
+		{"uname", nil, "cmd/uname/noargs.txt"},
+		{"sh", []string{"-c", "a | b"}, "cmd/sh/-c_a%20%7C%20b.txt"},
+		{"ipmitool", []string{"raw", "0x06", "  0x01"}, "cmd/ipmitool/raw_0x06_%20%200x01.txt"},
+		// arguments which used to share a path
+		{"cmd", []string{"a_b"}, "cmd/cmd/a%5Fb.txt"},
+		{"cmd", []string{"a", "b"}, "cmd/cmd/a_b.txt"},
+		{"cmd", []string{"a b"}, "cmd/cmd/a%20b.txt"},
+		{"cmd", []string{"a/b"}, "cmd/cmd/a%2Fb.txt"},
+		{"cmd", []string{"a", ""}, "cmd/cmd/a_%00.txt"},
+		{"cmd", []string{"a"}, "cmd/cmd/a.txt"},
+		{"cmd", []string{""}, "cmd/cmd/%00.txt"},
+		{"cmd", []string{"noargs"}, "cmd/cmd/%6Eoargs.txt"},
+		{"cmd", []string{"100%"}, "cmd/cmd/100%25.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			got := CommandPath(tt.name, tt.args...)
+			if got != tt.ex {
+				t.Errorf("got: %s, expect: %s", got, tt.ex)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	File("/sys/class/dmi/id/product_name", []byte("before start"))
+	if Enabled() {
+		t.Fatal("capture should be disabled before start")
+	}
+
+	Start()
+	File("/proc/modules", []byte("nvme 1 0 - Live 0x0\n"))
+	File("/proc/modules", []byte("e1000 1 0 - Live 0x0\n"))
+	Symlink("/sys/class/net/eth0", "../../devices/pci0000:00/0000:00:03.0/net/eth0")
+	CPUID(0x1, 0x0, make([]byte, 16))
+	MSR(3, 0x1a2, make([]byte, 8))
+	Ioctl("/dev/nvme0", "admin-06-00000000-00000001", make([]byte, 4096))
+	Command("ipmitool", []string{"mc", "info"}, "Firmware Revision : 1.00\n", fmt.Errorf("exit status 1"))
+	b := Stop()
+
+	File("/proc/cpuinfo", []byte("after stop"))
+	if Enabled() {
+		t.Fatal("capture should be disabled after stop")
+	}
+
+	var paths []string
+	for _, it := range b.Items() {
+		paths = append(paths, it.Path)
+	}
+	ex := []string{
+		"cmd/ipmitool/mc_info.txt",
+		"cpuid/00000001-00000000.bin",
+		"ioctl/nvme0/admin-06-00000000-00000001.bin",
+		"msr/cpu3/1a2.bin",
+		"root/proc/modules",
+		"root/sys/class/net/eth0",
+	}
+	if fmt.Sprint(paths) != fmt.Sprint(ex) {
+		t.Fatalf("got: %v, expect: %v", paths, ex)
+	}
+
+	data, ok := b.Data("root/proc/modules")
+	if !ok || string(data) != "e1000 1 0 - Live 0x0\n" {
+		t.Errorf("the latest data should be kept, got: %q", data)
+	}
+
+	for _, it := range b.Items() {
+		if it.Path == "cmd/ipmitool/mc_info.txt" && it.Error != "exit status 1" {
+			t.Errorf("error got: %s", it.Error)
+		}
+	}
+}
+
+func TestWriteTarGz(t *testing.T) {
+	b := NewBundle()
+	b.Add(&Item{Path: "root/sys/bus/pci/devices/0000:00:03.0/config", Kind: FileKind}, []byte{0x86, 0x80, 0x0e, 0x10})
+	b.Add(&Item{Path: "root/sys/class/net/eth0", Kind: SymlinkKind, Target: "../../devices/pci0000:00/0000:00:03.0/net/eth0"}, nil)
+	b.Add(&Item{Path: "report.json", Kind: ReportKind}, []byte("{}"))
+
+	var buf bytes.Buffer
+	err := b.WriteTarGz(&buf)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+	tr := tar.NewReader(gr)
+
+	files := make(map[string][]byte)
+	links := make(map[string]string)
+	var first string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error should be nil, got: %s", err)
+		}
+		if first == "" {
+			first = h.Name
+		}
+
+		switch h.Typeflag {
+		case tar.TypeReg:
+			files[h.Name], _ = ioutil.ReadAll(tr)
+		case tar.TypeSymlink:
+			links[h.Name] = h.Linkname
+		}
+	}
+
+	if first != "manifest.json" {
+		t.Errorf("the first entry got: %s, expect: manifest.json", first)
+	}
+
+	var m Manifest
+	err = json.Unmarshal(files["manifest.json"], &m)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+	if m.Format != ManifestFormat || len(m.Items) != 3 {
+		t.Fatalf("manifest got: %+v", m)
+	}
+	for _, it := range m.Items {
+		if it.Kind == SymlinkKind {
+			continue
+		}
+		if it.SHA256 != checksum(files[it.Path]) || it.Size != len(files[it.Path]) {
+			t.Errorf("%s does not match the manifest: %+v", it.Path, it)
+		}
+	}
+
+	if !bytes.Equal(files["root/sys/bus/pci/devices/0000:00:03.0/config"], []byte{0x86, 0x80, 0x0e, 0x10}) {
+		t.Errorf("config got: %v", files["root/sys/bus/pci/devices/0000:00:03.0/config"])
+	}
+	if links["root/sys/class/net/eth0"] != "../../devices/pci0000:00/0000:00:03.0/net/eth0" {
+		t.Errorf("symlink got: %s", links["root/sys/class/net/eth0"])
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moxspec/moxspec/aggregate"
+	"github.com/moxspec/moxspec/model"
+)
+
+func aggregateReports(cli *app) error {
+	format, err := outputFormat(cli)
+	if err != nil {
+		return err
+	}
+
+	var by []string
+	for _, col := range strings.Split(cli.getString("by"), ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			by = append(by, col)
+		}
+	}
+
+	agg, err := aggregate.NewAggregator(cli.getString("table"), by)
+	if err != nil {
+		return err
+	}
+
+	paths := cli.rest()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	for _, p := range paths {
+		err = readReports(p, agg.Add)
+		if err != nil {
+			return err
+		}
+	}
+
+	res := agg.Result()
+
+	switch format {
+	case jsonFormat:
+		return printJSON(res)
+	case csvFormat:
+		var rows [][]string
+		for _, g := range res.Groups {
+			rows = append(rows, append(append([]string{}, g.Values...), strconv.Itoa(g.Count), strconv.Itoa(len(g.Hosts)), strings.Join(g.Hosts, " ")))
+		}
+		return printCSV(append(append([]string{}, res.By...), "count", "hosts", "hostList"), rows)
+	}
+
+	headers := append(append([]string{}, res.By...), "count", "hosts", "host list")
+	tbl := newTable(headers[0], headers[1:]...)
+	for _, g := range res.Groups {
+		row := append(append([]string{}, g.Values...), strconv.Itoa(g.Count), strconv.Itoa(len(g.Hosts)), strings.Join(g.Hosts, ","))
+		tbl.append(row[0], row[1:]...)
+	}
+	tbl.print()
+	fmt.Printf("%d hosts\n", res.Hosts)
+
+	return nil
+}
+
+// readReports reads reports from a file, json files under a directory or a stream of reports on stdin (-)
+func readReports(path string, fn func(*model.Report)) error {
+	if path == "-" {
+		return decodeReports(os.Stdin, "stdin", fn)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return readReportFile(path, fn)
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(p) == ".json" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		err = readReportFile(f, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readReportFile(path string, fn func(*model.Report)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return decodeReports(f, path, fn)
+}
+
+// decodeReports decodes reports written one after another (e.g. JSON lines)
+func decodeReports(r io.Reader, name string, fn func(*model.Report)) error {
+	dec := json.NewDecoder(r)
+	for {
+		rep := new(model.Report)
+		err := dec.Decode(rep)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		fn(rep)
+	}
+}
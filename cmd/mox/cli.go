@@ -91,3 +91,7 @@ func newGenericFlag(key string, def interface{}, desc string) *genericFlag {
 	g.desc = desc
 	return g
 }
+
+func (a *app) rest() []string {
+	return a.fset.Args()
+}
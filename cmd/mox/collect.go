@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/moxspec/moxspec/capture"
+)
+
+func collect(cli *app) error {
+	out := cli.getString("o")
+	if out == "" {
+		return fmt.Errorf("output path is empty")
+	}
+
+	capture.Start()
+	r, err := decode(cli)
+	b := capture.Stop()
+
+	// the raw data is worth keeping even if decoding failed partially
+	if err != nil {
+		log.Warn(err.Error())
+	}
+	if missingDMITables(b) {
+		log.Warn("the bundle has no DMI tables since they are readable only by root, processors, memory devices, power supplies and serial numbers are not decoded from it")
+	}
+
+	if r != nil {
+		jb, jerr := json.MarshalIndent(r, "", "  ")
+		if jerr != nil {
+			return jerr
+		}
+		b.Add(&capture.Item{Path: "report.json", Kind: capture.ReportKind}, jb)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+
+	err = b.WriteTarGz(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %d items to %s\n", len(b.Items()), out)
+	return nil
+}
+
+// missingDMITables returns true if smbios was decoded from /sys/class/dmi/id without the tables
+func missingDMITables(b *capture.Bundle) bool {
+	var tables, dmiid bool
+	for _, it := range b.Items() {
+		switch {
+		case it.Source == "/sys/firmware/dmi/tables/DMI":
+			tables = true
+		case strings.Contains(it.Source, "/dmi/id/"):
+			dmiid = true
+		}
+	}
+	return dmiid && !tables
+}
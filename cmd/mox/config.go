@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/moxspec/moxspec/config"
+)
+
+// conf is loaded once since both the collection and the output look at it
+var conf *config.Config
+
+func appendConfigFlag(cli *app) {
+	cli.appendFlag("config", "", fmt.Sprintf("read the configuration from the given path (default $%s or %s)", config.PathEnv, config.DefaultPath))
+}
+
+// loadConfig loads the configuration given by -config, $MOX_CONFIG or at the default path
+func loadConfig(cli *app) (*config.Config, error) {
+	if conf != nil {
+		return conf, nil
+	}
+
+	c, err := config.LoadDefault(cli.getString("config"))
+	if err != nil {
+		return nil, err
+	}
+	conf = c
+	return conf, nil
+}
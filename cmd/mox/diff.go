@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/moxspec/moxspec/drift"
+	"github.com/moxspec/moxspec/model"
+)
+
+// exit codes of mox diff, same as diff(1)
+const (
+	exitNoDrift   = 0
+	exitDrift     = 1
+	exitDiffError = 2
+)
+
+func diff(cli *app) (int, error) {
+	files := cli.rest()
+	if len(files) != 2 {
+		return exitDiffError, fmt.Errorf("usage: mox diff [-j] old.json new.json")
+	}
+
+	before, err := loadReport(files[0])
+	if err != nil {
+		return exitDiffError, err
+	}
+
+	after, err := loadReport(files[1])
+	if err != nil {
+		return exitDiffError, err
+	}
+
+	res := drift.Compare(before, after)
+
+	j, err := jsonOutput(cli)
+	if err != nil {
+		return exitDiffError, err
+	}
+
+	if j {
+		jb, err := json.Marshal(res)
+		if err != nil {
+			return exitDiffError, err
+		}
+		fmt.Println(string(jb))
+	} else {
+		printDrift(res)
+	}
+
+	if res.HasDrift() {
+		return exitDrift, nil
+	}
+	return exitNoDrift, nil
+}
+
+func loadReport(path string) (*model.Report, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(model.Report)
+	err = json.Unmarshal(b, r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return r, nil
+}
+
+func printDrift(res *drift.Result) {
+	if !res.HasDrift() {
+		fmt.Println("no drift")
+		return
+	}
+
+	tbl := newTable("", "component", "id", "field", "old", "new")
+	for _, c := range res.Changes {
+		switch c.Kind {
+		case drift.Added:
+			tbl.append("+", c.Component, c.ID, "", "", c.Summary)
+		case drift.Removed:
+			tbl.append("-", c.Component, c.ID, "", c.Summary, "")
+		case drift.Changed:
+			for _, f := range c.Fields {
+				tbl.append("~", c.Component, c.ID, f.Field, f.Old, f.New)
+			}
+		}
+	}
+	tbl.print()
+
+	fmt.Printf("%d added, %d removed, %d changed\n", res.Added, res.Removed, res.Changed)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/moxspec/moxspec/export"
+	"github.com/moxspec/moxspec/model"
+)
+
+func exportTables(cli *app) error {
+	if f := cli.getString("format"); f != csvFormat {
+		return fmt.Errorf("unsupported format: %s (available: %s)", f, csvFormat)
+	}
+
+	dir := cli.getString("dir")
+	if dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+
+	var r *model.Report
+	var err error
+	if p := cli.getString("report"); p != "" {
+		r, err = loadReport(p)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err = decode(cli)
+		if r == nil {
+			return err
+		}
+		// components decoded are exported anyway
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	return export.WriteCSVDir(dir, r)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/mox"
+	"github.com/moxspec/moxspec/query"
+)
+
+func get(cli *app) error {
+	args := cli.rest()
+	if len(args) != 1 {
+		return fmt.Errorf("usage: mox get [-j] path (e.g. storage.nvmeControllers[*].serialNumber)")
+	}
+
+	p, err := query.Parse(args[0])
+	if err != nil {
+		return err
+	}
+
+	var r *model.Report
+	if rp := cli.getString("report"); rp != "" {
+		r, err = loadReport(rp)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err = decodeFor(cli, p)
+		if r == nil {
+			return err
+		}
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	vals, err := p.Get(r)
+	if err != nil {
+		return err
+	}
+	if len(vals) == 0 {
+		return fmt.Errorf("no value at %s", p)
+	}
+
+	j, err := jsonOutput(cli)
+	if err != nil {
+		return err
+	}
+
+	if j {
+		if p.Multi() {
+			return printJSON(vals)
+		}
+		return printJSON(vals[0])
+	}
+
+	for _, v := range vals {
+		fmt.Println(query.Format(v))
+	}
+	return nil
+}
+
+// decodeFor decodes only the sections the path needs unless they are given by -only
+func decodeFor(cli *app, p *query.Path) (*model.Report, error) {
+	opts, err := collectOptions(cli)
+	if err != nil {
+		return nil, err
+	}
+
+	if cli.getString("only") == "" {
+		if ss := mox.FieldSections(p.Root()); ss != nil {
+			opts.Sections = ss
+		}
+	}
+
+	return mox.Collect(context.Background(), opts)
+}
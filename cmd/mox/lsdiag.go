@@ -3,195 +3,126 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/moxspec/moxspec/diag"
 	"github.com/moxspec/moxspec/loglet"
 	"github.com/moxspec/moxspec/model"
 )
 
-const (
-	healthy   = "healthy"
-	unhealthy = "UNHEALTHY"
-)
-
-const (
-	exitHealthy   = 0
-	exitUnhealthy = 1
-)
+// exitUnhealthy is also used when lsdiag could not diagnose at all
+const exitUnhealthy = 1
 
 func lsdiag() (int, error) {
 	loglet.SetLevel(loglet.INFO)
 
 	cli := newAppWithoutCmd(os.Args)
+	appendFormatFlags(cli)
+	appendConfigFlag(cli)
+	cli.appendFlag("nagios", false, "print a status line and perfdata of a monitoring plugin, exits with 0/1/2/3 for OK/WARNING/CRITICAL/UNKNOWN")
+	cli.appendFlag("policy", "", fmt.Sprintf("diagnose with the given policy (default %s if exists)", diag.DefaultPolicyPath))
+	cli.appendFlag("state", "", fmt.Sprintf("keep counters in the given file to check deltas (default %s if the policy has deltas)", diag.DefaultStatePath))
 	err := cli.parse()
 	if err != nil {
 		return exitUnhealthy, err
 	}
 
-	var r *model.Report
-	r, err = decode(cli)
+	if cli.getBool("nagios") {
+		return lsdiagNagios(cli), nil
+	}
 
+	format, err := outputFormat(cli)
 	if err != nil {
 		return exitUnhealthy, err
 	}
 
-	exitCode := exitHealthy
-
-	tbl := newTable("category", "stat", "detail")
-
-	if r.Processor != nil {
-		for _, p := range r.Processor.Packages {
-			model := fmt.Sprintf("%s %s", p.Socket, p.ProductName)
-
-			stat := healthy
-			detail := model
-
-			if p.ThrottleCount > 0 {
-				stat = unhealthy
-				detail = fmt.Sprintf("%s: throttle cnt = %d", model, p.ThreadCount)
-
-				if exitCode != exitUnhealthy {
-					exitCode = exitUnhealthy
-				}
-			}
-
-			tbl.append("Processor", stat, detail)
-		}
+	policy, err := loadDiagPolicy(cli)
+	if err != nil {
+		return exitUnhealthy, err
 	}
 
-	if r.Memory != nil {
-		append := func(stat, detail string) {
-			tbl.append("Memory", stat, detail)
-		}
-
-		for _, ctl := range r.Memory.Controllers {
-			ctlName := ctl.Name
-			for _, cs := range ctl.CSRows {
-				if cs.HasError() {
-					append(unhealthy, fmt.Sprintf("%s %s", ctlName, cs.Summary()))
-
-					if exitCode != exitUnhealthy {
-						exitCode = exitUnhealthy
-					}
-				} else {
-					append(healthy, fmt.Sprintf("%s %s", ctlName, cs.Name))
-				}
-			}
-		}
+	var r *model.Report
+	r, err = decode(cli)
+	if r == nil {
+		return exitUnhealthy, err
+	}
+	// sections which timed out are in the report as errors and diagnosed as not inspected
+	if err != nil {
+		log.Warn(err.Error())
 	}
-	if r.Storage != nil {
-		for _, ctl := range r.Storage.RAIDControllers {
-			if ctl.IsHealthy() {
-				tbl.append("RAID Card", healthy, ctl.LongName())
-			} else {
-				appendMultiDiags(tbl, "RAID Card", unhealthy, ctl.DiagSummaries())
-
-				if exitCode != exitUnhealthy {
-					exitCode = exitUnhealthy
-				}
-			}
-
-			for _, ld := range ctl.LogDrives {
-				detail := fmt.Sprintf("%s: %s, %s", ld.Name, ld.RAIDLv, ld.Status)
-
-				stat := healthy
-				if !ld.IsHealthy() {
-					stat = unhealthy
-
-					if exitCode != exitUnhealthy {
-						exitCode = exitUnhealthy
-					}
-				}
-
-				tbl.append("RAID Volume", stat, detail)
-			}
-
-			for _, pd := range ctl.PassthroughDrives {
-				stat := healthy
-				if !pd.IsHealthy() {
-					stat = unhealthy
-
-					if exitCode != exitUnhealthy {
-						exitCode = exitUnhealthy
-					}
-				}
-				detail := fmt.Sprintf("%s: %s, %s", pd.Name, pd.Model, pd.Status)
-				tbl.append("Pass-Through Drive", stat, detail)
-			}
-		}
 
-		for _, ctl := range r.Storage.NVMeControllers {
-			if ctl.IsHealthy() {
-				tbl.append("NVMe Drive", healthy, ctl.LongName())
-			} else {
-				appendMultiDiags(tbl, "NVMe Drive", unhealthy, ctl.DiagSummaries())
+	d, err := diagnose(policy, r, cli.getString("state"))
+	if err != nil {
+		return exitUnhealthy, err
+	}
 
-				if exitCode != exitUnhealthy {
-					exitCode = exitUnhealthy
-				}
-			}
+	switch format {
+	case jsonFormat:
+		err = printJSON(d)
+	case csvFormat:
+		var rows [][]string
+		for _, res := range d.Results {
+			rows = append(rows, []string{res.Category, res.ID, res.Status, strings.Join(res.Details, "; ")})
 		}
-
-		for _, ctl := range r.Storage.AHCIControllers {
-			for _, drv := range ctl.Drives {
-				detail := fmt.Sprintf("%s %s", drv.Model, drv.SizeString())
-
-				if drv.IsHealthy() {
-					tbl.append("SATA Drive", healthy, detail)
-				} else {
-					appendMultiDiags(tbl, "SATA Drive", unhealthy, ctl.DiagSummaries())
-
-					if exitCode != exitUnhealthy {
-						exitCode = exitUnhealthy
-					}
-				}
-			}
+		err = printCSV([]string{"category", "id", "status", "details"}, rows)
+	default:
+		tbl := newTable("category", "stat", "detail")
+		for _, res := range d.Results {
+			appendMultiDiags(tbl, res.Category, res.Status, res.Details)
 		}
+		tbl.print()
+	}
+	if err != nil {
+		return exitUnhealthy, err
 	}
 
-	if r.Network != nil {
-		for _, ctl := range r.Network.EthControllers {
-			detail := ctl.LongName()
-
-			if ctl.IsHealthy() {
-				tbl.append("Network", healthy, detail)
-			} else {
-				appendMultiDiags(tbl, "Network", unhealthy, ctl.DiagSummaries())
+	return policy.ExitCode(d), nil
+}
 
-				if exitCode != exitUnhealthy {
-					exitCode = exitUnhealthy
-				}
-			}
+// loadDiagPolicy loads the policy given by -policy or the configuration, or the one at the default path if exists
+func loadDiagPolicy(cli *app) (*diag.Policy, error) {
+	path := cli.getString("policy")
+	if path == "" {
+		cf, err := loadConfig(cli)
+		if err != nil {
+			return nil, err
 		}
+		path = cf.Policy
+	}
+	if path != "" {
+		return diag.LoadPolicy(path)
 	}
 
-	if r.Accelerator != nil {
-		for _, g := range r.Accelerator.GPUs {
-			if g.IsHealthy() {
-				tbl.append("Accelerator", healthy, g.LongName())
-			} else {
-				appendMultiDiags(tbl, "Accelerator", unhealthy, g.DiagSummaries())
-
-				if exitCode != exitUnhealthy {
-					exitCode = exitUnhealthy
-				}
-			}
-		}
+	_, err := os.Stat(diag.DefaultPolicyPath)
+	if err == nil {
+		return diag.LoadPolicy(diag.DefaultPolicyPath)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return diag.DefaultPolicy(), nil
+}
 
-		for _, f := range r.Accelerator.FPGAs {
-			if f.IsHealthy() {
-				tbl.append("Accelerator", healthy, f.LongName())
-			} else {
-				appendMultiDiags(tbl, "Accelerator", unhealthy, f.DiagSummaries())
+// diagnose diagnoses the report, counters are compared with and kept in the state file if any
+func diagnose(policy *diag.Policy, r *model.Report, path string) (*diag.Diagnosis, error) {
+	if path == "" {
+		path = policy.StatePath()
+	}
+	if path == "" {
+		return policy.Diagnose(r), nil
+	}
 
-				if exitCode != exitUnhealthy {
-					exitCode = exitUnhealthy
-				}
-			}
-		}
+	prev, err := diag.LoadState(path)
+	if err != nil {
+		return nil, err
 	}
 
-	tbl.print()
-	return exitCode, nil
+	d, next := policy.DiagnoseSince(r, prev)
+	err = next.Save(path)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
 }
 
 func appendMultiDiags(t *table, cat, stat string, diags []string) {
@@ -203,3 +134,30 @@ func appendMultiDiags(t *table, cat, stat string, diags []string) {
 		}
 	}
 }
+
+// lsdiagNagios runs lsdiag as a monitoring plugin, every failure is told as UNKNOWN
+func lsdiagNagios(cli *app) int {
+	if cli.getBool("j") || cli.getBool("csv") {
+		return diag.NagiosError(os.Stdout, fmt.Errorf("-nagios can not be used with -j or -csv"))
+	}
+
+	policy, err := loadDiagPolicy(cli)
+	if err != nil {
+		return diag.NagiosError(os.Stdout, err)
+	}
+
+	// the log must not be mixed up with the plugin output
+	loglet.SetOutput(os.Stderr)
+
+	r, err := decode(cli)
+	if r == nil {
+		return diag.NagiosError(os.Stdout, err)
+	}
+
+	d, err := diagnose(policy, r, cli.getString("state"))
+	if err != nil {
+		return diag.NagiosError(os.Stdout, err)
+	}
+
+	return policy.Nagios(os.Stdout, r, d)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/moxspec/moxspec/loglet"
@@ -11,53 +12,108 @@ func lsraid() error {
 	loglet.SetLevel(loglet.INFO)
 
 	cli := newAppWithoutCmd(os.Args)
+	appendFormatFlags(cli)
+	appendConfigFlag(cli)
 	err := cli.parse()
 	if err != nil {
 		return err
 	}
 
+	format, err := outputFormat(cli)
+	if err != nil {
+		return err
+	}
+
 	var r *model.Report
 	r, err = decode(cli)
-
-	if err != nil {
+	if r == nil {
 		return err
 	}
+	// components decoded are listed anyway
+	if err != nil {
+		log.Warn(err.Error())
+	}
 
-	if r.Storage == nil {
-		return nil
+	var rs []*raidRecord
+	if r.Storage != nil {
+		rs = raidRecords(r.Storage.RAIDControllers)
 	}
 
-	if len(r.Storage.RAIDControllers) == 0 {
+	switch format {
+	case jsonFormat:
+		if rs == nil {
+			rs = []*raidRecord{}
+		}
+		return printJSON(rs)
+	case csvFormat:
+		var rows [][]string
+		for _, d := range rs {
+			rows = append(rows, []string{d.Block, d.Config, d.Adapter, d.Position, d.Status, fmt.Sprintf("%d", d.Size), d.Form, d.Model, d.SerialNumber})
+		}
+		return printCSV([]string{"blk", "conf", "adapter", "position", "status", "size", "form", "model", "serialNumber"}, rows)
+	}
+
+	if r.Storage == nil || len(r.Storage.RAIDControllers) == 0 {
 		return nil
 	}
 
 	tbl := newTable("blk", "conf", "adp", "pos", "stat", "size", "form", "model")
-	for _, ctl := range r.Storage.RAIDControllers {
-		if len(ctl.LogDrives) == 0 && len(ctl.UnconfDrives) == 0 && len(ctl.PassthroughDrives) == 0 {
-			continue
-		}
+	for _, d := range rs {
+		tbl.append(d.Block, d.Config, d.Adapter, d.Position, d.Status, d.sizeString, d.Form, d.Model)
+	}
+	tbl.print()
 
-		for _, ld := range ctl.LogDrives {
-			if len(ld.PhyDrives) == 0 {
-				continue
-			}
+	return nil
+}
+
+// raidRecord represents a physical drive listed by lsraid
+type raidRecord struct {
+	Block        string `json:"blk"`
+	Config       string `json:"conf"`
+	Adapter      string `json:"adapter"`
+	Position     string `json:"position"`
+	Status       string `json:"status"`
+	Size         uint64 `json:"size"` // bytes
+	Form         string `json:"form"`
+	Model        string `json:"model"`
+	SerialNumber string `json:"serialNumber"`
+
+	sizeString string
+}
 
+func newRAIDRecord(blk, conf, adp string, pd *model.PhyDrive) *raidRecord {
+	return &raidRecord{
+		Block:        blk,
+		Config:       conf,
+		Adapter:      adp,
+		Position:     pd.Pos(),
+		Status:       pd.Status,
+		Size:         pd.Size,
+		Form:         pd.FormSummary(),
+		Model:        pd.Model,
+		SerialNumber: pd.SerialNumber,
+		sizeString:   pd.SizeString(),
+	}
+}
+
+func raidRecords(ctls []*model.RAIDController) []*raidRecord {
+	var rs []*raidRecord
+	for _, ctl := range ctls {
+		for _, ld := range ctl.LogDrives {
 			for _, pd := range ld.PhyDrives {
-				tbl.append(ld.Name, ld.RAIDLv, ctl.AdapterID, pd.Pos(), pd.Status, pd.SizeString(), pd.FormSummary(), pd.Model)
+				rs = append(rs, newRAIDRecord(ld.Name, ld.RAIDLv, ctl.AdapterID, pd))
 			}
 		}
 
 		for _, pd := range ctl.UnconfDrives {
-			tbl.append("", "unconf", ctl.AdapterID, pd.Pos(), pd.Status, pd.SizeString(), pd.FormSummary(), pd.Model)
+			rs = append(rs, newRAIDRecord("", "unconf", ctl.AdapterID, pd))
 		}
 
 		for _, pd := range ctl.PassthroughDrives {
-			tbl.append(pd.Name, "Pass-Through", ctl.AdapterID, pd.Pos(), pd.Status, pd.SizeString(), pd.FormSummary(), pd.Model)
+			rs = append(rs, newRAIDRecord(pd.Name, "Pass-Through", ctl.AdapterID, pd))
 		}
 	}
-	tbl.print()
-
-	return nil
+	return rs
 }
 
 func strSliceToIntfSlice(in []string) []interface{} {
@@ -13,96 +13,150 @@ func lssn() error {
 	loglet.SetLevel(loglet.INFO)
 
 	cli := newAppWithoutCmd(os.Args)
+	appendFormatFlags(cli)
+	appendConfigFlag(cli)
 	err := cli.parse()
 	if err != nil {
 		return err
 	}
 
+	format, err := outputFormat(cli)
+	if err != nil {
+		return err
+	}
+
 	var r *model.Report
 	r, err = decode(cli)
-
-	if err != nil {
+	if r == nil {
 		return err
 	}
+	// components decoded are listed anyway
+	if err != nil {
+		log.Warn(err.Error())
+	}
 
-	tbl := newTable("Catagory", "Model", "Serial Number", "Location", "Spec")
+	sns := new(serials)
 
 	if r.Processor != nil {
-		writeDownProcessorSerialNumber(tbl, r.Processor)
+		writeDownProcessorSerialNumber(sns, r.Processor)
 	}
 
 	if r.Memory != nil {
-		writeDownMemorySerialNumber(tbl, r.Memory)
+		writeDownMemorySerialNumber(sns, r.Memory)
 	}
 
 	if r.Storage != nil {
-		writeDownStorageSerialNumber(tbl, r.Storage)
+		writeDownStorageSerialNumber(sns, r.Storage)
 	}
 
 	if r.Network != nil {
-		writeDownNetworkSerialNumber(tbl, r.Network)
+		writeDownNetworkSerialNumber(sns, r.Network)
+	}
+
+	writeDownSystemSerialNumber(sns, r)
+
+	switch format {
+	case jsonFormat:
+		if sns.records == nil {
+			sns.records = []*serialRecord{}
+		}
+		return printJSON(sns.records)
+	case csvFormat:
+		var rows [][]string
+		for _, s := range sns.records {
+			rows = append(rows, []string{s.Category, s.Type, s.Model, s.SerialNumber, s.Location, s.Spec})
+		}
+		return printCSV([]string{"category", "type", "model", "serialNumber", "location", "spec"}, rows)
 	}
 
-	writeDownSystemSerialNumber(tbl, r)
-	tbl.print()
+	t := newTable("Catagory", "Model", "Serial Number", "Location", "Spec")
+	for _, s := range sns.records {
+		t.append(s.Category, s.Model, s.SerialNumber, s.Location, s.Spec)
+	}
+	t.print()
 
 	return nil
 }
 
-func writeDownSystemSerialNumber(tbl *table, r *model.Report) {
+// serialRecord represents a component listed by lssn
+type serialRecord struct {
+	Category     string `json:"category"`
+	Type         string `json:"type"`
+	Model        string `json:"model"`
+	SerialNumber string `json:"serialNumber"`
+	Location     string `json:"location"`
+	Spec         string `json:"spec"`
+}
+
+type serials struct {
+	records []*serialRecord
+}
+
+func (s *serials) append(cat, typ, model, sn, loc, spec string) {
+	s.records = append(s.records, &serialRecord{
+		Category:     cat,
+		Type:         typ,
+		Model:        model,
+		SerialNumber: sn,
+		Location:     loc,
+		Spec:         spec,
+	})
+}
+
+func writeDownSystemSerialNumber(sns *serials, r *model.Report) {
 	if r.System != nil {
 		model := fmt.Sprintf("%s %s", r.System.Manufacturer, r.System.ProductName)
-		tbl.append("System", model, r.System.SerialNumber, "", "")
+		sns.append("System", "system", model, r.System.SerialNumber, "", "")
 	}
 
 	if r.Chassis != nil {
-		tbl.append("Chassis", "", r.Chassis.SerialNumber, "", "")
+		sns.append("Chassis", "chassis", "", r.Chassis.SerialNumber, "", "")
 	}
 
 	if r.Baseboard != nil {
 		model := fmt.Sprintf("%s %s", r.Baseboard.Manufacturer, r.Baseboard.ProductName)
-		tbl.append("Baseboard", model, r.Baseboard.SerialNumber, "", "")
+		sns.append("Baseboard", "baseboard", model, r.Baseboard.SerialNumber, "", "")
 	}
 
-	for _, p := range r.PowerSupply {
+	for i, p := range r.PowerSupply {
 		model := fmt.Sprintf("%s %s", p.Manufacturer, p.ProductName)
 		spec := fmt.Sprintf("%dW", p.Capacity)
-		tbl.append("PSU", model, p.SerialNumber, "", spec)
+		sns.append("PSU", "psu", model, p.SerialNumber, fmt.Sprintf("PSU%d", i), spec)
 	}
 }
 
-func writeDownProcessorSerialNumber(tbl *table, r *model.ProcessorReport) {
+func writeDownProcessorSerialNumber(sns *serials, r *model.ProcessorReport) {
 	for _, p := range r.Packages {
 		spec := fmt.Sprintf("%dcores, %dthreads", p.CoreCount, p.ThreadCount)
-		tbl.append("Processor", p.ProductName, p.SerialNumber, p.Socket, spec)
+		sns.append("Processor", "processor", p.ProductName, p.SerialNumber, p.Socket, spec)
 	}
 }
 
-func writeDownMemorySerialNumber(tbl *table, r *model.MemoryReport) {
+func writeDownMemorySerialNumber(sns *serials, r *model.MemoryReport) {
 	for _, m := range r.Modules {
 		spec := m.Spec()
 		model := fmt.Sprintf("%s %s", m.Manufacturer, m.PartNumber)
-		tbl.append("Memory", model, m.SerialNumber, m.Locator, spec)
+		sns.append("Memory", "memory", model, m.SerialNumber, m.Locator, spec)
 	}
 }
 
-func writeDownNVMeSerialNumber(tbl *table, c *model.NVMeController) {
+func writeDownNVMeSerialNumber(sns *serials, c *model.NVMeController) {
 	spec := fmt.Sprintf("NVMe SSD %s", c.SizeString())
 	model := fmt.Sprintf("%s %s", c.VendorName, c.Model)
-	tbl.append("Storage", model, c.SerialNumber, "", spec)
+	sns.append("Storage", "nvme", model, c.SerialNumber, c.PCIID(), spec)
 }
 
-func writeDownPhyDriveSerialNumber(tbl *table, d *model.PhyDrive) {
+func writeDownPhyDriveSerialNumber(sns *serials, d *model.PhyDrive) {
 	media := "HDD"
 	if d.SolidStateDrive {
 		media = "SSD"
 	}
 	spec := fmt.Sprintf("%s %s %s", d.Transport, media, d.SizeString())
 	location := fmt.Sprintf("Enc %s - Slot %s", d.Enclosure, d.Slot)
-	tbl.append("Storage", d.Model, d.SerialNumber, location, spec)
+	sns.append("Storage", "phydrive", d.Model, d.SerialNumber, location, spec)
 }
 
-func writeDownDriveSerialNumber(tbl *table, d *model.Drive) {
+func writeDownDriveSerialNumber(sns *serials, d *model.Drive) {
 	media := ""
 	if d.IsHDD() {
 		media = "HDD"
@@ -111,40 +165,40 @@ func writeDownDriveSerialNumber(tbl *table, d *model.Drive) {
 	}
 	transport := strings.Split(d.Transport, " ")[0]
 	spec := fmt.Sprintf("%s %s %s", transport, media, d.SizeString())
-	tbl.append("Storage", d.Model, d.SerialNumber, "", spec)
+	sns.append("Storage", "drive", d.Model, d.SerialNumber, d.Name, spec)
 }
 
-func writeDownStorageSerialNumber(tbl *table, r *model.StorageReport) {
+func writeDownStorageSerialNumber(sns *serials, r *model.StorageReport) {
 	for _, c := range r.NVMeControllers {
-		writeDownNVMeSerialNumber(tbl, c)
+		writeDownNVMeSerialNumber(sns, c)
 	}
 
 	for _, c := range r.RAIDControllers {
-		tbl.append("RAID Card", c.ProductName, c.SerialNumber, "", "")
+		sns.append("RAID Card", "raid", c.ProductName, c.SerialNumber, c.PCIID(), "")
 		for _, ld := range c.LogDrives {
 			for _, pd := range ld.PhyDrives {
-				writeDownPhyDriveSerialNumber(tbl, pd)
+				writeDownPhyDriveSerialNumber(sns, pd)
 			}
 		}
 
 		for _, pd := range c.UnconfDrives {
-			writeDownPhyDriveSerialNumber(tbl, pd)
+			writeDownPhyDriveSerialNumber(sns, pd)
 		}
 
 		for _, pd := range c.PassthroughDrives {
-			writeDownPhyDriveSerialNumber(tbl, pd)
+			writeDownPhyDriveSerialNumber(sns, pd)
 		}
 	}
 
 	for _, c := range r.AHCIControllers {
 		for _, d := range c.Drives {
-			writeDownDriveSerialNumber(tbl, d)
+			writeDownDriveSerialNumber(sns, d)
 		}
 	}
 }
 
-func writeDownNetworkSerialNumber(tbl *table, r *model.NetworkReport) {
+func writeDownNetworkSerialNumber(sns *serials, r *model.NetworkReport) {
 	for _, c := range r.EthControllers {
-		tbl.append("Network", c.LongName(), c.SerialNumber, "", "")
+		sns.append("Network", "nic", c.LongName(), c.SerialNumber, c.PCIID(), "")
 	}
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moxspec/moxspec/metrics"
+	"github.com/moxspec/moxspec/model"
+)
+
+func writeMetrics(cli *app) error {
+	out := cli.getString("o")
+	format := cli.getString("format")
+	switch format {
+	case "":
+		format = metrics.OpenMetrics
+		// the textfile collector reads the Prometheus text format
+		if out != "" {
+			format = metrics.Prometheus
+		}
+	case metrics.OpenMetrics, metrics.Prometheus:
+	default:
+		return fmt.Errorf("unknown format: %s (available: %s)", format, strings.Join(metrics.Formats(), ", "))
+	}
+
+	var r *model.Report
+	var err error
+	if p := cli.getString("report"); p != "" {
+		r, err = loadReport(p)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err = decode(cli)
+		if r == nil {
+			return err
+		}
+		// partial metrics are better than none
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	if out == "" {
+		return metrics.WriteFormat(os.Stdout, r, format)
+	}
+
+	// the textfile collector may read the file at any moment, it is replaced at once
+	f, err := ioutil.TempFile(filepath.Dir(out), "."+filepath.Base(out))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = metrics.WriteFormat(f, r, format)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Chmod(0644)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), out)
+}
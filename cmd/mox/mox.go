@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/moxspec/moxspec/cmdrec"
+	"github.com/moxspec/moxspec/config"
+	"github.com/moxspec/moxspec/diag"
+	"github.com/moxspec/moxspec/extension"
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/metrics"
 	"github.com/moxspec/moxspec/model"
-	"github.com/moxspec/moxspec/pci"
-	"github.com/moxspec/moxspec/smbios"
+	"github.com/moxspec/moxspec/mox"
+	"github.com/moxspec/moxspec/push"
+	"github.com/moxspec/moxspec/util"
 )
 
 var (
@@ -29,21 +38,18 @@ func main() {
 
 	switch runAs {
 	case "lsraid":
-		rootOrExit()
 		err := lsraid()
 		if err != nil {
 			log.Fatal(err)
 		}
 		return
 	case "lsdiag":
-		rootOrExit()
 		exitCode, err := lsdiag()
 		if err != nil {
 			log.Fatal(err)
 		}
 		os.Exit(exitCode)
 	case "lssn":
-		rootOrExit()
 		err := lssn()
 		if err != nil {
 			log.Fatal(err)
@@ -58,10 +64,65 @@ func main() {
 	}
 
 	cli.appendFlag("d", false, "show verbose log")
+	appendConfigFlag(cli)
 	cli.appendFlag("noraidcli", false, "disable running RAID utilities")
+	cli.appendFlag("timeout", "", "limit the whole decoding time (e.g. 2m)")
+	cli.appendFlag("timeouts", "", "limit the decoding time per section (e.g. storage=30s,bmc=10s)")
+	cli.appendFlag("workers", "", "limit the number of devices decoded at once")
+	cli.appendFlag("only", "", "decode only the given sections (e.g. storage,network)")
+	cli.appendFlag("skip", "", "skip decoding the given sections (e.g. bmc,accelerator)")
+	cli.appendFlag("root", "", "decode a captured sysfs/procfs/dev tree under the given directory")
+	cli.appendFlag("record", "", "record external commands to fixtures under the given directory")
+	cli.appendFlag("replay", "", "replay external commands from fixtures under the given directory")
+	cli.appendFlag("plugins", "", fmt.Sprintf("run plugins in the given directory (default %s)", extension.DefaultDir))
 	switch cli.cmd {
 	case "show":
 		cli.appendFlag("j", false, "print json")
+		cli.appendFlag("redact", false, "replace serial numbers, MACs, IP addresses and the hostname with tokens")
+		cli.appendFlag("redactkey", "", "derive tokens with the given key so that they can not be guessed")
+	case "collect":
+		cli.appendFlag("o", "bundle.tar.gz", "write the bundle to the given path")
+	case "diff":
+		cli.appendFlag("j", false, "print json")
+	case "get":
+		cli.appendFlag("j", false, "print json")
+		cli.appendFlag("report", "", "read the given report instead of decoding this host")
+	case "aggregate":
+		appendFormatFlags(cli)
+		cli.appendFlag("table", "", "aggregate components in the given table (e.g. nics, dimms, drives)")
+		cli.appendFlag("by", "", "group components by the given columns (e.g. parent.deviceName,firmwareVersion)")
+	case "export":
+		cli.appendFlag("format", csvFormat, "write tables in the given format (csv)")
+		cli.appendFlag("dir", "", "write tables under the given directory")
+		cli.appendFlag("report", "", "export the given report instead of decoding this host")
+	case "metrics":
+		cli.appendFlag("o", "", "write the metrics to the given path instead of stdout")
+		cli.appendFlag("format", "", fmt.Sprintf("write in the given format (%s), default prometheus with -o for the textfile collector", strings.Join(metrics.Formats(), ", ")))
+		cli.appendFlag("report", "", "convert the given report instead of decoding this host")
+	case "serve":
+		cli.appendFlag("listen", ":9393", "listen on the given address")
+		cli.appendFlag("interval", "", "refresh counters at the given interval (default 1m)")
+		cli.appendFlag("fullinterval", "", "collect the whole inventory at the given interval (default 1h)")
+		cli.appendFlag("policy", "", fmt.Sprintf("diagnose with the given policy (default %s if exists)", diag.DefaultPolicyPath))
+	case "push":
+		cli.appendFlag("url", "", "push to the given URL")
+		cli.appendFlag("diag", false, "push the diagnosis instead of the report")
+		cli.appendFlag("policy", "", fmt.Sprintf("diagnose with the given policy (default %s if exists)", diag.DefaultPolicyPath))
+		cli.appendFlag("state", "", fmt.Sprintf("keep counters in the given file shared with lsdiag to check deltas (default %s if the policy has deltas)", diag.DefaultStatePath))
+		cli.appendFlag("report", "", "push the given report instead of decoding this host")
+		cli.appendFlag("headers", "", "add the given headers (e.g. X-Site=tokyo,X-Rack=r01)")
+		cli.appendFlag("token", "", "send the token in the given file as a bearer token")
+		cli.appendFlag("cacert", "", "verify the collector with the given CA certificate")
+		cli.appendFlag("cert", "", "authenticate with the given client certificate")
+		cli.appendFlag("key", "", "authenticate with the given client key")
+		cli.appendFlag("retries", "", fmt.Sprintf("retry the given times (default %d)", push.DefaultRetries))
+		cli.appendFlag("backoff", "", fmt.Sprintf("wait the given duration before the first retry, doubled on every retry (default %s)", push.DefaultBackoff))
+		cli.appendFlag("spool", "", fmt.Sprintf("keep what could not be pushed under the given directory (default %s)", push.DefaultSpoolDir))
+		cli.appendFlag("nospool", false, "do not keep what could not be pushed")
+	case "verify":
+		cli.appendFlag("j", false, "print json")
+		cli.appendFlag("spec", "", "verify against the given spec file")
+		cli.appendFlag("report", "", "verify the given report instead of decoding this host")
 	}
 
 	err = cli.parse()
@@ -77,8 +138,37 @@ func main() {
 
 	switch cli.cmd {
 	case "show":
-		rootOrExit()
 		err = show(cli)
+	case "collect":
+		err = collect(cli)
+	case "diff":
+		exitCode, err := diff(cli)
+		if err != nil {
+			loglet.SetOutput(os.Stderr)
+			log.Error(err)
+		}
+		os.Exit(exitCode)
+	case "get":
+		err = get(cli)
+	case "aggregate":
+		err = aggregateReports(cli)
+	case "export":
+		err = exportTables(cli)
+	case "metrics":
+		err = writeMetrics(cli)
+	case "serve":
+		err = serve(cli)
+	case "push":
+		err = pushReport(cli)
+	case "verify":
+		exitCode, err := verify(cli)
+		if err != nil {
+			loglet.SetOutput(os.Stderr)
+			log.Error(err)
+		}
+		os.Exit(exitCode)
+	case "schema":
+		err = printSchema()
 	case "version":
 		showVersion()
 	default:
@@ -95,50 +185,125 @@ func main() {
 }
 
 func decode(cli *app) (*model.Report, error) {
-	spec := smbios.NewDecoder()
-	pcidevs := pci.NewDecoder()
+	opts, err := collectOptions(cli)
+	if err != nil {
+		return nil, err
+	}
+
+	return mox.Collect(context.Background(), opts)
+}
+
+// collectOptions builds options for mox.Collect from the global flags
+// the configuration gives the defaults, flags take precedence over it
+// users other than root get a report without what requires privileges
+func collectOptions(cli *app) (mox.Options, error) {
+	cf, err := loadConfig(cli)
+	if err != nil {
+		return mox.Options{}, err
+	}
+	cf = cf.ForVendor(config.Vendor(cli.getString("root")))
 
-	decoders := []Decoder{
-		spec,
-		pcidevs,
+	opts := mox.Options{
+		NoRAIDCLI:    cli.getBool("noraidcli") || cf.Disabled(config.RAIDCLI),
+		Root:         cli.getString("root"),
+		Unprivileged: os.Geteuid() != 0,
+		Paths:        cf.Paths,
+		PluginDir:    cf.Plugins,
+		Timeout:      cf.Timeout,
+		Workers:      cf.Workers,
+		Version:      versionString(),
+	}
+	if d := cli.getString("plugins"); d != "" {
+		opts.PluginDir = d
+	}
+
+	if d := cli.getString("replay"); d != "" {
+		opts.Runner = cmdrec.NewReplayer(d)
+	}
+	if d := cli.getString("record"); d != "" {
+		if opts.Runner == nil {
+			opts.Runner = util.CurrentRunner()
+		}
+		opts.Runner = cmdrec.NewRecorder(opts.Runner, d)
 	}
-	for _, d := range decoders {
-		err := d.Decode()
+
+	if t := cli.getString("timeout"); t != "" {
+		opts.Timeout, err = time.ParseDuration(t)
 		if err != nil {
-			return nil, err
+			return opts, fmt.Errorf("invalid timeout: %s", err)
 		}
 	}
 
-	r := new(model.Report)
-	shapeSystem(r, spec.GetSystem())
-	shapeChassis(r, spec.GetChassis())
-	shapeFirmware(r, spec.GetBIOS())
-	shapeBaseboard(r, spec.GetBaseboard())
-	shapeProcessor(r, spec.GetProcessor())
-	shapeMemory(r, spec.GetMemoryDevice())
-	shapeDisk(r, pcidevs, cli)
-	shapeNetwork(r, pcidevs)
-	shapeAccelerater(r, pcidevs)
-	shapePowerSupply(r, spec.GetPowerSupply())
-	shapeAllPCIDevices(r, pcidevs)
-	shapeBMC(r)
-	shapeMisc(r)
-
-	r.Version = versionString()
-
-	tm := time.Now()
-	r.Timestamp = tm.Unix()
-	r.Datetime = tm.Format(time.RFC1123Z)
-
-	return r, nil
+	ts, err := parseTimeouts(cli.getString("timeouts"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Timeouts = cf.Timeouts
+	for sec, t := range ts {
+		opts.Timeouts[sec] = t
+	}
+
+	opts.Sections, err = mox.ParseSections(cli.getString("only"))
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Skip, err = mox.ParseSections(cli.getString("skip"))
+	if err != nil {
+		return opts, err
+	}
+	// sections disabled in the configuration can still be decoded with -only
+	for _, sec := range cf.DisabledSections() {
+		if !containsSection(opts.Sections, sec) {
+			opts.Skip = append(opts.Skip, sec)
+		}
+	}
+
+	if w := cli.getString("workers"); w != "" {
+		opts.Workers, err = strconv.Atoi(w)
+		if err != nil || opts.Workers < 1 {
+			return opts, fmt.Errorf("invalid workers: %s", w)
+		}
+	}
+
+	return opts, nil
 }
 
-func rootOrExit() {
-	uid := os.Getuid()
-	if uid > 0 {
-		fmt.Println("must be root")
-		os.Exit(1)
+func containsSection(ss []mox.Section, s mox.Section) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
 	}
+	return false
+}
+
+// parseTimeouts parses a comma separated list such as "storage=30s,bmc=10s"
+func parseTimeouts(in string) (map[mox.Section]time.Duration, error) {
+	if in == "" {
+		return nil, nil
+	}
+
+	ts := make(map[mox.Section]time.Duration)
+	for _, kv := range strings.Split(in, ",") {
+		flds := strings.SplitN(kv, "=", 2)
+		if len(flds) != 2 {
+			return nil, fmt.Errorf("invalid timeout: %s", kv)
+		}
+
+		sec, err := mox.ParseSection(flds[0])
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(flds[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for %s: %s", sec, err)
+		}
+		ts[sec] = d
+	}
+
+	return ts, nil
 }
 
 func showHelp() {
@@ -149,12 +314,38 @@ func showHelp() {
 	fmt.Println("  mox command [command options] [arguments...]")
 	fmt.Println()
 	fmt.Println("COMMANDS:")
-	fmt.Println("  show")
+	fmt.Println("  show     print the report (-j json, -redact hide serial numbers, MACs, IP addresses and the hostname)")
+	fmt.Println("  collect  write raw hardware data and the report to a bundle (-o bundle.tar.gz)")
+	fmt.Println("  diff     compare two reports and print added, removed and changed components (old.json new.json)")
+	fmt.Println("           exits with 0 if nothing changed, 1 if something changed, 2 on error")
+	fmt.Println("  get      print values at a path of the report (e.g. bmc.ipaddr, storage.nvmeControllers[*].serialNumber)")
+	fmt.Println("  aggregate")
+	fmt.Println("           count components over many reports (-table nics -by firmwareVersion dir/, or reports on stdin)")
+	fmt.Println("  export   write a table per kind of component (-format csv -dir out/, -report report.json)")
+	fmt.Println("  metrics  print health and wear counters as OpenMetrics text (-o file.prom, -format prometheus, -report report.json)")
+	fmt.Println("  serve    serve the report over HTTP and keep it up to date (-listen :9393)")
+	fmt.Println("  push     send the report to an HTTP collector, spooled if it is down (-url https://collector/mox, -diag)")
+	fmt.Println("  verify   check this host against a golden hardware spec (-spec spec.yaml, -report report.json)")
+	fmt.Println("           exits with 0 if the spec is satisfied, 1 on mismatch, 2 on error")
+	fmt.Println("  schema   print the JSON Schema of the report printed by show -j")
 	fmt.Println("  version")
 	fmt.Println("  help")
 	fmt.Println()
 	fmt.Println("GLOBAL OPTIONS:")
 	fmt.Println("  -d,--debug   enabling debug logging")
+	fmt.Printf("  -config      read the configuration from the given path (default $%s or %s)\n", config.PathEnv, config.DefaultPath)
+	fmt.Println("  -timeout     limit the whole decoding time (e.g. 2m)")
+	fmt.Println("  -timeouts    limit the decoding time per section (e.g. storage=30s,bmc=10s)")
+	fmt.Println("  -workers     limit the number of devices decoded at once")
+	fmt.Println("  -only        decode only the given sections (e.g. storage,network)")
+	fmt.Println("  -skip        skip decoding the given sections (e.g. bmc,accelerator)")
+	fmt.Println("  -root        decode a captured sysfs/procfs/dev tree under the given directory")
+	fmt.Println("  -record      record external commands to fixtures under the given directory")
+	fmt.Println("  -replay      replay external commands from fixtures under the given directory")
+	fmt.Printf("  -plugins     run plugins in the given directory (default %s)\n", extension.DefaultDir)
+	fmt.Println()
+	fmt.Println("SECTIONS:")
+	fmt.Println("  system, processor, memory, storage, network, accelerator, powersupply, bmc, pci, extensions")
 }
 
 func showVersion() {
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// These are output formats of lsdiag, lssn and lsraid
+const (
+	tableFormat = "table"
+	jsonFormat  = "json"
+	csvFormat   = "csv"
+)
+
+func appendFormatFlags(cli *app) {
+	cli.appendFlag("j", false, "print json")
+	cli.appendFlag("csv", false, "print csv")
+}
+
+// outputFormat returns the format given by the flags, or the one in the configuration
+func outputFormat(cli *app) (string, error) {
+	j := cli.getBool("j")
+	c := cli.getBool("csv")
+
+	switch {
+	case j && c:
+		return "", fmt.Errorf("-j and -csv can not be used together")
+	case j:
+		return jsonFormat, nil
+	case c:
+		return csvFormat, nil
+	}
+
+	cf, err := loadConfig(cli)
+	if err != nil {
+		return "", err
+	}
+	if cf.Format != "" {
+		return cf.Format, nil
+	}
+	return tableFormat, nil
+}
+
+// jsonOutput returns true if -j is given or the configuration prefers json
+// for the commands printing either json or a text
+func jsonOutput(cli *app) (bool, error) {
+	if cli.getBool("j") {
+		return true, nil
+	}
+
+	cf, err := loadConfig(cli)
+	if err != nil {
+		return false, err
+	}
+	return cf.Format == jsonFormat, nil
+}
+
+func printJSON(v interface{}) error {
+	jb, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jb))
+	return nil
+}
+
+func printCSV(header []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write(header)
+	if err != nil {
+		return err
+	}
+	err = w.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return w.Error()
+}
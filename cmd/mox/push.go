@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/moxspec/moxspec/config"
+	"github.com/moxspec/moxspec/diag"
+	"github.com/moxspec/moxspec/export"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/push"
+)
+
+// diagPush is pushed with -diag, the diagnosis alone does not tell which host it is of
+type diagPush struct {
+	Hostname  string          `json:"hostname,omitempty"`
+	Host      string          `json:"host"` // the system serial number, or the hostname
+	Version   string          `json:"version"`
+	Timestamp int64           `json:"timestamp"`
+	Datetime  string          `json:"datetime"`
+	Diagnosis *diag.Diagnosis `json:"diagnosis"`
+}
+
+func pushReport(cli *app) error {
+	opts, err := pushOptions(cli)
+	if err != nil {
+		return err
+	}
+
+	p, err := push.New(opts)
+	if err != nil {
+		return err
+	}
+
+	var r *model.Report
+	if path := cli.getString("report"); path != "" {
+		r, err = loadReport(path)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err = decode(cli)
+		if r == nil {
+			return err
+		}
+		// the collector can tell a partial report from its errors
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	kind := push.ReportKind
+	var body interface{} = r
+	if cli.getBool("diag") {
+		policy, err := loadDiagPolicy(cli)
+		if err != nil {
+			return err
+		}
+
+		// deltas are taken since the previous diagnosis of lsdiag or push
+		d, err := diagnose(policy, r, cli.getString("state"))
+		if err != nil {
+			return err
+		}
+
+		kind = push.DiagKind
+		body = &diagPush{
+			Hostname:  r.Hostname,
+			Host:      export.HostKey(r),
+			Version:   r.Version,
+			Timestamp: r.Timestamp,
+			Datetime:  r.Datetime,
+			Diagnosis: d,
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	err = p.Push(ctx, kind, export.HostKey(r), body)
+	if err != nil {
+		return err
+	}
+	log.Infof("pushed the %s to %s", kind, opts.URL)
+	return nil
+}
+
+// pushOptions builds options for push.New from the configuration and the flags over it
+func pushOptions(cli *app) (push.Options, error) {
+	cf, err := loadConfig(cli)
+	if err != nil {
+		return push.Options{}, err
+	}
+	pc := cf.Push
+	if pc == nil {
+		pc = new(config.Push)
+	}
+
+	opts := push.Options{
+		URL:       pc.URL,
+		Headers:   make(map[string]string),
+		TokenFile: pc.TokenFile,
+		CACert:    pc.CACert,
+		Cert:      pc.Cert,
+		Key:       pc.Key,
+		Retries:   pc.Retries,
+		Backoff:   pc.Backoff,
+		SpoolDir:  push.DefaultSpoolDir,
+	}
+	for name, v := range pc.Headers {
+		opts.Headers[name] = v
+	}
+	if pc.Spool != "" {
+		opts.SpoolDir = pc.Spool
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  *string
+	}{
+		{"url", &opts.URL},
+		{"token", &opts.TokenFile},
+		{"cacert", &opts.CACert},
+		{"cert", &opts.Cert},
+		{"key", &opts.Key},
+		{"spool", &opts.SpoolDir},
+	} {
+		if v := cli.getString(f.name); v != "" {
+			*f.dst = v
+		}
+	}
+
+	hs, err := parseHeaders(cli.getString("headers"))
+	if err != nil {
+		return opts, err
+	}
+	for name, v := range hs {
+		opts.Headers[name] = v
+	}
+
+	if r := cli.getString("retries"); r != "" {
+		opts.Retries, err = strconv.Atoi(r)
+		if err != nil || opts.Retries < 0 {
+			return opts, fmt.Errorf("invalid retries: %s", r)
+		}
+		// zero means the default in push.Options
+		if opts.Retries == 0 {
+			opts.Retries = -1
+		}
+	}
+
+	if b := cli.getString("backoff"); b != "" {
+		opts.Backoff, err = time.ParseDuration(b)
+		if err != nil {
+			return opts, fmt.Errorf("invalid backoff: %s", err)
+		}
+	}
+
+	if cli.getBool("nospool") {
+		opts.SpoolDir = ""
+	}
+
+	return opts, nil
+}
+
+// parseHeaders parses a comma separated list such as "X-Site=tokyo,X-Rack=r01"
+func parseHeaders(in string) (map[string]string, error) {
+	if in == "" {
+		return nil, nil
+	}
+
+	hs := make(map[string]string)
+	for _, kv := range strings.Split(in, ",") {
+		flds := strings.SplitN(kv, "=", 2)
+		if len(flds) != 2 || strings.TrimSpace(flds[0]) == "" {
+			return nil, fmt.Errorf("invalid header: %s", kv)
+		}
+		hs[strings.TrimSpace(flds[0])] = strings.TrimSpace(flds[1])
+	}
+
+	return hs, nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+
+	"github.com/moxspec/moxspec/schema"
+)
+
+func printSchema() error {
+	b, err := schema.JSON()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/moxspec/moxspec/server"
+)
+
+// shutdownTimeout is how long in-flight requests are waited for on shutdown
+const shutdownTimeout = 10 * time.Second
+
+func serve(cli *app) error {
+	opts := server.Options{}
+
+	var err error
+	opts.Collect, err = collectOptions(cli)
+	if err != nil {
+		return err
+	}
+
+	opts.DiagPolicy, err = loadDiagPolicy(cli)
+	if err != nil {
+		return err
+	}
+
+	if i := cli.getString("interval"); i != "" {
+		opts.RefreshInterval, err = time.ParseDuration(i)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %s", err)
+		}
+	}
+	if i := cli.getString("fullinterval"); i != "" {
+		opts.FullInterval, err = time.ParseDuration(i)
+		if err != nil {
+			return fmt.Errorf("invalid fullinterval: %s", err)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	s := server.New(opts)
+	srv := &http.Server{
+		Addr:    cli.getString("listen"),
+		Handler: s.Handler(),
+	}
+
+	done := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Infof("listening on %s", srv.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errc:
+		cancel()
+		<-done
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("shutting down")
+	sctx, scancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer scancel()
+
+	err = srv.Shutdown(sctx)
+	<-done
+	return err
+}
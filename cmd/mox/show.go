@@ -3,9 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/redact"
 )
 
 func show(cli *app) error {
@@ -14,18 +16,28 @@ func show(cli *app) error {
 	var r *model.Report
 	r, err = decode(cli)
 
-	if err != nil {
+	// a partial report is shown along with the error
+	if r == nil {
 		return err
 	}
 
-	if cli.getBool("j") {
-		jb, err := json.Marshal(r)
-		if err != nil {
-			return err
+	if cli.getBool("redact") {
+		redact.Report(r, cli.getString("redactkey"))
+	}
+
+	j, jerr := jsonOutput(cli)
+	if jerr != nil {
+		return jerr
+	}
+
+	if j {
+		jb, jerr := json.Marshal(r)
+		if jerr != nil {
+			return jerr
 		}
 
 		fmt.Printf("%s\n", jb)
-		return nil
+		return err
 	}
 
 	p := newPrinter()
@@ -39,10 +51,12 @@ func show(cli *app) error {
 	writeDownAccelerator(r, p)
 	writeDownBMC(r, p)
 	writeDownPowerSupply(r, p)
+	writeDownExtensions(r, p)
 	writeDownPlatform(r, p)
+	writeDownErrors(r, p)
 	p.show()
 
-	return nil
+	return err
 }
 
 func writeDownSystem(r *model.Report, p *printer) {
@@ -82,6 +96,11 @@ func writeDownBaseboard(r *model.Report, p *printer) {
 }
 
 func writeDownProcessor(r *model.Report, p *printer) {
+	if r.Processor == nil || len(r.Processor.Packages) == 0 {
+		log.Debug("could not decode processor information")
+		return
+	}
+
 	s := newSection("Processor")
 	s.block.append(r.Processor.Summary())
 	p.append(s)
@@ -115,6 +134,11 @@ func writeDownProcessor(r *model.Report, p *printer) {
 }
 
 func writeDownMemory(r *model.Report, p *printer) {
+	if r.Memory == nil {
+		log.Debug("could not decode memory information")
+		return
+	}
+
 	s := newSection("Memory")
 	s.block.appendf("Total: %s", r.Memory.TotalString())
 	s.block.append(newIndentedBlock(r.Memory.ModuleSummaries()))
@@ -132,6 +156,10 @@ func writeDownMemory(r *model.Report, p *printer) {
 }
 
 func writeDownDisk(r *model.Report, p *printer) {
+	if r.Storage == nil {
+		return
+	}
+
 	s := newSection("Disk")
 
 	if r.Storage.AHCIControllers != nil {
@@ -362,7 +390,7 @@ func writeDownNonStdControllers(r *model.Report, s *section) {
 }
 
 func writeDownNetwork(r *model.Report, p *printer) {
-	if r.Network.EthControllers == nil {
+	if r.Network == nil || r.Network.EthControllers == nil {
 		return
 	}
 
@@ -490,10 +518,38 @@ func writeDownBMC(r *model.Report, p *printer) {
 	p.append(s)
 }
 
+func writeDownExtensions(r *model.Report, p *printer) {
+	if len(r.Extensions) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range r.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := newSection("Extensions")
+	for _, name := range names {
+		s.block.append(name)
+		sb := new(block)
+		for _, dg := range r.Extensions[name].Diag {
+			sb.appendf("%s: %s", dg.ID, dg.Status)
+			if len(dg.Details) > 0 {
+				sb.append(newIndentedBlock(dg.Details))
+			}
+		}
+		s.block.append(sb)
+	}
+	p.append(s)
+}
+
 func writeDownPlatform(r *model.Report, p *printer) {
-	sos := newSection("OS")
-	sos.block.appendf("%s, %s", r.OS.Distro, r.OS.Kernel)
-	p.append(sos)
+	if r.OS != nil {
+		sos := newSection("OS")
+		sos.block.appendf("%s, %s", r.OS.Distro, r.OS.Kernel)
+		p.append(sos)
+	}
 
 	cl := newSection("Client")
 	cl.block.appendf("v%s", r.Version)
@@ -504,6 +560,18 @@ func writeDownPlatform(r *model.Report, p *printer) {
 	p.append(sh)
 }
 
+func writeDownErrors(r *model.Report, p *printer) {
+	if len(r.Errors) == 0 {
+		return
+	}
+
+	s := newSection("Errors")
+	for _, e := range r.Errors {
+		s.block.appendf("%s: %s", e.Component, e.Summary())
+	}
+	p.append(s)
+}
+
 func writeDownLastUpdate(r *model.Report, p *printer) {
 	lu := newSection("Last Update")
 	lu.block.appendf("%s", r.Datetime)
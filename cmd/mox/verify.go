@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/spec"
+)
+
+const (
+	exitVerified    = 0
+	exitMismatch    = 1
+	exitVerifyError = 2
+)
+
+func verify(cli *app) (int, error) {
+	path := cli.getString("spec")
+	if path == "" {
+		return exitVerifyError, fmt.Errorf("usage: mox verify -spec spec.yaml [-report report.json]")
+	}
+
+	s, err := spec.Load(path)
+	if err != nil {
+		return exitVerifyError, err
+	}
+
+	var r *model.Report
+	if rp := cli.getString("report"); rp != "" {
+		r, err = loadReport(rp)
+		if err != nil {
+			return exitVerifyError, err
+		}
+	} else {
+		r, err = decode(cli)
+		if r == nil {
+			return exitVerifyError, err
+		}
+		// components which could not be decoded fail their checks
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	res := s.Verify(r)
+
+	j, err := jsonOutput(cli)
+	if err != nil {
+		return exitVerifyError, err
+	}
+
+	if j {
+		jb, err := json.Marshal(res)
+		if err != nil {
+			return exitVerifyError, err
+		}
+		fmt.Println(string(jb))
+	} else {
+		tbl := newTable("check", "stat", "detail")
+		for _, o := range res.Outcomes {
+			stat := "pass"
+			if !o.Pass {
+				stat = "FAIL"
+			}
+			tbl.append(o.Check, stat, o.Detail)
+		}
+		tbl.print()
+	}
+
+	if !res.Passed() {
+		return exitMismatch, nil
+	}
+	return exitVerified, nil
+}
@@ -0,0 +1,182 @@
+// Package cmdrec records external commands run by the decoders to fixture files and replays them
+//
+// Fixtures share the layout of a support bundle written by mox collect:
+//
+//	cmd/<command>/<args>.txt    stdout
+//	cmd/<command>/<args>.json   the invocation (command, stderr, exit code), optional
+//
+// where <args> are the arguments escaped like a url and joined with "_" (see capture.CommandPath),
+// so that the directory a bundle is extracted to is replayed as it is.
+package cmdrec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moxspec/moxspec/capture"
+	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
+)
+
+var log *loglet.Logger
+
+func init() {
+	log = loglet.NewLogger("cmdrec")
+}
+
+// Invocation represents an external command which was run
+type Invocation struct {
+	Command  []string `json:"command"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ExitError is returned by a replayed command which exited with non-zero status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func stdoutPath(dir, name string, arg ...string) string {
+	return filepath.Join(dir, filepath.FromSlash(capture.CommandPath(name, arg...)))
+}
+
+func invocationPath(p string) string {
+	return strings.TrimSuffix(p, ".txt") + ".json"
+}
+
+// Recorder runs commands via another runner and writes them down as fixtures
+type Recorder struct {
+	dir    string
+	runner util.Runner
+}
+
+// NewRecorder creates and initializes a Recorder which writes fixtures under dir
+func NewRecorder(r util.Runner, dir string) *Recorder {
+	c := new(Recorder)
+	c.dir = dir
+	c.runner = r
+	return c
+}
+
+// Run makes Recorder satisfy the util.Runner interface
+func (c *Recorder) Run(ctx context.Context, name string, arg ...string) (*util.CmdResult, error) {
+	res, err := c.runner.Run(ctx, name, arg...)
+	if ctx.Err() != nil {
+		// an interrupted command is not worth replaying
+		return res, err
+	}
+
+	werr := c.write(name, arg, res, err)
+	if werr != nil {
+		log.Warnf("could not record %s: %s", name, werr)
+	}
+
+	return res, err
+}
+
+// LookPath makes Recorder satisfy the util.Runner interface
+func (c *Recorder) LookPath(file string) (string, error) {
+	return c.runner.LookPath(file)
+}
+
+func (c *Recorder) write(name string, arg []string, res *util.CmdResult, rerr error) error {
+	p := stdoutPath(c.dir, name, arg...)
+
+	err := os.MkdirAll(filepath.Dir(p), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(p, []byte(res.Stdout), 0644)
+	if err != nil {
+		return err
+	}
+
+	inv := Invocation{
+		Command:  append([]string{name}, arg...),
+		Stderr:   res.Stderr,
+		ExitCode: res.ExitCode,
+	}
+	if rerr != nil && res.ExitCode == 0 {
+		inv.Error = rerr.Error()
+	}
+
+	jb, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(invocationPath(p), jb, 0644)
+}
+
+// Replayer replays commands from fixtures instead of running them
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer creates and initializes a Replayer which reads fixtures under dir
+func NewReplayer(dir string) *Replayer {
+	p := new(Replayer)
+	p.dir = dir
+	return p
+}
+
+// Run makes Replayer satisfy the util.Runner interface
+func (p *Replayer) Run(ctx context.Context, name string, arg ...string) (*util.CmdResult, error) {
+	res := new(util.CmdResult)
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+
+	sp := stdoutPath(p.dir, name, arg...)
+	out, err := ioutil.ReadFile(sp)
+	if err != nil {
+		return res, fmt.Errorf("no fixture for %s", strings.Join(append([]string{name}, arg...), " "))
+	}
+	res.Stdout = string(out)
+
+	jb, err := ioutil.ReadFile(invocationPath(sp))
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+
+	var inv Invocation
+	err = json.Unmarshal(jb, &inv)
+	if err != nil {
+		return res, fmt.Errorf("%s: %s", invocationPath(sp), err)
+	}
+
+	res.Stderr = inv.Stderr
+	res.ExitCode = inv.ExitCode
+	if inv.ExitCode != 0 {
+		return res, &ExitError{Code: inv.ExitCode}
+	}
+	if inv.Error != "" {
+		return res, fmt.Errorf("%s", inv.Error)
+	}
+
+	return res, nil
+}
+
+// LookPath makes Replayer satisfy the util.Runner interface
+// a command is found if it has any fixtures
+func (p *Replayer) LookPath(file string) (string, error) {
+	dir := filepath.Dir(stdoutPath(p.dir, file))
+	if !util.Exists(dir) {
+		return "", fmt.Errorf("%s: no fixture", file)
+	}
+	return file, nil
+}
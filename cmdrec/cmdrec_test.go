@@ -0,0 +1,125 @@
+package cmdrec
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/moxspec/moxspec/util"
+)
+
+type fakeRunner struct {
+	results map[string]*util.CmdResult
+}
+
+func (f fakeRunner) Run(ctx context.Context, name string, arg ...string) (*util.CmdResult, error) {
+	res, ok := f.results[fmt.Sprint(append([]string{name}, arg...))]
+	if !ok {
+		return new(util.CmdResult), fmt.Errorf("exec: %q: executable file not found in $PATH", name)
+	}
+	if res.ExitCode != 0 {
+		return res, fmt.Errorf("exit status %d", res.ExitCode)
+	}
+	return res, nil
+}
+
+func (f fakeRunner) LookPath(file string) (string, error) {
+	return file, nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdrec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fake := fakeRunner{
+		results: map[string]*util.CmdResult{
+			"[ipmitool raw 0x06 0x01]":                             {Stdout: " 20 01 01 17 02 bf af 2b 00 02 02 12 00 17 00\n"},
+			"[/usr/sbin/sas3ircu 1 DISPLAY]":                       {Stdout: "SAS3IRCU: Invalid controller number.\n", Stderr: "error\n", ExitCode: 1},
+			"[/opt/MegaRAID/MegaCli/MegaCli64 -PDList -a0 -NoLog]": {Stdout: "\nAdapter #0\n\nExit Code: 0x00\n"},
+		},
+	}
+
+	tests := []struct {
+		cmd []string
+		err string
+	}{
+		{[]string{"ipmitool", "raw", "0x06", "0x01"}, ""},
+		{[]string{"/usr/sbin/sas3ircu", "1", "DISPLAY"}, "exit status 1"},
+		{[]string{"/opt/MegaRAID/MegaCli/MegaCli64", "-PDList", "-a0", "-NoLog"}, ""},
+		{[]string{"nvidia-smi", "-q", "-x"}, `exec: "nvidia-smi": executable file not found in $PATH`},
+	}
+
+	rec := NewRecorder(fake, dir)
+	rep := NewReplayer(dir)
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			ex, exerr := rec.Run(ctx, tt.cmd[0], tt.cmd[1:]...)
+			if fmt.Sprint(exerr) != fmt.Sprint(errOrNil(tt.err)) {
+				t.Fatalf("recorded error got: %v, expect: %s", exerr, tt.err)
+			}
+
+			got, err := rep.Run(ctx, tt.cmd[0], tt.cmd[1:]...)
+			if fmt.Sprint(err) != fmt.Sprint(exerr) {
+				t.Errorf("replayed error got: %v, expect: %v", err, exerr)
+			}
+			if *got != *ex {
+				t.Errorf("got: %+v, expect: %+v", got, ex)
+			}
+		})
+	}
+}
+
+func errOrNil(s string) error {
+	if s == "" {
+		return nil
+	}
+	return fmt.Errorf("%s", s)
+}
+
+func TestReplayer(t *testing.T) {
+	rep := NewReplayer("testdata")
+
+	res, err := rep.Run(context.Background(), "/usr/sbin/hpssacli", "controller", "all", "show")
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+	if res.Stdout != "\nSmart Array P420 in Slot 2                (sn: PDSXK0ARH5O18K)\n\n" {
+		t.Errorf("stdout got: %q", res.Stdout)
+	}
+
+	_, err = rep.Run(context.Background(), "/usr/sbin/hpssacli", "controller", "slot=0", "show")
+	if err == nil {
+		t.Error("error should not be nil without a fixture")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = rep.Run(ctx, "/usr/sbin/hpssacli", "controller", "all", "show")
+	if err != context.Canceled {
+		t.Errorf("error got: %v, expect: %s", err, context.Canceled)
+	}
+
+	tests := []struct {
+		file  string
+		found bool
+	}{
+		{"/usr/sbin/hpssacli", true},
+		{"hpssacli", true},
+		{"/usr/sbin/sas3ircu", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			_, err := rep.LookPath(tt.file)
+			if (err == nil) != tt.found {
+				t.Errorf("found got: %t, expect: %t", err == nil, tt.found)
+			}
+		})
+	}
+}
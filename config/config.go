@@ -0,0 +1,316 @@
+// Package config reads the configuration of mox, which gives defaults to the command line
+//
+//	paths:
+//	  ipmitool: /usr/local/bin/ipmitool
+//	  pci.ids: /opt/hwdata/pci.ids
+//	disable: [raidcli, accelerator]
+//	timeout: 2m
+//	timeouts:
+//	  storage: 30s
+//	format: json
+//	policy: /etc/mox/diag-gpu.yaml
+//	push:
+//	  url: https://collector.example.com/mox
+//	  tokenFile: /etc/mox/token
+//	vendors:
+//	  HPE:
+//	    paths:
+//	      hpacucli: /usr/sbin/ssacli
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/mox"
+	"github.com/moxspec/moxspec/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var log *loglet.Logger
+
+func init() {
+	log = loglet.NewLogger("config")
+}
+
+// DefaultPath is where the configuration is read from if exists
+const DefaultPath = "/etc/mox/mox.yaml"
+
+// PathEnv names the environment variable which gives the path of the configuration
+const PathEnv = "MOX_CONFIG"
+
+// RAIDCLI disables running RAID utilities when it is in Disable
+const RAIDCLI = "raidcli"
+
+// Formats returns the output formats
+func Formats() []string {
+	return []string{"table", "json", "csv"}
+}
+
+// Config represents the configuration
+type Config struct {
+	Paths    map[string]string             `yaml:"paths"`   // overrides tools and devices by name, see util.PathNames
+	Disable  []string                      `yaml:"disable"` // sections or raidcli
+	Timeout  time.Duration                 `yaml:"timeout"`
+	Timeouts map[mox.Section]time.Duration `yaml:"timeouts"`
+	Workers  int                           `yaml:"workers"`
+	Format   string                        `yaml:"format"` // output format of the commands printing a table or json
+	Policy   string                        `yaml:"policy"` // path to the diagnosis policy
+	Plugins  string                        `yaml:"plugins"`
+	Push     *Push                         `yaml:"push"`
+	Vendors  map[string]*Override          `yaml:"vendors"` // keyed by a prefix of the system vendor
+}
+
+// Push represents defaults of mox push, see push.Options
+type Push struct {
+	URL       string            `yaml:"url"`
+	Headers   map[string]string `yaml:"headers"`
+	TokenFile string            `yaml:"tokenFile"`
+	CACert    string            `yaml:"caCert"`
+	Cert      string            `yaml:"cert"`
+	Key       string            `yaml:"key"`
+	Retries   int               `yaml:"retries"` // negative means no retries
+	Backoff   time.Duration     `yaml:"backoff"`
+	Spool     string            `yaml:"spool"`
+}
+
+// Override represents settings applied on systems of a vendor over the others
+type Override struct {
+	Paths    map[string]string             `yaml:"paths"`
+	Disable  []string                      `yaml:"disable"`
+	Timeouts map[mox.Section]time.Duration `yaml:"timeouts"`
+}
+
+// Load reads a configuration from the file
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return c, nil
+}
+
+// LoadDefault reads the configuration at path, at $MOX_CONFIG or at DefaultPath in this order
+// the first two must exist if given, a missing DefaultPath means an empty configuration
+func LoadDefault(path string) (*Config, error) {
+	if path != "" {
+		return Load(path)
+	}
+	if path = os.Getenv(PathEnv); path != "" {
+		return Load(path)
+	}
+
+	_, err := os.Stat(DefaultPath)
+	if err == nil {
+		return Load(DefaultPath)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return new(Config), nil
+}
+
+// Parse parses a configuration written in YAML
+func Parse(b []byte) (*Config, error) {
+	c := new(Config)
+	err := yaml.UnmarshalStrict(b, c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout: negative duration: %s", c.Timeout)
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("workers: must be positive: %d", c.Workers)
+	}
+	if c.Format != "" && !contains(Formats(), c.Format) {
+		return fmt.Errorf("format: unknown format: %s (available: %s)", c.Format, strings.Join(Formats(), ", "))
+	}
+
+	err := validateOverride(&Override{Paths: c.Paths, Disable: c.Disable, Timeouts: c.Timeouts})
+	if err != nil {
+		return err
+	}
+
+	if c.Push != nil {
+		err = c.Push.validate()
+		if err != nil {
+			return fmt.Errorf("push: %s", err)
+		}
+	}
+
+	for _, v := range vendors(c.Vendors) {
+		o := c.Vendors[v]
+		if o == nil {
+			continue
+		}
+		err = validateOverride(o)
+		if err != nil {
+			return fmt.Errorf("vendors: %s: %s", v, err)
+		}
+	}
+	return nil
+}
+
+func validateOverride(o *Override) error {
+	for _, name := range sortedKeys(o.Paths) {
+		if !contains(util.PathNames(), name) {
+			return fmt.Errorf("paths: unknown name: %s (available: %s)", name, strings.Join(util.PathNames(), ", "))
+		}
+		if o.Paths[name] == "" {
+			return fmt.Errorf("paths: %s: empty path", name)
+		}
+	}
+
+	for _, d := range o.Disable {
+		if d != RAIDCLI && !isSection(d) {
+			return fmt.Errorf("disable: unknown section: %s (available: %s, %s)", d, sectionNames(), RAIDCLI)
+		}
+	}
+
+	for s, t := range o.Timeouts {
+		if !isSection(string(s)) {
+			return fmt.Errorf("timeouts: unknown section: %s (available: %s)", s, sectionNames())
+		}
+		if t <= 0 {
+			return fmt.Errorf("timeouts: %s: must be positive: %s", s, t)
+		}
+	}
+	return nil
+}
+
+func (p *Push) validate() error {
+	if p.URL != "" && !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+		return fmt.Errorf("url: http or https is needed: %s", p.URL)
+	}
+	if (p.Cert == "") != (p.Key == "") {
+		return fmt.Errorf("cert and key must be given together")
+	}
+	if p.Backoff < 0 {
+		return fmt.Errorf("backoff: negative duration: %s", p.Backoff)
+	}
+	return nil
+}
+
+// ForVendor returns a copy of the configuration with the overrides of the vendor applied
+// an override applies if its key is a prefix of the vendor ignoring case, in the order of the keys
+func (c *Config) ForVendor(vendor string) *Config {
+	vc := *c
+	vc.Paths = make(map[string]string)
+	for name, p := range c.Paths {
+		vc.Paths[name] = p
+	}
+	vc.Disable = append([]string{}, c.Disable...)
+	vc.Timeouts = make(map[mox.Section]time.Duration)
+	for s, t := range c.Timeouts {
+		vc.Timeouts[s] = t
+	}
+
+	for _, v := range vendors(c.Vendors) {
+		o := c.Vendors[v]
+		if o == nil || !strings.HasPrefix(strings.ToLower(vendor), strings.ToLower(v)) {
+			continue
+		}
+
+		log.Debugf("applying the override for %s", v)
+		for name, p := range o.Paths {
+			vc.Paths[name] = p
+		}
+		vc.Disable = append(vc.Disable, o.Disable...)
+		for s, t := range o.Timeouts {
+			vc.Timeouts[s] = t
+		}
+	}
+	return &vc
+}
+
+// Disabled returns true if name (a section or raidcli) is disabled
+func (c *Config) Disabled(name string) bool {
+	return contains(c.Disable, name)
+}
+
+// DisabledSections returns the sections disabled
+func (c *Config) DisabledSections() []mox.Section {
+	var ss []mox.Section
+	for _, d := range c.Disable {
+		if isSection(d) {
+			ss = append(ss, mox.Section(d))
+		}
+	}
+	return ss
+}
+
+// Vendor returns the system vendor of the system under root, empty root means the live system
+func Vendor(root string) string {
+	b, err := ioutil.ReadFile(filepath.Join(root, "/sys/class/dmi/id/sys_vendor"))
+	if err != nil {
+		log.Debug(err)
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// isSection returns true if name is a section as it is, since timeouts are looked up by the name
+func isSection(name string) bool {
+	for _, s := range mox.AllSections() {
+		if string(s) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sectionNames() string {
+	var names []string
+	for _, s := range mox.AllSections() {
+		names = append(names, string(s))
+	}
+	return strings.Join(names, ", ")
+}
+
+func vendors(m map[string]*Override) []string {
+	var vs []string
+	for v := range m {
+		vs = append(vs, v)
+	}
+	sort.Strings(vs)
+	return vs
+}
+
+func sortedKeys(m map[string]string) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moxspec/moxspec/mox"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{``, ""},
+		{"paths:\n  ipmitool: /usr/local/bin/ipmitool\n  pci.ids: /opt/pci.ids\n", ""},
+		{"disable: [raidcli, bmc]\ntimeout: 2m\ntimeouts:\n  storage: 30s\nworkers: 4\nformat: json\n", ""},
+		{"vendors:\n  HPE:\n    paths:\n      hpacucli: /usr/sbin/ssacli\n    disable: [accelerator]\n", ""},
+		{"unknown: 1\n", "field unknown not found"},
+		{"paths:\n  smartctl: /usr/sbin/smartctl\n", "paths: unknown name: smartctl"},
+		{"paths:\n  ipmitool: \"\"\n", "paths: ipmitool: empty path"},
+		{"disable: [gpu]\n", "disable: unknown section: gpu"},
+		{"timeouts:\n  Storage: 30s\n", "timeouts: unknown section: Storage"},
+		{"timeouts:\n  storage: 0s\n", "timeouts: storage: must be positive"},
+		{"timeout: -1m\n", "timeout: negative duration"},
+		{"workers: -1\n", "workers: must be positive"},
+		{"format: xml\n", "format: unknown format: xml"},
+		{"vendors:\n  Dell:\n    disable: [raid]\n", "vendors: Dell: disable: unknown section: raid"},
+		{"push:\n  url: https://collector/mox\n  headers:\n    X-Site: tokyo\n  cert: client.crt\n  key: client.key\n  backoff: 2s\n", ""},
+		{"push:\n  url: collector:8080\n", "push: url: http or https is needed"},
+		{"push:\n  cert: client.crt\n", "push: cert and key must be given together"},
+		{"push:\n  backoff: -1s\n", "push: backoff: negative duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("got: %s, expect no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got: %v, expect: %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestForVendor(t *testing.T) {
+	c, err := Parse([]byte(`
+paths:
+  ipmitool: /usr/local/bin/ipmitool
+disable: [bmc]
+timeouts:
+  storage: 30s
+vendors:
+  hpe:
+    paths:
+      hpacucli: /usr/sbin/ssacli
+    disable: [raidcli]
+    timeouts:
+      storage: 2m
+  Dell:
+    disable: [accelerator]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		vendor   string
+		paths    map[string]string
+		disable  []string
+		timeouts map[mox.Section]time.Duration
+	}{
+		{
+			"",
+			map[string]string{"ipmitool": "/usr/local/bin/ipmitool"},
+			[]string{"bmc"},
+			map[mox.Section]time.Duration{mox.StorageSection: 30 * time.Second},
+		},
+		{
+			"HPE",
+			map[string]string{"ipmitool": "/usr/local/bin/ipmitool", "hpacucli": "/usr/sbin/ssacli"},
+			[]string{"bmc", "raidcli"},
+			map[mox.Section]time.Duration{mox.StorageSection: 2 * time.Minute},
+		},
+		{
+			"Dell Inc.",
+			map[string]string{"ipmitool": "/usr/local/bin/ipmitool"},
+			[]string{"bmc", "accelerator"},
+			map[mox.Section]time.Duration{mox.StorageSection: 30 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vendor, func(t *testing.T) {
+			vc := c.ForVendor(tt.vendor)
+			if !reflect.DeepEqual(vc.Paths, tt.paths) {
+				t.Errorf("paths got: %v, expect: %v", vc.Paths, tt.paths)
+			}
+			if !reflect.DeepEqual(vc.Disable, tt.disable) {
+				t.Errorf("disable got: %v, expect: %v", vc.Disable, tt.disable)
+			}
+			if !reflect.DeepEqual(vc.Timeouts, tt.timeouts) {
+				t.Errorf("timeouts got: %v, expect: %v", vc.Timeouts, tt.timeouts)
+			}
+		})
+	}
+
+	// the overrides do not leak into the configuration
+	if len(c.Paths) != 1 || len(c.Disable) != 1 || c.Timeouts[mox.StorageSection] != 30*time.Second {
+		t.Errorf("configuration changed: %+v", c)
+	}
+}
+
+func TestLoadDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "mox.yaml")
+	err = ioutil.WriteFile(path, []byte("format: csv\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Setenv(PathEnv, os.Getenv(PathEnv))
+
+	os.Setenv(PathEnv, path)
+	c, err := LoadDefault("")
+	if err != nil || c.Format != "csv" {
+		t.Errorf("got: %+v, %v, expect the configuration at $%s", c, err, PathEnv)
+	}
+
+	os.Setenv(PathEnv, filepath.Join(dir, "none.yaml"))
+	_, err = LoadDefault("")
+	if err == nil {
+		t.Errorf("expect an error for a missing $%s", PathEnv)
+	}
+
+	_, err = LoadDefault(filepath.Join(dir, "none.yaml"))
+	if err == nil {
+		t.Errorf("expect an error for a missing path")
+	}
+}
+
+func TestVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if v := Vendor(dir); v != "" {
+		t.Errorf("got: %s, expect empty", v)
+	}
+
+	p := filepath.Join(dir, "sys", "class", "dmi", "id")
+	os.MkdirAll(p, 0755)
+	ioutil.WriteFile(filepath.Join(p, "sys_vendor"), []byte("Dell Inc.\n"), 0644)
+
+	if v := Vendor(dir); v != "Dell Inc." {
+		t.Errorf("got: %s, expect: Dell Inc.", v)
+	}
+}
@@ -1,8 +1,8 @@
 package cpu
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -19,7 +19,7 @@ func init() {
 
 func newTopology() *Topology {
 	t := new(Topology)
-	t.path = "/sys/devices/system/cpu"
+	t.path = util.RootPath("/sys/devices/system/cpu")
 	t.packages = make(map[uint16]*Package)
 	return t
 }
@@ -51,9 +51,18 @@ func NewDecoder() *Topology {
 
 // Decode makes Topology satisfy the mox.Decoder interface
 func (t *Topology) Decode() error {
+	return t.DecodeContext(context.Background())
+}
+
+// DecodeContext makes Topology satisfy the mox.ContextDecoder interface
+func (t *Topology) DecodeContext(ctx context.Context) error {
 	for _, cpudir := range util.FilterPrefixedDirs(t.path, "cpu") {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		log.Debugf("scanning %s", cpudir)
-		pid, nid, cid, err := findProcessorID(cpudir)
+		pid, nid, cid, err := findProcessorID(ctx, cpudir)
 		if err != nil {
 			log.Debug(err.Error())
 			continue
@@ -66,7 +75,7 @@ func (t *Topology) Decode() error {
 		}
 
 		p := t.packages[pid]
-		p.decode(cpudir)
+		p.decode(ctx, cpudir)
 
 		if !p.hasNode(nid) {
 			p.nodes[nid] = newNode(nid)
@@ -78,7 +87,7 @@ func (t *Topology) Decode() error {
 		}
 
 		c := newCore(cid)
-		err = c.decode(cpudir)
+		err = c.decode(ctx, cpudir)
 		if err != nil {
 			log.Warn(err.Error())
 			continue
@@ -88,9 +97,9 @@ func (t *Topology) Decode() error {
 	return nil
 }
 
-func findProcessorID(cpudir string) (pid uint16, nid uint16, cid uint16, err error) {
+func findProcessorID(ctx context.Context, cpudir string) (pid uint16, nid uint16, cid uint16, err error) {
 	topodir := filepath.Join(cpudir, "topology")
-	pid, err = findPackageID(topodir)
+	pid, err = findPackageID(ctx, topodir)
 	if err != nil {
 		return
 	}
@@ -98,15 +107,15 @@ func findProcessorID(cpudir string) (pid uint16, nid uint16, cid uint16, err err
 	if err != nil {
 		return
 	}
-	cid, err = findCoreID(topodir)
+	cid, err = findCoreID(ctx, topodir)
 	if err != nil {
 		return
 	}
 	return
 }
 
-func findPackageID(topodir string) (uint16, error) {
-	pid, err := util.LoadUint16(filepath.Join(topodir, "physical_package_id"))
+func findPackageID(ctx context.Context, topodir string) (uint16, error) {
+	pid, err := util.LoadUint16WithContext(ctx, filepath.Join(topodir, "physical_package_id"))
 	if err != nil {
 		return 0, fmt.Errorf("%s has no physical_package_id file", topodir)
 	}
@@ -119,7 +128,7 @@ func findNodeID(cpudir string) (uint16, error) {
 		return 0, fmt.Errorf("valid node dir did not found in %s %v", cpudir, fs)
 	}
 
-	linkPath, err := os.Readlink(fs[0])
+	linkPath, err := util.Readlink(fs[0])
 	if err != nil {
 		return 0, err
 	}
@@ -130,8 +139,8 @@ func findNodeID(cpudir string) (uint16, error) {
 	return uint16(id), err
 }
 
-func findCoreID(topodir string) (uint16, error) {
-	cid, err := util.LoadUint16(filepath.Join(topodir, "core_id"))
+func findCoreID(ctx context.Context, topodir string) (uint16, error) {
+	cid, err := util.LoadUint16WithContext(ctx, filepath.Join(topodir, "core_id"))
 	if err != nil {
 		return 0, fmt.Errorf("%s has no core_id file", topodir)
 	}
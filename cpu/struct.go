@@ -1,6 +1,7 @@
 package cpu
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"sort"
@@ -85,8 +86,8 @@ func (p Package) Nodes() []*Node {
 	return l
 }
 
-func (p *Package) decode(cpudir string) error {
-	pThrottle, err := util.LoadUint16(filepath.Join(cpudir, "thermal_throttle", "package_throttle_count"))
+func (p *Package) decode(ctx context.Context, cpudir string) error {
+	pThrottle, err := util.LoadUint16WithContext(ctx, filepath.Join(cpudir, "thermal_throttle", "package_throttle_count"))
 	if err != nil {
 		return err
 	}
@@ -156,24 +157,24 @@ func (c Core) ThreadCount() int {
 	return len(c.Threads)
 }
 
-func (c *Core) decode(cpudir string) error {
+func (c *Core) decode(ctx context.Context, cpudir string) error {
 	list := filepath.Join(filepath.Join(cpudir, "topology"), "thread_siblings_list")
-	ls, err := util.LoadString(list)
+	ls, err := util.LoadStringWithContext(ctx, list)
 	if err != nil {
 		return fmt.Errorf("could not load %s", list)
 	}
 	c.Threads = parseListString(ls)
 
-	c.ThrottleCount, _ = util.LoadUint16(filepath.Join(cpudir, "thermal_throttle", "core_throttle_count"))
-	c.BaseFreq, _ = util.LoadUint64(filepath.Join(cpudir, "cpufreq", "base_frequency"))
-	c.MaxFreq, _ = util.LoadUint64(filepath.Join(cpudir, "cpufreq", "cpuinfo_max_freq"))
-	c.MinFreq, _ = util.LoadUint64(filepath.Join(cpudir, "cpufreq", "cpuinfo_min_freq"))
-	c.Scaling.CurFreq, _ = util.LoadUint64(filepath.Join(cpudir, "cpufreq", "scaling_cur_freq"))
-	c.Scaling.MaxFreq, _ = util.LoadUint64(filepath.Join(cpudir, "cpufreq", "scaling_max_freq"))
-	c.Scaling.MinFreq, _ = util.LoadUint64(filepath.Join(cpudir, "cpufreq", "scaling_min_freq"))
-	c.Scaling.Governor, _ = util.LoadString(filepath.Join(cpudir, "cpufreq", "scaling_governor"))
-	c.Scaling.Driver, _ = util.LoadString(filepath.Join(cpudir, "cpufreq", "scaling_driver"))
-	govs, _ := util.LoadString(filepath.Join(cpudir, "cpufreq", "scaling_available_governors"))
+	c.ThrottleCount, _ = util.LoadUint16WithContext(ctx, filepath.Join(cpudir, "thermal_throttle", "core_throttle_count"))
+	c.BaseFreq, _ = util.LoadUint64WithContext(ctx, filepath.Join(cpudir, "cpufreq", "base_frequency"))
+	c.MaxFreq, _ = util.LoadUint64WithContext(ctx, filepath.Join(cpudir, "cpufreq", "cpuinfo_max_freq"))
+	c.MinFreq, _ = util.LoadUint64WithContext(ctx, filepath.Join(cpudir, "cpufreq", "cpuinfo_min_freq"))
+	c.Scaling.CurFreq, _ = util.LoadUint64WithContext(ctx, filepath.Join(cpudir, "cpufreq", "scaling_cur_freq"))
+	c.Scaling.MaxFreq, _ = util.LoadUint64WithContext(ctx, filepath.Join(cpudir, "cpufreq", "scaling_max_freq"))
+	c.Scaling.MinFreq, _ = util.LoadUint64WithContext(ctx, filepath.Join(cpudir, "cpufreq", "scaling_min_freq"))
+	c.Scaling.Governor, _ = util.LoadStringWithContext(ctx, filepath.Join(cpudir, "cpufreq", "scaling_governor"))
+	c.Scaling.Driver, _ = util.LoadStringWithContext(ctx, filepath.Join(cpudir, "cpufreq", "scaling_driver"))
+	govs, _ := util.LoadStringWithContext(ctx, filepath.Join(cpudir, "cpufreq", "scaling_available_governors"))
 	c.Scaling.AvailableGovernors = strings.Fields(govs)
 
 	return nil
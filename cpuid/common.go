@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/moxspec/moxspec/capture"
 	"github.com/moxspec/moxspec/util"
 )
 
@@ -55,6 +56,7 @@ func cpuid(p *Processor, eaxIn, ecxIn uint32) (eax, ebx, ecx, edx uint32) {
 	var ptr int64
 	ptr = (int64(ecxIn) << 32) | int64(eaxIn)
 	fd.ReadAt(d, ptr)
+	capture.CPUID(eaxIn, ecxIn, d)
 
 	eax = util.BytesToUint32(d[0:4])
 	ebx = util.BytesToUint32(d[4:8])
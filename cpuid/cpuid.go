@@ -1,6 +1,9 @@
 package cpuid
 
-import "github.com/moxspec/moxspec/loglet"
+import (
+	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
+)
 
 type parser func(*Processor) error
 
@@ -19,6 +22,10 @@ func NewDecoder() *Processor {
 func (cpu *Processor) Decode() error {
 	var err error
 
+	if util.Captured() {
+		return util.ErrLiveOnly
+	}
+
 	var parsers = []parser{
 		parseVendorID,
 		parseFlags,
@@ -0,0 +1,313 @@
+// Package diag diagnoses the health of the components in a report
+package diag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// These are statuses of a component
+const (
+	Healthy     = "healthy"
+	Warned      = "WARNING"
+	Unhealthy   = "UNHEALTHY"
+	Uninspected = "could not inspect"
+)
+
+// Result represents a diagnosis of a component
+type Result struct {
+	Category string   `json:"category"`
+	ID       string   `json:"id"` // identifies the component, e.g. PCI ID, socket or device name
+	Status   string   `json:"status"`
+	Details  []string `json:"details"`
+}
+
+// Diagnosis represents diagnoses of all components in a report
+type Diagnosis struct {
+	Healthy bool      `json:"healthy"` // false if any component is critical
+	Warning bool      `json:"warning"` // true if any component is warned
+	Results []*Result `json:"results"`
+}
+
+func (d *Diagnosis) append(cat, id, stat string, details ...string) {
+	d.Results = append(d.Results, &Result{Category: cat, ID: id, Status: stat, Details: details})
+	switch stat {
+	case Unhealthy:
+		d.Healthy = false
+	case Warned:
+		d.Warning = true
+	}
+}
+
+// appendEvaluated appends a result of the evaluation, name is shown if the component is healthy
+// summaries and findings are shown following the name otherwise
+func (d *Diagnosis) appendEvaluated(cat, id string, e *evaluation, name string, summaries ...string) {
+	if e.severity == None {
+		d.append(cat, id, Healthy, name)
+		return
+	}
+
+	details := append([]string{name}, summaries...)
+	d.append(cat, id, e.status(), append(details, e.findings...)...)
+}
+
+// Diagnose diagnoses the components in the report with the default policy
+func Diagnose(r *model.Report) *Diagnosis {
+	return DefaultPolicy().Diagnose(r)
+}
+
+// Diagnose diagnoses the components in the report
+// a component which could not be inspected does not make the diagnosis unhealthy
+// thresholds on deltas of counters are not evaluated since there is no previous state
+func (p *Policy) Diagnose(r *model.Report) *Diagnosis {
+	d := new(Diagnosis)
+	d.Healthy = true
+	d.Results = []*Result{}
+
+	de := newDiagErrors(r)
+
+	if r.Processor != nil {
+		perrs := de.ofComponent("Processor")
+		for _, pkg := range r.Processor.Packages {
+			model := fmt.Sprintf("%s %s", pkg.Socket, pkg.ProductName)
+
+			e := p.evaluate(pkg.Socket, pkg.ProductName, pkg.SerialNumber)
+			e.check("", CPUThrottle, float64(pkg.ThrottleCount))
+
+			if len(perrs) > 0 && e.severity == None {
+				d.append("Processor", pkg.Socket, Uninspected, fmt.Sprintf("%s: %s", model, perrs[0].Summary()))
+				continue
+			}
+			d.appendEvaluated("Processor", pkg.Socket, e, model)
+		}
+	}
+
+	if r.Memory != nil {
+		for _, ctl := range r.Memory.Controllers {
+			ctlName := ctl.Name
+			for _, cs := range ctl.CSRows {
+				id := fmt.Sprintf("%s/%s", ctlName, cs.Name)
+				e := p.evaluate(id, "", "")
+				e.check("", MemoryCE, float64(cs.CECount))
+				e.check("", MemoryUE, float64(cs.UECount))
+				for _, ch := range cs.Channels {
+					e.check(ch.Label, DIMMCE, float64(ch.CECount))
+				}
+
+				if e.severity == None {
+					d.append("Memory", id, Healthy, fmt.Sprintf("%s %s", ctlName, cs.Name))
+				} else {
+					d.append("Memory", id, e.status(), append([]string{fmt.Sprintf("%s %s", ctlName, cs.Summary())}, e.findings...)...)
+				}
+			}
+		}
+	}
+
+	if r.Storage != nil {
+		for _, ctl := range r.Storage.RAIDControllers {
+			e := p.evaluate(ctl.PCIID(), ctl.ProductName, ctl.SerialNumber)
+			e.checkPCI(&ctl.PCIBaseSpec)
+
+			if errs := de.of(ctl.PCIID(), ""); len(errs) > 0 && e.severity == None {
+				d.append("RAID Card", ctl.PCIID(), Uninspected, errorSummaries(ctl.LongName(), errs)...)
+			} else {
+				d.appendEvaluated("RAID Card", ctl.PCIID(), e, ctl.LongName(), ctl.DiagSummaries()...)
+			}
+
+			for _, ld := range ctl.LogDrives {
+				detail := fmt.Sprintf("%s: %s, %s", ld.Name, ld.RAIDLv, ld.Status)
+
+				e := p.evaluate(ld.Name, "", "")
+				for _, pd := range ld.PhyDrives {
+					e.merge(p.evaluatePhyDrive(ctl, pd))
+				}
+				if ld.Degraded {
+					e.raise(Critical)
+				}
+
+				d.appendEvaluated("RAID Volume", ld.Name, e, detail)
+			}
+
+			for _, pd := range ctl.PassthroughDrives {
+				detail := fmt.Sprintf("%s: %s, %s", pd.Name, pd.Model, pd.Status)
+				d.appendEvaluated("Pass-Through Drive", pd.Name, p.evaluatePhyDrive(ctl, pd), detail)
+			}
+		}
+
+		for _, ctl := range r.Storage.NVMeControllers {
+			e := p.evaluate(ctl.PCIID(), ctl.Model, ctl.SerialNumber)
+			e.checkPCI(&ctl.PCIBaseSpec)
+			e.checkWear(&ctl.StorageWearSpec)
+
+			if errs := de.of(ctl.PCIID(), ""); len(errs) > 0 && e.severity == None {
+				d.append("NVMe Drive", ctl.PCIID(), Uninspected, errorSummaries(ctl.LongName(), errs)...)
+			} else {
+				d.appendEvaluated("NVMe Drive", ctl.PCIID(), e, ctl.LongName(), ctl.DiagSummaries()...)
+			}
+		}
+
+		for _, ctl := range r.Storage.AHCIControllers {
+			for _, drv := range ctl.Drives {
+				detail := fmt.Sprintf("%s %s", drv.Model, drv.SizeString())
+
+				e := p.evaluate(driveID(drv.SerialNumber, drv.Name), drv.Model, drv.SerialNumber)
+				e.check("", SMART, float64(len(drv.ErrorRecords)))
+				e.checkWear(&drv.StorageWearSpec)
+				e.check("", ReallocatedSectors, float64(drv.ReallocatedSectors))
+				e.check("", PendingSectors, float64(drv.PendingSectors))
+
+				if errs := de.of("", "/dev/"+drv.Name); len(errs) > 0 && e.severity == None {
+					d.append("SATA Drive", drv.Name, Uninspected, errorSummaries(detail, errs)...)
+				} else {
+					d.appendEvaluated("SATA Drive", drv.Name, e, detail, drv.DiagSummaries()...)
+				}
+			}
+		}
+	}
+
+	if r.Network != nil {
+		for _, ctl := range r.Network.EthControllers {
+			e := p.evaluate(ctl.PCIID(), ctl.LongName(), ctl.SerialNumber)
+			e.checkPCI(&ctl.PCIBaseSpec)
+			for _, intf := range ctl.Interfaces {
+				e.check(intf.Name, NICRxErrors, float64(intf.RxErrors))
+				e.check(intf.Name, NICTxErrors, float64(intf.TxErrors))
+			}
+
+			d.appendEvaluated("Network", ctl.PCIID(), e, ctl.LongName(), ctl.DiagSummaries()...)
+		}
+	}
+
+	if r.Accelerator != nil {
+		for _, g := range r.Accelerator.GPUs {
+			e := p.evaluate(g.PCIID(), g.ProductName, g.SerialNumber)
+			e.checkPCI(&g.PCIBaseSpec)
+			e.check("", GPUCE, float64(g.CECount.Total))
+			e.check("", GPUUE, float64(g.UECount.Total))
+			e.check("", GPURetiredPages, float64(g.Retired.Total()))
+
+			if errs := de.of(g.PCIID(), ""); len(errs) > 0 && e.severity == None {
+				d.append("Accelerator", g.PCIID(), Uninspected, errorSummaries(g.LongName(), errs)...)
+			} else {
+				d.appendEvaluated("Accelerator", g.PCIID(), e, g.LongName(), g.DiagSummaries()...)
+			}
+		}
+
+		for _, f := range r.Accelerator.FPGAs {
+			e := p.evaluate(f.PCIID(), f.LongName(), f.SerialNumber)
+			e.checkPCI(&f.PCIBaseSpec)
+
+			d.appendEvaluated("Accelerator", f.PCIID(), e, f.LongName(), f.DiagSummaries()...)
+		}
+	}
+
+	var plugins []string
+	for name := range r.Extensions {
+		plugins = append(plugins, name)
+	}
+	sort.Strings(plugins)
+	for _, name := range plugins {
+		for _, dg := range r.Extensions[name].Diag {
+			d.append(name, dg.ID, extensionStatus(dg.Status), dg.Details...)
+		}
+	}
+
+	// errors not bound to any row above, such as a controller which could not be decoded at all
+	for _, e := range de.rest() {
+		id := e.PCIID
+		if id == "" {
+			id = e.Path
+		}
+		d.append(e.Component, id, Uninspected, e.Summary())
+	}
+
+	return d
+}
+
+// extensionStatus returns the status of a diagnosis given by a plugin
+func extensionStatus(stat string) string {
+	switch stat {
+	case model.ExtensionHealthy:
+		return Healthy
+	case model.ExtensionWarning:
+		return Warned
+	case model.ExtensionCritical:
+		return Unhealthy
+	}
+	return Uninspected
+}
+
+// driveID identifies a drive by the serial number which does not change across reboots
+func driveID(serial, fallback string) string {
+	if serial != "" {
+		return serial
+	}
+	return fallback
+}
+
+func (p *Policy) evaluatePhyDrive(ctl *model.RAIDController, pd *model.PhyDrive) *evaluation {
+	e := p.evaluate(driveID(pd.SerialNumber, ctl.PCIID()+"/"+pd.Pos()), pd.Model, pd.SerialNumber)
+	e.check(pd.Pos(), DriveErrors, float64(pd.ErrorCount))
+	e.checkWear(&pd.StorageWearSpec)
+	e.check(pd.Pos(), ReallocatedSectors, float64(pd.ReallocatedSectors))
+	e.check(pd.Pos(), PendingSectors, float64(pd.PendingSectors))
+	return e
+}
+
+// diagErrors keeps track of decode errors already shown
+type diagErrors struct {
+	errs []*model.DecodeError
+	seen map[*model.DecodeError]bool
+}
+
+func newDiagErrors(r *model.Report) *diagErrors {
+	return &diagErrors{
+		errs: r.Errors,
+		seen: make(map[*model.DecodeError]bool),
+	}
+}
+
+// of returns errors about a device with the given pci id or path
+func (d *diagErrors) of(pciid, path string) []*model.DecodeError {
+	var errs []*model.DecodeError
+	for _, e := range d.errs {
+		if e.Matches(pciid, path) {
+			d.seen[e] = true
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// ofComponent returns errors about a component which are not bound to any device
+func (d *diagErrors) ofComponent(component string) []*model.DecodeError {
+	var errs []*model.DecodeError
+	for _, e := range d.errs {
+		if e.Component == component && e.PCIID == "" && e.Path == "" {
+			d.seen[e] = true
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// rest returns errors not returned yet
+func (d *diagErrors) rest() []*model.DecodeError {
+	var errs []*model.DecodeError
+	for _, e := range d.errs {
+		if !d.seen[e] {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+func errorSummaries(name string, errs []*model.DecodeError) []string {
+	sums := []string{name}
+	for _, e := range errs {
+		sums = append(sums, e.Summary())
+	}
+	return sums
+}
@@ -0,0 +1,101 @@
+package diag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestDiagnose(t *testing.T) {
+	csrow := func(name string, ue uint64) *model.ChipSelectRow {
+		cs := new(model.ChipSelectRow)
+		cs.Name = name
+		cs.UECount = ue
+		return cs
+	}
+	memory := func(rows ...*model.ChipSelectRow) *model.MemoryReport {
+		return &model.MemoryReport{Controllers: []*model.MemoryController{{Name: "mc0", CSRows: rows}}}
+	}
+
+	tests := []struct {
+		name    string
+		report  *model.Report
+		healthy bool
+		ex      []*Result
+	}{
+		{
+			"empty",
+			new(model.Report),
+			true,
+			[]*Result{},
+		},
+		{
+			"healthy memory",
+			&model.Report{Memory: memory(csrow("csrow0", 0))},
+			true,
+			[]*Result{{"Memory", "mc0/csrow0", Healthy, []string{"mc0 csrow0"}}},
+		},
+		{
+			"uncorrectable error",
+			&model.Report{Memory: memory(csrow("csrow0", 0), csrow("csrow1", 2))},
+			false,
+			[]*Result{
+				{"Memory", "mc0/csrow0", Healthy, []string{"mc0 csrow0"}},
+				{"Memory", "mc0/csrow1", Unhealthy, []string{"mc0 cs: csrow1, 0B, ce=0, ue=2", "memory_ue = 2 > 0 (critical)"}},
+			},
+		},
+		{
+			"uninspected",
+			&model.Report{
+				Processor: &model.ProcessorReport{Packages: []*model.Package{{Socket: "CPU1", ProductName: "Xeon"}}},
+				Errors: []*model.DecodeError{
+					{Component: "Processor", Decoder: "msr", Message: "permission denied"},
+					{Component: "BMC", Decoder: "ipmitool", Message: "not installed"},
+				},
+			},
+			true,
+			[]*Result{
+				{"Processor", "CPU1", Uninspected, []string{"CPU1 Xeon: msr: permission denied"}},
+				{"BMC", "", Uninspected, []string{"ipmitool: not installed"}},
+			},
+		},
+		{
+			"extensions",
+			&model.Report{
+				Extensions: map[string]*model.Extension{
+					"ocp": {Diag: []*model.ExtensionDiag{
+						{ID: "board1", Status: model.ExtensionHealthy, Details: []string{"OCP board"}},
+						{ID: "board2", Status: model.ExtensionCritical, Details: []string{"OCP board", "fan1 stopped"}},
+					}},
+					"fpga": {Diag: []*model.ExtensionDiag{{ID: "slot3", Status: "unknown"}}},
+				},
+				Errors: []*model.DecodeError{
+					{Component: "Extension", Decoder: "plugin", Path: "/etc/mox/plugins.d/broken", Message: "invalid output"},
+				},
+			},
+			false,
+			[]*Result{
+				{"fpga", "slot3", Uninspected, nil},
+				{"ocp", "board1", Healthy, []string{"OCP board"}},
+				{"ocp", "board2", Unhealthy, []string{"OCP board", "fan1 stopped"}},
+				{"Extension", "/etc/mox/plugins.d/broken", Uninspected, []string{"plugin (/etc/mox/plugins.d/broken): invalid output"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diagnose(tt.report)
+			if d.Healthy != tt.healthy {
+				t.Errorf("healthy got: %t, expect: %t", d.Healthy, tt.healthy)
+			}
+			if !reflect.DeepEqual(d.Results, tt.ex) {
+				for _, r := range d.Results {
+					t.Logf("got: %+v", r)
+				}
+				t.Errorf("unexpected results")
+			}
+		})
+	}
+}
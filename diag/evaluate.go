@@ -0,0 +1,137 @@
+package diag
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// evaluation collects results of the checks of a component
+type evaluation struct {
+	policy   *Policy
+	id       string
+	model    string
+	serial   string
+	severity Severity
+	findings []string
+}
+
+// evaluate starts an evaluation of a component, id identifies it in the state
+func (p *Policy) evaluate(id, model, serial string) *evaluation {
+	e := new(evaluation)
+	e.policy = p
+	e.id = id
+	e.model = model
+	e.serial = serial
+	return e
+}
+
+// check compares the value with the thresholds of the check, what tells a part of the component if any
+func (e *evaluation) check(what, name string, v float64) {
+	key := e.key(name, what)
+	if e.policy.next != nil && counterChecks[name] {
+		e.policy.next.Counters[key] = v
+	}
+
+	t := e.policy.Thresholds[name]
+	if t == nil || e.policy.ignores(name, e.model, e.serial) {
+		return
+	}
+
+	value := v
+	desc := fmt.Sprintf("%v", v)
+	switch {
+	case t.Per > 0:
+		inc, elapsed, ok := e.policy.deltaWithin(key, v, t.Per)
+		if !ok {
+			// the first diagnosis only keeps the counter
+			return
+		}
+
+		value = inc
+		desc = fmt.Sprintf("+%v in %s", inc, elapsed)
+		if elapsed > t.Per {
+			// runs further apart than per are taken as the average
+			value = inc * float64(t.Per) / float64(elapsed)
+			desc = fmt.Sprintf("%s per %s (%s)", formatRate(value), t.Per, desc)
+		}
+	case t.Delta:
+		inc, elapsed, ok := e.policy.delta(key, v)
+		if !ok {
+			return
+		}
+
+		value = inc
+		desc = fmt.Sprintf("+%v in %s", inc, elapsed)
+	}
+
+	var sev Severity
+	var limit float64
+	switch {
+	case t.Critical != nil && value > *t.Critical:
+		sev = Critical
+		limit = *t.Critical
+	case t.Warning != nil && value > *t.Warning:
+		sev = Warning
+		limit = *t.Warning
+	default:
+		return
+	}
+
+	e.raise(sev)
+
+	f := fmt.Sprintf("%s = %s > %v (%s)", name, desc, limit, sev)
+	if what != "" {
+		f = fmt.Sprintf("%s: %s", what, f)
+	}
+	e.findings = append(e.findings, f)
+}
+
+// key identifies a counter in the state
+func (e *evaluation) key(name, what string) string {
+	k := fmt.Sprintf("%s/%s", name, e.id)
+	if what != "" {
+		k = fmt.Sprintf("%s/%s", k, what)
+	}
+	return k
+}
+
+func formatRate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// checkPCI checks errors reported by PCIe AER
+func (e *evaluation) checkPCI(p *model.PCIBaseSpec) {
+	e.check("", PCIeUE, float64(len(p.UEList)))
+	e.check("", PCIeCE, float64(len(p.CEList)))
+}
+
+// checkWear checks the endurance used if the drive reports it
+func (e *evaluation) checkWear(w *model.StorageWearSpec) {
+	if w.Used != nil {
+		e.check("", SSDUsed, float64(*w.Used))
+	}
+}
+
+func (e *evaluation) raise(sev Severity) {
+	if sev > e.severity {
+		e.severity = sev
+	}
+}
+
+// merge takes findings of a part of the component
+func (e *evaluation) merge(o *evaluation) {
+	e.raise(o.severity)
+	e.findings = append(e.findings, o.findings...)
+}
+
+func (e *evaluation) status() string {
+	switch e.severity {
+	case Critical:
+		return Unhealthy
+	case Warning:
+		return Warned
+	}
+	return Healthy
+}
@@ -0,0 +1,226 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// These are exit codes of monitoring plugins (Nagios, Icinga)
+const (
+	NagiosOK       = 0
+	NagiosWarning  = 1
+	NagiosCritical = 2
+	NagiosUnknown  = 3
+)
+
+var nagiosStates = map[int]string{
+	NagiosOK:       "OK",
+	NagiosWarning:  "WARNING",
+	NagiosCritical: "CRITICAL",
+	NagiosUnknown:  "UNKNOWN",
+}
+
+// toolDecoders are decoders running an external tool, their warnings leave the diagnosis unknown
+var toolDecoders = map[string]bool{
+	"ipmi": true,
+}
+
+// Nagios writes the diagnosis as output of a monitoring plugin and returns its exit code
+// a component which could not be inspected makes the state UNKNOWN unless another is warned or critical
+func (p *Policy) Nagios(w io.Writer, r *model.Report, d *Diagnosis) int {
+	var crits, warns, unknowns []string
+	for _, res := range d.Results {
+		line := fmt.Sprintf("%s %s: %s", res.Category, res.ID, strings.Join(res.Details, "; "))
+		if res.ID == "" {
+			line = fmt.Sprintf("%s: %s", res.Category, strings.Join(res.Details, "; "))
+		}
+
+		switch res.Status {
+		case Unhealthy:
+			crits = append(crits, line)
+		case Warned:
+			warns = append(warns, line)
+		case Uninspected:
+			unknowns = append(unknowns, line)
+		}
+	}
+	for _, e := range r.Warnings {
+		if toolDecoders[e.Decoder] {
+			unknowns = append(unknowns, fmt.Sprintf("%s: %s", e.Component, e.Summary()))
+		}
+	}
+
+	code := NagiosOK
+	switch {
+	case len(crits) > 0:
+		code = NagiosCritical
+	case len(warns) > 0:
+		code = NagiosWarning
+	case len(unknowns) > 0:
+		code = NagiosUnknown
+	}
+
+	summary := fmt.Sprintf("%d components healthy", len(d.Results))
+	if code != NagiosOK {
+		summary = fmt.Sprintf("%d critical, %d warning, %d unknown of %d components", len(crits), len(warns), len(unknowns), len(d.Results))
+	}
+
+	status := fmt.Sprintf("MOX %s - %s", nagiosStates[code], summary)
+	if perf := p.PerfData(r); len(perf) > 0 {
+		status = fmt.Sprintf("%s | %s", status, strings.Join(perf, " "))
+	}
+	fmt.Fprintln(w, status)
+
+	for _, l := range crits {
+		fmt.Fprintf(w, "[CRITICAL] %s\n", l)
+	}
+	for _, l := range warns {
+		fmt.Fprintf(w, "[WARNING] %s\n", l)
+	}
+	for _, l := range unknowns {
+		fmt.Fprintf(w, "[UNKNOWN] %s\n", l)
+	}
+
+	return code
+}
+
+// NagiosError writes a failure of the diagnosis as output of a monitoring plugin and returns its exit code
+func NagiosError(w io.Writer, err error) int {
+	fmt.Fprintf(w, "MOX UNKNOWN - %s\n", err)
+	return NagiosUnknown
+}
+
+// perf builds performance data of the output of a monitoring plugin
+type perf struct {
+	policy *Policy
+	data   []string
+}
+
+// add appends a value, warning and critical thresholds are taken from the check if given
+func (pf *perf) add(label string, v float64, uom, check, model, serial string) {
+	var warn, crit string
+	if t := pf.policy.Thresholds[check]; t != nil && !pf.policy.ignores(check, model, serial) {
+		if t.Warning != nil {
+			warn = formatPerfValue(*t.Warning)
+		}
+		if t.Critical != nil {
+			crit = formatPerfValue(*t.Critical)
+		}
+	}
+
+	d := fmt.Sprintf("%s=%s%s", quotePerfLabel(label), formatPerfValue(v), uom)
+	if warn != "" || crit != "" {
+		d = fmt.Sprintf("%s;%s;%s", d, warn, crit)
+	}
+	pf.data = append(pf.data, d)
+}
+
+// PerfData returns performance data (temperatures, wear and error counts) in the report
+func (p *Policy) PerfData(r *model.Report) []string {
+	pf := &perf{policy: p}
+
+	if r.Processor != nil {
+		for _, pkg := range r.Processor.Packages {
+			pf.add(fmt.Sprintf("cpu%d_throttle", pkg.ID), float64(pkg.ThrottleCount), "c", CPUThrottle, pkg.ProductName, pkg.SerialNumber)
+			for _, n := range pkg.Nodes {
+				if n.AvgTemp > 0 {
+					pf.add(fmt.Sprintf("cpu%d_node%d_temp", pkg.ID, n.ID), n.AvgTemp, "", "", "", "")
+				}
+			}
+		}
+	}
+
+	if r.Memory != nil {
+		for _, ctl := range r.Memory.Controllers {
+			for _, cs := range ctl.CSRows {
+				pf.add(fmt.Sprintf("%s_%s_ce", ctl.Name, cs.Name), float64(cs.CECount), "c", MemoryCE, "", "")
+				pf.add(fmt.Sprintf("%s_%s_ue", ctl.Name, cs.Name), float64(cs.UECount), "c", MemoryUE, "", "")
+			}
+		}
+	}
+
+	if r.Storage != nil {
+		for _, ctl := range r.Storage.NVMeControllers {
+			pf.addDrive(ctl.Name, ctl.Model, ctl.SerialNumber, ctl.CurTemp, &ctl.StorageWearSpec)
+		}
+
+		for _, ctl := range r.Storage.AHCIControllers {
+			for _, drv := range ctl.Drives {
+				pf.addDrive(drv.Name, drv.Model, drv.SerialNumber, drv.CurTemp, &drv.StorageWearSpec)
+				if drv.IsHDD() || drv.IsSSD() {
+					pf.add(drv.Name+"_reallocated", float64(drv.ReallocatedSectors), "", ReallocatedSectors, drv.Model, drv.SerialNumber)
+					pf.add(drv.Name+"_pending", float64(drv.PendingSectors), "", PendingSectors, drv.Model, drv.SerialNumber)
+				}
+			}
+		}
+
+		for _, ctl := range r.Storage.RAIDControllers {
+			var pds []*model.PhyDrive
+			for _, ld := range ctl.LogDrives {
+				pds = append(pds, ld.PhyDrives...)
+			}
+			pds = append(pds, ctl.PassthroughDrives...)
+
+			for _, pd := range pds {
+				name := fmt.Sprintf("adp%s_%s", ctl.AdapterID, pd.Pos())
+				pf.addDrive(name, pd.Model, pd.SerialNumber, pd.CurTemp, &pd.StorageWearSpec)
+				pf.add(name+"_errors", float64(pd.ErrorCount), "c", DriveErrors, pd.Model, pd.SerialNumber)
+			}
+		}
+	}
+
+	if r.Network != nil {
+		for _, ctl := range r.Network.EthControllers {
+			for _, intf := range ctl.Interfaces {
+				pf.add(intf.Name+"_rx_errors", float64(intf.RxErrors), "c", NICRxErrors, ctl.LongName(), ctl.SerialNumber)
+				pf.add(intf.Name+"_tx_errors", float64(intf.TxErrors), "c", NICTxErrors, ctl.LongName(), ctl.SerialNumber)
+			}
+		}
+	}
+
+	if r.Accelerator != nil {
+		for _, g := range r.Accelerator.GPUs {
+			name := "gpu_" + g.PCIID()
+			if g.Temp.GPU > 0 {
+				pf.add(name+"_temp", float64(g.Temp.GPU), "", "", "", "")
+			}
+			if g.Power.Current > 0 {
+				pf.add(name+"_power", float64(g.Power.Current), "", "", "", "")
+			}
+			pf.add(name+"_ce", float64(g.CECount.Total), "c", GPUCE, g.ProductName, g.SerialNumber)
+			pf.add(name+"_ue", float64(g.UECount.Total), "c", GPUUE, g.ProductName, g.SerialNumber)
+			pf.add(name+"_retired_pages", float64(g.Retired.Total()), "", GPURetiredPages, g.ProductName, g.SerialNumber)
+		}
+	}
+
+	return pf.data
+}
+
+func (pf *perf) addDrive(name, model, serial string, temp int16, w *model.StorageWearSpec) {
+	if temp != 0 {
+		pf.add(name+"_temp", float64(temp), "", "", "", "")
+	}
+	if w.Used != nil {
+		pf.add(name+"_used", float64(*w.Used), "%", SSDUsed, model, serial)
+	}
+	if w.SpareSpace != nil {
+		pf.add(name+"_spare", float64(*w.SpareSpace), "%", "", "", "")
+	}
+}
+
+// quotePerfLabel quotes a label if needed, single quotes in it are doubled
+func quotePerfLabel(l string) string {
+	l = strings.ReplaceAll(l, "=", "_")
+	if !strings.ContainsAny(l, " '") {
+		return l
+	}
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(l, "'", "''"))
+}
+
+func formatPerfValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
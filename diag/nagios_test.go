@@ -0,0 +1,98 @@
+package diag
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestNagios(t *testing.T) {
+	nvme := func(used byte) *model.NVMeController {
+		n := new(model.NVMeController)
+		n.Name = "nvme0"
+		n.CurTemp = 40
+		n.Used = &used
+		return n
+	}
+	ipmiWarning := &model.DecodeError{Component: "BMC", Decoder: "ipmi", Message: "ipmitool not found"}
+	csrow := &model.ChipSelectRow{Name: "csrow0", UECount: 1}
+
+	p, err := ParsePolicy([]byte("thresholds: {ssd_used: {warning: 80, critical: 95}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		report *model.Report
+		code   int
+		status string
+	}{
+		{
+			"ok",
+			&model.Report{Storage: &model.StorageReport{NVMeControllers: []*model.NVMeController{nvme(3)}}},
+			NagiosOK,
+			"MOX OK - 1 components healthy | nvme0_temp=40 nvme0_used=3%;80;95",
+		},
+		{
+			"warning",
+			&model.Report{Storage: &model.StorageReport{NVMeControllers: []*model.NVMeController{nvme(85)}}},
+			NagiosWarning,
+			"MOX WARNING - 0 critical, 1 warning, 0 unknown of 1 components | nvme0_temp=40 nvme0_used=85%;80;95",
+		},
+		{
+			"critical over unknown",
+			&model.Report{
+				Memory:   &model.MemoryReport{Controllers: []*model.MemoryController{{Name: "mc0", CSRows: []*model.ChipSelectRow{csrow}}}},
+				Warnings: []*model.DecodeError{ipmiWarning},
+			},
+			NagiosCritical,
+			"MOX CRITICAL - 1 critical, 0 warning, 1 unknown of 1 components | mc0_csrow0_ce=0c;;1000 mc0_csrow0_ue=1c;;0",
+		},
+		{
+			"unknown",
+			&model.Report{Warnings: []*model.DecodeError{ipmiWarning}},
+			NagiosUnknown,
+			"MOX UNKNOWN - 0 critical, 0 warning, 1 unknown of 0 components",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			code := p.Nagios(&b, tt.report, p.Diagnose(tt.report))
+			if code != tt.code {
+				t.Errorf("code got: %d, expect: %d", code, tt.code)
+			}
+
+			status := strings.SplitN(b.String(), "\n", 2)[0]
+			if status != tt.status {
+				t.Errorf("got: %s, expect: %s", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestQuotePerfLabel(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect string
+	}{
+		{"nvme0_temp", "nvme0_temp"},
+		{"adp0_32:1_temp", "adp0_32:1_temp"},
+		{"a b", "'a b'"},
+		{"it's", "'it''s'"},
+		{"a=b", "a_b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			if got := quotePerfLabel(tt.in); got != tt.expect {
+				t.Errorf("got: %s, expect: %s", got, tt.expect)
+			}
+		})
+	}
+}
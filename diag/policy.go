@@ -0,0 +1,338 @@
+package diag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultPolicyPath is where lsdiag looks for a policy when none is given
+const DefaultPolicyPath = "/etc/mox/diag.yaml"
+
+// These are names of checks
+const (
+	CPUThrottle        = "cpu_throttle"        // thermal throttling events of a processor package
+	MemoryCE           = "memory_ce"           // corrected errors of a csrow
+	MemoryUE           = "memory_ue"           // uncorrected errors of a csrow
+	DIMMCE             = "dimm_ce"             // corrected errors of a DIMM (EDAC channel)
+	SMART              = "smart"               // SMART attributes at or below the vendor threshold
+	SSDUsed            = "ssd_used"            // percentage of the endurance of an SSD used
+	ReallocatedSectors = "reallocated_sectors" // reallocated sectors of a SATA drive
+	PendingSectors     = "pending_sectors"     // sectors pending reallocation of a SATA drive
+	DriveErrors        = "drive_errors"        // media errors of a drive under a RAID controller
+	PCIeUE             = "pcie_ue"             // uncorrectable errors reported by PCIe AER
+	PCIeCE             = "pcie_ce"             // correctable errors reported by PCIe AER
+	NICRxErrors        = "nic_rx_errors"       // receive errors of a network interface
+	NICTxErrors        = "nic_tx_errors"       // transmit errors of a network interface
+	GPUCE              = "gpu_ce"              // corrected ECC errors of a GPU
+	GPUUE              = "gpu_ue"              // uncorrected ECC errors of a GPU
+	GPURetiredPages    = "gpu_retired_pages"   // memory pages retired by a GPU
+)
+
+// Checks returns the names of all checks
+func Checks() []string {
+	return []string{
+		CPUThrottle, MemoryCE, MemoryUE, DIMMCE, SMART, SSDUsed, ReallocatedSectors, PendingSectors,
+		DriveErrors, PCIeUE, PCIeCE, NICRxErrors, NICTxErrors, GPUCE, GPUUE, GPURetiredPages,
+	}
+}
+
+// counterChecks are checks on cumulative counters, they can be evaluated as deltas since the previous diagnosis
+var counterChecks = map[string]bool{
+	CPUThrottle:        true,
+	MemoryCE:           true,
+	MemoryUE:           true,
+	DIMMCE:             true,
+	ReallocatedSectors: true,
+	PendingSectors:     true,
+	DriveErrors:        true,
+	PCIeUE:             true,
+	PCIeCE:             true,
+	NICRxErrors:        true,
+	NICTxErrors:        true,
+	GPUCE:              true,
+	GPUUE:              true,
+	GPURetiredPages:    true,
+}
+
+// Severity represents how bad a result is, the larger the worse
+type Severity int
+
+// These are severities
+const (
+	None Severity = iota
+	Warning
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	}
+	return "none"
+}
+
+// Policy decides what makes a component unhealthy and how lsdiag exits
+//
+//	thresholds:
+//	  ssd_used: {warning: 80, critical: 95}
+//	  memory_ce: {warning: 100, critical: 1000}
+//	  pcie_ce: {}               # never checked
+//	  dimm_ce: {critical: 10, per: 24h}
+//	  nic_rx_errors: {warning: 0, delta: true}
+//	ignore:
+//	  - model: "MZ7LH*"
+//	    checks: [smart]
+//	exitCodes:
+//	  warning: 2
+//	  critical: 1
+//
+// A value above a threshold makes a result warning or critical.
+// Thresholds not given are taken from the default policy.
+// A counter can be checked by the increase since the previous diagnosis (delta) or within a duration (per),
+// they need the state of the previous diagnoses kept in a file.
+type Policy struct {
+	Thresholds map[string]*Threshold `yaml:"thresholds"`
+	Ignore     []*IgnoreRule         `yaml:"ignore"`
+	ExitCodes  ExitCodes             `yaml:"exitCodes"`
+	State      string                `yaml:"state"` // path to the state file
+
+	prev *State
+	next *State
+}
+
+// Threshold represents thresholds of a check, nil means not checked
+type Threshold struct {
+	Warning  *float64      `yaml:"warning"`
+	Critical *float64      `yaml:"critical"`
+	Delta    bool          `yaml:"delta"` // compares the increase since the previous diagnosis
+	Per      time.Duration `yaml:"per"`   // compares the increase within the duration, implies delta
+}
+
+func (t Threshold) delta() bool {
+	return t.Delta || t.Per > 0
+}
+
+// NeedsState returns whether the policy has thresholds on deltas
+func (p *Policy) NeedsState() bool {
+	for _, t := range p.Thresholds {
+		if t != nil && t.delta() {
+			return true
+		}
+	}
+	return false
+}
+
+// StatePath returns the path to the state file, empty if the policy needs no state
+func (p *Policy) StatePath() string {
+	if p.State != "" {
+		return p.State
+	}
+	if p.NeedsState() {
+		return DefaultStatePath
+	}
+	return ""
+}
+
+// IgnoreRule skips checks of components matching the model and the serial number
+// model and serial are shell patterns, empty ones match anything, empty checks mean all checks
+type IgnoreRule struct {
+	Model  string   `yaml:"model"`
+	Serial string   `yaml:"serial"`
+	Checks []string `yaml:"checks"`
+}
+
+// ExitCodes represents exit codes of lsdiag, nil means the default
+type ExitCodes struct {
+	Warning     *int `yaml:"warning"`
+	Critical    *int `yaml:"critical"`
+	Uninspected *int `yaml:"uninspected"`
+}
+
+// These are default exit codes
+const (
+	DefaultExitHealthy     = 0
+	DefaultExitCritical    = 1
+	DefaultExitWarning     = 2
+	DefaultExitUninspected = 0
+)
+
+func threshold(critical float64) *Threshold {
+	return &Threshold{Critical: &critical}
+}
+
+// DefaultPolicy returns the policy lsdiag has always followed
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Thresholds: map[string]*Threshold{
+			CPUThrottle: threshold(0),
+			MemoryCE:    threshold(1000),
+			MemoryUE:    threshold(0),
+			SMART:       threshold(0),
+			DriveErrors: threshold(0),
+			PCIeUE:      threshold(0),
+			PCIeCE:      threshold(0),
+			GPUCE:       threshold(0),
+			GPUUE:       threshold(0),
+		},
+	}
+}
+
+// LoadPolicy reads a policy from the file
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ParsePolicy(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return p, nil
+}
+
+// ParsePolicy parses a policy written in YAML over the default policy
+func ParsePolicy(b []byte) (*Policy, error) {
+	in := new(Policy)
+	err := yaml.UnmarshalStrict(b, in)
+	if err != nil {
+		return nil, err
+	}
+
+	err = in.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	p := DefaultPolicy()
+	for name, t := range in.Thresholds {
+		p.Thresholds[name] = t
+	}
+	p.Ignore = in.Ignore
+	p.ExitCodes = in.ExitCodes
+	p.State = in.State
+
+	return p, nil
+}
+
+func (p *Policy) validate() error {
+	known := make(map[string]bool)
+	for _, c := range Checks() {
+		known[c] = true
+	}
+	available := strings.Join(Checks(), ", ")
+
+	var names []string
+	for name := range p.Thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("unknown check: %s (available: %s)", name, available)
+		}
+
+		t := p.Thresholds[name]
+		if t == nil {
+			continue
+		}
+		if t.Warning != nil && t.Critical != nil && *t.Warning > *t.Critical {
+			return fmt.Errorf("%s: warning %v is above critical %v", name, *t.Warning, *t.Critical)
+		}
+		if t.Per < 0 {
+			return fmt.Errorf("%s: negative per: %s", name, t.Per)
+		}
+		if t.delta() && !counterChecks[name] {
+			return fmt.Errorf("%s: not a counter, delta and per are not available", name)
+		}
+	}
+
+	for i, ig := range p.Ignore {
+		if ig == nil || (ig.Model == "" && ig.Serial == "" && len(ig.Checks) == 0) {
+			return fmt.Errorf("ignore %d: neither model, serial nor checks is given", i+1)
+		}
+		for _, pat := range []string{ig.Model, ig.Serial} {
+			if _, err := path.Match(pat, ""); err != nil {
+				return fmt.Errorf("ignore %d: invalid pattern: %s", i+1, pat)
+			}
+		}
+		for _, c := range ig.Checks {
+			if !known[c] {
+				return fmt.Errorf("ignore %d: unknown check: %s (available: %s)", i+1, c, available)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ignores returns whether the check of a component is ignored
+func (p *Policy) ignores(check, model, serial string) bool {
+	for _, ig := range p.Ignore {
+		if !matchPattern(ig.Model, model) || !matchPattern(ig.Serial, serial) {
+			continue
+		}
+		if len(ig.Checks) == 0 {
+			return true
+		}
+		for _, c := range ig.Checks {
+			if c == check {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, strings.TrimSpace(s))
+	return ok
+}
+
+// ExitCode returns the exit code of lsdiag for the diagnosis
+func (p *Policy) ExitCode(d *Diagnosis) int {
+	code := func(c *int, def int) int {
+		if c != nil {
+			return *c
+		}
+		return def
+	}
+
+	uninspected := false
+	worst := None
+	for _, res := range d.Results {
+		switch res.Status {
+		case Unhealthy:
+			worst = Critical
+		case Warned:
+			if worst < Warning {
+				worst = Warning
+			}
+		case Uninspected:
+			uninspected = true
+		}
+	}
+
+	switch {
+	case worst == Critical:
+		return code(p.ExitCodes.Critical, DefaultExitCritical)
+	case worst == Warning:
+		return code(p.ExitCodes.Warning, DefaultExitWarning)
+	case uninspected:
+		return code(p.ExitCodes.Uninspected, DefaultExitUninspected)
+	}
+	return DefaultExitHealthy
+}
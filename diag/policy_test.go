@@ -0,0 +1,132 @@
+package diag
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in  string
+		err bool
+	}{
+		{"thresholds: {ssd_used: {warning: 80, critical: 95}}", false},
+		{"thresholds: {pcie_ce: {}}", false},
+		{"ignore: [{model: 'MZ7*', checks: [smart]}]", false},
+		{"exitCodes: {warning: 3}", false},
+		{"thresholds: {unknown: {critical: 1}}", true},
+		{"thresholds: {ssd_used: {warning: 95, critical: 80}}", true},
+		{"thresholds: {ssd_used: {warn: 80}}", true},
+		{"ignore: [{}]", true},
+		{"ignore: [{checks: [unknown]}]", true},
+		{"ignore: [{model: '['}]", true},
+		{"thresholds: {dimm_ce: {critical: 10, per: 24h}}", false},
+		{"thresholds: {nic_rx_errors: {warning: 0, delta: true}}", false},
+		{"thresholds: {ssd_used: {critical: 95, delta: true}}", true},
+		{"thresholds: {memory_ce: {critical: 10, per: -1h}}", true},
+		{"state: /tmp/diag-state.json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.in))
+			if (err != nil) != tt.err {
+				t.Errorf("got: %v, expect error: %t", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParsePolicyOverDefault(t *testing.T) {
+	p, err := ParsePolicy([]byte("thresholds: {memory_ce: {warning: 10}, pcie_ce: {}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mc := p.Thresholds[MemoryCE]; mc.Critical != nil || mc.Warning == nil || *mc.Warning != 10 {
+		t.Errorf("memory_ce should be replaced, got: %+v", mc)
+	}
+	if pc := p.Thresholds[PCIeCE]; pc.Critical != nil || pc.Warning != nil {
+		t.Errorf("pcie_ce should be disabled, got: %+v", pc)
+	}
+	if mu := p.Thresholds[MemoryUE]; mu == nil || *mu.Critical != 0 {
+		t.Errorf("memory_ue should be kept, got: %+v", mu)
+	}
+}
+
+func TestPolicyDiagnose(t *testing.T) {
+	used := func(v byte) *model.NVMeController {
+		n := new(model.NVMeController)
+		n.Model = "PM1725b"
+		n.SerialNumber = fmt.Sprintf("S%d", v)
+		n.Used = &v
+		n.CEList = []string{"BadTLP"}
+		return n
+	}
+	r := &model.Report{
+		Storage: &model.StorageReport{
+			NVMeControllers: []*model.NVMeController{used(10), used(85), used(99)},
+		},
+	}
+
+	p, err := ParsePolicy([]byte(`
+thresholds:
+  ssd_used: {warning: 80, critical: 95}
+ignore:
+  - model: PM1725*
+    checks: [pcie_ce]
+  - serial: S99
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := p.Diagnose(r)
+
+	var got []string
+	for _, res := range d.Results {
+		got = append(got, res.Status)
+	}
+	expect := []string{Healthy, Warned, Healthy}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got: %v, expect: %v", got, expect)
+	}
+	if !d.Healthy || !d.Warning {
+		t.Errorf("got: healthy %t, warning %t, expect: healthy true, warning true", d.Healthy, d.Warning)
+	}
+	if code := p.ExitCode(d); code != DefaultExitWarning {
+		t.Errorf("exit code got: %d, expect: %d", code, DefaultExitWarning)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	three := 3
+	custom := &Policy{ExitCodes: ExitCodes{Uninspected: &three}}
+
+	tests := []struct {
+		policy *Policy
+		stats  []string
+		expect int
+	}{
+		{DefaultPolicy(), []string{Healthy}, DefaultExitHealthy},
+		{DefaultPolicy(), []string{Healthy, Uninspected}, DefaultExitUninspected},
+		{DefaultPolicy(), []string{Warned, Uninspected}, DefaultExitWarning},
+		{DefaultPolicy(), []string{Warned, Unhealthy}, DefaultExitCritical},
+		{custom, []string{Healthy, Uninspected}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt.stats), func(t *testing.T) {
+			d := new(Diagnosis)
+			for _, s := range tt.stats {
+				d.append("test", "", s)
+			}
+			if got := tt.policy.ExitCode(d); got != tt.expect {
+				t.Errorf("got: %d, expect: %d", got, tt.expect)
+			}
+		})
+	}
+}
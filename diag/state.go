@@ -0,0 +1,216 @@
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// DefaultStatePath is where lsdiag keeps counters when the policy has thresholds on deltas
+const DefaultStatePath = "/var/lib/mox/diag-state.json"
+
+// samplesPerWindow limits the samples kept within the longest per, runs closer than per/samplesPerWindow are not kept
+const samplesPerWindow = 48
+
+// State represents counters of a diagnosis kept for the next one
+type State struct {
+	Timestamp int64              `json:"timestamp"`         // unix time the counters were read at
+	Counters  map[string]float64 `json:"counters"`          // keyed by check/component[/part]
+	Samples   []*Sample          `json:"samples,omitempty"` // earlier counters checked per a duration, oldest first
+}
+
+// Sample represents counters read at a time
+type Sample struct {
+	Timestamp int64              `json:"timestamp"`
+	Counters  map[string]float64 `json:"counters"`
+}
+
+func newState(ts int64) *State {
+	s := new(State)
+	s.Timestamp = ts
+	s.Counters = make(map[string]float64)
+	return s
+}
+
+// LoadState reads a state from the file, it returns nil without error if the file does not exist
+func LoadState(path string) (*State, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(State)
+	err = json.Unmarshal(b, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the state to the file at once so that a crash never leaves a broken file
+func (s *State) Save(path string) error {
+	jb, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(jb)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// DiagnoseSince diagnoses the components in the report comparing counters with the previous state
+// and returns the state to be kept for the next diagnosis, prev may be nil on the first run
+func (p *Policy) DiagnoseSince(r *model.Report, prev *State) (*Diagnosis, *State) {
+	ts := r.Timestamp
+	if ts == 0 {
+		ts = time.Now().Unix()
+	}
+
+	dp := *p
+	dp.prev = prev
+	dp.next = newState(ts)
+	dp.next.Samples = p.samples(prev, ts)
+
+	return dp.Diagnose(r), dp.next
+}
+
+// samples returns the samples of the previous state and the previous state itself which are kept for the next diagnosis
+// those older than the longest per are dropped
+func (p *Policy) samples(prev *State, now int64) []*Sample {
+	w := p.window()
+	if prev == nil || w == 0 {
+		return nil
+	}
+
+	start := now - int64(w/time.Second)
+	gap := int64(w / samplesPerWindow / time.Second)
+
+	var kept []*Sample
+	for _, s := range prev.history() {
+		if s.Timestamp < start {
+			continue
+		}
+		if len(kept) > 0 && s.Timestamp-kept[len(kept)-1].Timestamp < gap {
+			continue
+		}
+
+		c := make(map[string]float64)
+		for key, v := range s.Counters {
+			if t := p.Thresholds[checkOf(key)]; t != nil && t.Per > 0 {
+				c[key] = v
+			}
+		}
+		kept = append(kept, &Sample{Timestamp: s.Timestamp, Counters: c})
+	}
+	return kept
+}
+
+// window returns the longest per of the thresholds
+func (p *Policy) window() time.Duration {
+	var w time.Duration
+	for _, t := range p.Thresholds {
+		if t != nil && t.Per > w {
+			w = t.Per
+		}
+	}
+	return w
+}
+
+// history returns the samples and the counters of the state itself, oldest first
+func (s *State) history() []*Sample {
+	return append(append([]*Sample{}, s.Samples...), &Sample{Timestamp: s.Timestamp, Counters: s.Counters})
+}
+
+// checkOf returns the name of the check of a counter key
+func checkOf(key string) string {
+	return strings.SplitN(key, "/", 2)[0]
+}
+
+// delta returns the increase of the counter since the previous state and the time elapsed
+// a counter which decreased is taken as reset (e.g. on reboot) and the whole value is the increase
+func (p *Policy) delta(key string, v float64) (float64, time.Duration, bool) {
+	if p.prev == nil || p.next == nil {
+		return 0, 0, false
+	}
+
+	pv, ok := p.prev.Counters[key]
+	if !ok {
+		return 0, 0, false
+	}
+
+	elapsed := time.Duration(p.next.Timestamp-p.prev.Timestamp) * time.Second
+	if elapsed <= 0 {
+		return 0, 0, false
+	}
+
+	if v < pv {
+		return v, elapsed, true
+	}
+	return v - pv, elapsed, true
+}
+
+// deltaWithin returns the increase of the counter since the oldest sample within per and the time elapsed
+// if there is no sample within per, it is taken since the latest one and the elapsed time exceeds per
+func (p *Policy) deltaWithin(key string, v float64, per time.Duration) (float64, time.Duration, bool) {
+	if p.prev == nil || p.next == nil {
+		return 0, 0, false
+	}
+
+	start := p.next.Timestamp - int64(per/time.Second)
+
+	var base *Sample
+	for _, s := range p.prev.history() {
+		if _, ok := s.Counters[key]; !ok {
+			continue
+		}
+		base = s
+		if s.Timestamp >= start {
+			break
+		}
+	}
+	if base == nil {
+		return 0, 0, false
+	}
+
+	elapsed := time.Duration(p.next.Timestamp-base.Timestamp) * time.Second
+	if elapsed <= 0 {
+		return 0, 0, false
+	}
+
+	bv := base.Counters[key]
+	if v < bv {
+		return v, elapsed, true
+	}
+	return v - bv, elapsed, true
+}
@@ -0,0 +1,164 @@
+package diag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func memoryReport(ts int64, ce uint64) *model.Report {
+	return &model.Report{
+		Timestamp: ts,
+		Memory: &model.MemoryReport{
+			Controllers: []*model.MemoryController{
+				{
+					Name: "mc0",
+					CSRows: []*model.ChipSelectRow{
+						{Name: "csrow0", CECount: ce},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiagnoseSince(t *testing.T) {
+	const day = 86400
+
+	tests := []struct {
+		policy  string
+		prevCE  uint64
+		curCE   uint64
+		elapsed int64
+		status  string
+		finding string
+	}{
+		{"{memory_ce: {critical: 10, delta: true}}", 5000, 5005, day, Healthy, ""},
+		{"{memory_ce: {critical: 10, delta: true}}", 5000, 5020, day, Unhealthy, "memory_ce = +20 in 24h0m0s > 10 (critical)"},
+		{"{memory_ce: {critical: 10, per: 24h}}", 100, 112, day / 2, Unhealthy, "memory_ce = +12 in 12h0m0s > 10 (critical)"},
+		{"{memory_ce: {critical: 10, per: 24h}}", 100, 105, 300, Healthy, ""},
+		{"{memory_ce: {critical: 10, per: 24h}}", 100, 112, 2 * day, Healthy, ""},
+		{"{memory_ce: {critical: 10, per: 24h}}", 100, 150, 2 * day, Unhealthy, "memory_ce = 25.0 per 24h0m0s (+50 in 48h0m0s) > 10 (critical)"},
+		// a counter reset on reboot counts from zero
+		{"{memory_ce: {warning: 2, delta: true}}", 5000, 3, day, Warned, "memory_ce = +3 in 24h0m0s > 2 (warning)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			p, err := ParsePolicy([]byte("thresholds: " + tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, prev := p.DiagnoseSince(memoryReport(1000, tt.prevCE), nil)
+			d, next := p.DiagnoseSince(memoryReport(1000+tt.elapsed, tt.curCE), prev)
+
+			res := d.Results[0]
+			if res.Status != tt.status {
+				t.Errorf("got: %s, want: %s (%v)", res.Status, tt.status, res.Details)
+			}
+			if tt.finding != "" && res.Details[len(res.Details)-1] != tt.finding {
+				t.Errorf("got: %v, want: %s", res.Details, tt.finding)
+			}
+			if next.Counters["memory_ce/mc0/csrow0"] != float64(tt.curCE) {
+				t.Errorf("counter is not kept: %v", next.Counters)
+			}
+		})
+	}
+}
+
+func TestDiagnoseSinceWindow(t *testing.T) {
+	p, err := ParsePolicy([]byte("thresholds: {memory_ce: {critical: 10, per: 24h}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		interval int64 // seconds between runs, a CE is added on every run
+		runs     int
+		status   string
+		finding  string
+	}{
+		{300, 10, Healthy, ""},
+		{3600, 10, Healthy, ""},
+		{3600, 11, Unhealthy, "memory_ce = +11 in 11h0m0s > 10 (critical)"},
+		{3600, 48, Unhealthy, "memory_ce = +24 in 24h0m0s > 10 (critical)"},
+		{3 * 3600, 40, Healthy, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			var d *Diagnosis
+			_, state := p.DiagnoseSince(memoryReport(1000, 100), nil)
+			for i := 1; i <= tt.runs; i++ {
+				d, state = p.DiagnoseSince(memoryReport(1000+int64(i)*tt.interval, uint64(100+i)), state)
+			}
+
+			res := d.Results[0]
+			if res.Status != tt.status {
+				t.Errorf("got: %s, want: %s (%v)", res.Status, tt.status, res.Details)
+			}
+			if tt.finding != "" && res.Details[len(res.Details)-1] != tt.finding {
+				t.Errorf("got: %v, want: %s", res.Details, tt.finding)
+			}
+			if len(state.Samples) > samplesPerWindow+1 {
+				t.Errorf("too many samples are kept: %d", len(state.Samples))
+			}
+		})
+	}
+}
+
+func TestDiagnoseSinceFirstRun(t *testing.T) {
+	p, err := ParsePolicy([]byte("thresholds: {memory_ce: {critical: 10, delta: true}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, next := p.DiagnoseSince(memoryReport(1000, 5000), nil)
+	if d.Results[0].Status != Healthy {
+		t.Errorf("a delta can not be taken on the first run, got: %+v", d.Results[0])
+	}
+	if next.Timestamp != 1000 {
+		t.Errorf("got: %d, want: 1000", next.Timestamp)
+	}
+
+	// the plain diagnosis ignores delta thresholds as well
+	if st := p.Diagnose(memoryReport(1000, 5000)).Results[0].Status; st != Healthy {
+		t.Errorf("got: %s, want: %s", st, Healthy)
+	}
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "mox", "diag-state.json")
+
+	s, err := LoadState(path)
+	if s != nil || err != nil {
+		t.Fatalf("a missing state should be nil without error, got: %v, %v", s, err)
+	}
+
+	want := newState(1000)
+	want.Counters["memory_ce/mc0/csrow0"] = 12
+	err = want.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
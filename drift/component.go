@@ -0,0 +1,303 @@
+package drift
+
+import (
+	"fmt"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// These are names of compared components
+const (
+	SystemComponent         = "system"
+	ChassisComponent        = "chassis"
+	BaseboardComponent      = "baseboard"
+	FirmwareComponent       = "firmware"
+	BMCComponent            = "bmc"
+	OSComponent             = "os"
+	ProcessorComponent      = "processor"
+	MemoryComponent         = "memory"
+	PCIDeviceComponent      = "pci"
+	NetInterfaceComponent   = "nic"
+	NVMeComponent           = "nvme"
+	RAIDControllerComponent = "raid"
+	LogDriveComponent       = "logdrive"
+	PhyDriveComponent       = "phydrive"
+	DriveComponent          = "drive"
+	GPUComponent            = "gpu"
+	PowerSupplyComponent    = "psu"
+)
+
+type field struct {
+	name  string
+	value string
+}
+
+// component represents a comparable part of a report
+type component struct {
+	component string
+	id        string
+	summary   string
+	fields    []field
+}
+
+func newComponent(name, id, summary string) *component {
+	c := new(component)
+	c.component = name
+	c.id = id
+	c.summary = summary
+	return c
+}
+
+func (c *component) set(name string, v interface{}) *component {
+	c.fields = append(c.fields, field{name: name, value: fmt.Sprint(v)})
+	return c
+}
+
+func (c component) value(name string) string {
+	for _, f := range c.fields {
+		if f.name == name {
+			return f.value
+		}
+	}
+	return ""
+}
+
+// index keys components by their identity, a duplicated identity gets a suffix in order of appearance
+func index(cs []*component) map[string]*component {
+	m := make(map[string]*component)
+	for _, c := range cs {
+		id := c.id
+		for n := 2; ; n++ {
+			if _, ok := m[c.component+"/"+id]; !ok {
+				break
+			}
+			id = fmt.Sprintf("%s#%d", c.id, n)
+		}
+		c.id = id
+		m[c.component+"/"+id] = c
+	}
+	return m
+}
+
+// firstOf returns the first non-empty identity
+func firstOf(ids ...string) string {
+	for _, id := range ids {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// pciKey identifies a device by its PCI ID along with the slot
+func pciKey(p model.PCIBaseSpec) string {
+	return fmt.Sprintf("%04x:%04x@%s", p.VendorID, p.DeviceID, p.PCIID())
+}
+
+func linkString(l *model.PCIeLink) string {
+	if l == nil {
+		return ""
+	}
+	return fmt.Sprintf("Gen%d %.1fGT/s x%d", l.Gen, l.Speed, l.Width)
+}
+
+func components(r *model.Report) []*component {
+	if r == nil {
+		return nil
+	}
+
+	var cs []*component
+
+	if s := r.System; s != nil {
+		cs = append(cs, newComponent(SystemComponent, "system", s.Summary()).
+			set("manufacturer", s.Manufacturer).
+			set("productName", s.ProductName).
+			set("serialNumber", s.SerialNumber))
+	}
+
+	if c := r.Chassis; c != nil {
+		cs = append(cs, newComponent(ChassisComponent, "chassis", c.Manufacturer).
+			set("manufacturer", c.Manufacturer).
+			set("serialNumber", c.SerialNumber))
+	}
+
+	if b := r.Baseboard; b != nil {
+		cs = append(cs, newComponent(BaseboardComponent, "baseboard", b.Summary()).
+			set("manufacturer", b.Manufacturer).
+			set("productName", b.ProductName).
+			set("serialNumber", b.SerialNumber))
+	}
+
+	if f := r.Firmware; f != nil {
+		cs = append(cs, newComponent(FirmwareComponent, "firmware", f.Summary()).
+			set("type", f.Type).
+			set("vendor", f.Vendor).
+			set("version", f.Version).
+			set("releaseDate", f.ReleaseDate))
+	}
+
+	if b := r.BMC; b != nil {
+		cs = append(cs, newComponent(BMCComponent, "bmc", b.Type).
+			set("type", b.Type).
+			set("firmware", b.Firmware).
+			set("hwaddr", b.MAC))
+	}
+
+	if o := r.OS; o != nil {
+		cs = append(cs, newComponent(OSComponent, "os", o.Distro).
+			set("distro", o.Distro).
+			set("kernel", o.Kernel))
+	}
+
+	if r.Processor != nil {
+		for _, p := range r.Processor.Packages {
+			id := firstOf(p.Socket, fmt.Sprintf("package%d", p.ID))
+			cs = append(cs, newComponent(ProcessorComponent, id, p.ProductName).
+				set("manufacturer", p.Manufacturer).
+				set("productName", p.ProductName).
+				set("serialNumber", p.SerialNumber).
+				set("coreCount", p.CoreCount).
+				set("threadCount", p.ThreadCount))
+		}
+	}
+
+	if r.Memory != nil {
+		for _, m := range r.Memory.Modules {
+			cs = append(cs, newComponent(MemoryComponent, m.Locator, m.Summary()).
+				set("manufacturer", m.Manufacturer).
+				set("partNumber", m.PartNumber).
+				set("serialNumber", m.SerialNumber).
+				set("type", m.Type).
+				set("formFactor", m.FormFactor).
+				set("size", m.SizeString()).
+				set("speed", m.Speed).
+				set("configuredSpeed", m.ConfiguredSpeed))
+		}
+	}
+
+	for _, p := range r.PCIDevice {
+		if p == nil {
+			continue
+		}
+		cs = append(cs, newComponent(PCIDeviceComponent, pciKey(*p), p.LongName()).
+			set("subsystem", fmt.Sprintf("%04x:%04x", p.SubSystemVendorID, p.SubSystemDeviceID)).
+			set("serialNumber", p.SerialNumber).
+			set("driver", p.Driver).
+			set("currentLink", linkString(p.CurLink)).
+			set("maxLink", linkString(p.MaxLink)))
+	}
+
+	if r.Network != nil {
+		for _, e := range r.Network.EthControllers {
+			for _, n := range e.Interfaces {
+				var modName, modSerial string
+				if n.Module != nil {
+					modName = n.Module.ProductName
+					modSerial = n.Module.SerialNumber
+				}
+				cs = append(cs, newComponent(NetInterfaceComponent, firstOf(n.HWAddr, n.Name), e.LongName()).
+					set("name", n.Name).
+					set("controller", pciKey(e.PCIBaseSpec)).
+					set("firmwareVersion", n.FirmwareVersion).
+					set("speed", n.Speed).
+					set("mtu", n.MTU).
+					set("module", modName).
+					set("moduleSerialNumber", modSerial))
+			}
+		}
+	}
+
+	if r.Storage != nil {
+		cs = append(cs, storageComponents(r.Storage)...)
+	}
+
+	if r.Accelerator != nil {
+		for _, g := range r.Accelerator.GPUs {
+			cs = append(cs, newComponent(GPUComponent, firstOf(g.SerialNumber, pciKey(g.PCIBaseSpec)), g.LongName()).
+				set("productName", g.ProductName).
+				set("bios", g.BIOS).
+				set("slot", g.PCIID()))
+		}
+	}
+
+	// power supplies have no serial number while they are absent, so the position is the identity
+	for i, p := range r.PowerSupply {
+		cs = append(cs, newComponent(PowerSupplyComponent, fmt.Sprintf("PSU%d", i), p.Summary()).
+			set("manufacturer", p.Manufacturer).
+			set("productName", p.ProductName).
+			set("modelPartNumber", p.ModelPartNumber).
+			set("serialNumber", p.SerialNumber).
+			set("capacity", p.Capacity).
+			set("present", p.Present).
+			set("plugged", p.Plugged))
+	}
+
+	return cs
+}
+
+func storageComponents(s *model.StorageReport) []*component {
+	var cs []*component
+
+	for _, n := range s.NVMeControllers {
+		cs = append(cs, newComponent(NVMeComponent, firstOf(n.SerialNumber, pciKey(n.PCIBaseSpec)), n.LongName()).
+			set("model", n.Model).
+			set("firmware", n.Firmware).
+			set("size", n.SizeString()).
+			set("slot", n.PCIID()))
+	}
+
+	for _, r := range s.RAIDControllers {
+		key := pciKey(r.PCIBaseSpec)
+		cs = append(cs, newComponent(RAIDControllerComponent, key, r.Summary()).
+			set("productName", r.ProductName).
+			set("serialNumber", r.SerialNumber).
+			set("firmware", r.Firmware).
+			set("bios", r.BIOS).
+			set("battery", r.Battery))
+
+		var pds []*model.PhyDrive
+		for _, ld := range r.LogDrives {
+			cs = append(cs, newComponent(LogDriveComponent, firstOf(ld.WWN, key+"/"+firstOf(ld.GroupLabel, ld.Name)), ld.LDSummary()).
+				set("raidLv", ld.RAIDLv).
+				set("status", ld.Status).
+				set("size", ld.SizeString()).
+				set("stripeSize", ld.StripeSize).
+				set("cachePolicy", ld.CachePolicy))
+			pds = append(pds, ld.PhyDrives...)
+		}
+		pds = append(pds, r.UnconfDrives...)
+		pds = append(pds, r.PassthroughDrives...)
+
+		for _, pd := range pds {
+			cs = append(cs, newComponent(PhyDriveComponent, firstOf(pd.SerialNumber, pd.WWN, key+"/"+pd.Pos()), pd.Model).
+				set("model", pd.Model).
+				set("firmware", pd.Firmware).
+				set("size", pd.SizeString()).
+				set("controller", key).
+				set("position", pd.Pos()).
+				set("status", pd.Status))
+		}
+	}
+
+	var drives []*model.Drive
+	for _, a := range s.AHCIControllers {
+		drives = append(drives, a.Drives...)
+	}
+	for _, v := range s.VirtControllers {
+		drives = append(drives, v.Drives...)
+	}
+	for _, d := range drives {
+		cs = append(cs, newComponent(DriveComponent, firstOf(d.SerialNumber, d.Name), d.Model).
+			set("model", d.Model).
+			set("firmware", d.Firmware).
+			set("size", d.SizeString()))
+	}
+
+	for _, n := range s.NonStdControllers {
+		cs = append(cs, newComponent(DriveComponent, firstOf(n.SerialNumber, pciKey(n.PCIBaseSpec)), n.Model).
+			set("model", n.Model).
+			set("firmware", n.Firmware))
+	}
+
+	return cs
+}
@@ -0,0 +1,124 @@
+// Package drift compares two reports and classifies hardware drift between them
+//
+// Components are matched by a stable identity rather than by their position in a report:
+// serial numbers, PCI IDs along with the slot, DIMM locators and WWNs.
+// Only fields describing the hardware and its firmware are compared, counters and
+// sensor readings are ignored since they change on every run.
+package drift
+
+import (
+	"sort"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// Kind represents a kind of drift
+type Kind string
+
+// These are kinds of drift
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// FieldChange represents a field whose value differs between two reports
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Change represents a component which has been added, removed or changed
+type Change struct {
+	Kind      Kind           `json:"kind"`
+	Component string         `json:"component"`
+	ID        string         `json:"id"`
+	Summary   string         `json:"summary,omitempty"`
+	Fields    []*FieldChange `json:"fields,omitempty"`
+}
+
+// Result represents drift between two reports
+type Result struct {
+	Added   int       `json:"added"`
+	Removed int       `json:"removed"`
+	Changed int       `json:"changed"`
+	Changes []*Change `json:"changes"`
+}
+
+// HasDrift returns whether any component differs
+func (r Result) HasDrift() bool {
+	return len(r.Changes) > 0
+}
+
+// Compare compares two reports
+func Compare(before, after *model.Report) *Result {
+	olds := index(components(before))
+	news := index(components(after))
+
+	res := newResult()
+	for key, oc := range olds {
+		nc, ok := news[key]
+		if !ok {
+			res.append(&Change{Kind: Removed, Component: oc.component, ID: oc.id, Summary: oc.summary})
+			continue
+		}
+
+		fcs := compareFields(oc, nc)
+		if len(fcs) == 0 {
+			continue
+		}
+		res.append(&Change{Kind: Changed, Component: nc.component, ID: nc.id, Summary: nc.summary, Fields: fcs})
+	}
+
+	for key, nc := range news {
+		if _, ok := olds[key]; ok {
+			continue
+		}
+		res.append(&Change{Kind: Added, Component: nc.component, ID: nc.id, Summary: nc.summary})
+	}
+
+	sort.Slice(res.Changes, func(i, j int) bool {
+		a, b := res.Changes[i], res.Changes[j]
+		if a.Component != b.Component {
+			return a.Component < b.Component
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Kind < b.Kind
+	})
+
+	return res
+}
+
+func newResult() *Result {
+	res := new(Result)
+	res.Changes = []*Change{}
+	return res
+}
+
+func (r *Result) append(c *Change) {
+	switch c.Kind {
+	case Added:
+		r.Added++
+	case Removed:
+		r.Removed++
+	case Changed:
+		r.Changed++
+	}
+	r.Changes = append(r.Changes, c)
+}
+
+// compareFields compares fields of the same component, every component of a kind has the same set of fields
+func compareFields(before, after *component) []*FieldChange {
+	var fcs []*FieldChange
+	for _, f := range before.fields {
+		v := after.value(f.name)
+		if f.value == v {
+			continue
+		}
+		fcs = append(fcs, &FieldChange{Field: f.name, Old: f.value, New: v})
+	}
+	return fcs
+}
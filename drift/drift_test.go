@@ -0,0 +1,179 @@
+package drift
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func dimm(loc, sn string) *model.MemoryModule {
+	m := new(model.MemoryModule)
+	m.Locator = loc
+	m.Manufacturer = "Samsung"
+	m.PartNumber = "M393A4K40CB2-CTD"
+	m.SerialNumber = sn
+	m.Type = "DDR4"
+	m.Speed = 2666
+	m.Size = 32 * 1024 * 1024 * 1024
+	return m
+}
+
+func nic(loc uint32, hwaddr, fw string) *model.EthController {
+	e := new(model.EthController)
+	e.VendorID = 0x15b3
+	e.DeviceID = 0x1015
+	e.Location.Bus = loc
+	e.Interfaces = []*model.NetInterface{
+		{Name: fmt.Sprintf("eth%d", loc), HWAddr: hwaddr, FirmwareVersion: fw, Speed: 25000, MTU: 9000},
+	}
+	return e
+}
+
+func pd(sn, wwn, slot string) *model.PhyDrive {
+	p := new(model.PhyDrive)
+	p.SerialNumber = sn
+	p.WWN = wwn
+	p.Model = "ST4000NM0035"
+	p.Enclosure = "32"
+	p.Slot = slot
+	p.Status = "Online"
+	return p
+}
+
+func report() *model.Report {
+	r := new(model.Report)
+	r.System = &model.System{Manufacturer: "ACME", ProductName: "R1", SerialNumber: "SYS01"}
+	r.Firmware = &model.Firmware{Vendor: "ACME", Version: "1.0.0", ReleaseDate: "01/01/2020"}
+	r.Memory = &model.MemoryReport{Modules: []*model.MemoryModule{dimm("A0", "0001"), dimm("B0", "0002")}}
+	r.Network = &model.NetworkReport{EthControllers: []*model.EthController{nic(1, "b8:59:9f:00:00:01", "14.23.1020")}}
+
+	ctl := new(model.RAIDController)
+	ctl.VendorID = 0x1000
+	ctl.DeviceID = 0x005d
+	ctl.Location.Bus = 2
+	ctl.Firmware = "24.21.0-0012"
+	ctl.UnconfDrives = []*model.PhyDrive{pd("ZC1", "5000c500a0000001", "0"), pd("", "5000c500a0000002", "1")}
+	r.Storage = &model.StorageReport{RAIDControllers: []*model.RAIDController{ctl}}
+
+	r.PowerSupply = []*model.PowerSupply{
+		{Manufacturer: "Delta", SerialNumber: "PS1", Capacity: 750, Present: true, Plugged: true},
+		{Manufacturer: "Delta", SerialNumber: "PS2", Capacity: 750, Present: true, Plugged: true},
+	}
+	r.Timestamp = 1
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *model.Report)
+		ex     []*Change
+	}{
+		{
+			"identical",
+			func(r *model.Report) { r.Timestamp = 2 },
+			[]*Change{},
+		},
+		{
+			"dimm swapped",
+			func(r *model.Report) { r.Memory.Modules[1].SerialNumber = "0003" },
+			[]*Change{
+				{Kind: Changed, Component: MemoryComponent, ID: "B0", Fields: []*FieldChange{{"serialNumber", "0002", "0003"}}},
+			},
+		},
+		{
+			"dimm removed",
+			func(r *model.Report) { r.Memory.Modules = r.Memory.Modules[:1] },
+			[]*Change{
+				{Kind: Removed, Component: MemoryComponent, ID: "B0"},
+			},
+		},
+		{
+			"nic firmware bumped",
+			func(r *model.Report) { r.Network.EthControllers[0].Interfaces[0].FirmwareVersion = "14.26.1040" },
+			[]*Change{
+				{Kind: Changed, Component: NetInterfaceComponent, ID: "b8:59:9f:00:00:01", Fields: []*FieldChange{{"firmwareVersion", "14.23.1020", "14.26.1040"}}},
+			},
+		},
+		{
+			"drive replaced",
+			func(r *model.Report) {
+				r.Storage.RAIDControllers[0].UnconfDrives[0] = pd("ZC9", "5000c500a0000009", "0")
+			},
+			[]*Change{
+				{Kind: Removed, Component: PhyDriveComponent, ID: "ZC1"},
+				{Kind: Added, Component: PhyDriveComponent, ID: "ZC9"},
+			},
+		},
+		{
+			"drive matched by wwn",
+			func(r *model.Report) { r.Storage.RAIDControllers[0].UnconfDrives[1].Slot = "5" },
+			[]*Change{
+				{Kind: Changed, Component: PhyDriveComponent, ID: "5000c500a0000002", Fields: []*FieldChange{{"position", "32:1", "32:5"}}},
+			},
+		},
+		{
+			"psu missing",
+			func(r *model.Report) { r.PowerSupply[1] = &model.PowerSupply{Present: false} },
+			[]*Change{
+				{Kind: Changed, Component: PowerSupplyComponent, ID: "PSU1", Fields: []*FieldChange{
+					{"manufacturer", "Delta", ""},
+					{"serialNumber", "PS2", ""},
+					{"capacity", "750", "0"},
+					{"present", "true", "false"},
+					{"plugged", "true", "false"},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := report()
+			tt.modify(after)
+
+			res := Compare(report(), after)
+			for _, c := range res.Changes {
+				c.Summary = ""
+			}
+
+			if !reflect.DeepEqual(res.Changes, tt.ex) {
+				for _, c := range res.Changes {
+					t.Logf("got: %+v", c)
+					for _, f := range c.Fields {
+						t.Logf("  %+v", f)
+					}
+				}
+				t.Errorf("unexpected changes")
+			}
+			if res.HasDrift() != (len(tt.ex) > 0) {
+				t.Errorf("got: %t, expect: %t", res.HasDrift(), len(tt.ex) > 0)
+			}
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	cs := []*component{
+		newComponent(DriveComponent, "sda", ""),
+		newComponent(DriveComponent, "sda", ""),
+		newComponent(DriveComponent, "sda", ""),
+		newComponent(MemoryComponent, "sda", ""),
+	}
+
+	m := index(cs)
+
+	var got []string
+	for _, c := range cs {
+		got = append(got, c.component+"/"+c.id)
+	}
+	ex := []string{"drive/sda", "drive/sda#2", "drive/sda#3", "memory/sda"}
+	if !reflect.DeepEqual(got, ex) {
+		t.Errorf("got: %v, expect: %v", got, ex)
+	}
+	if len(m) != 4 {
+		t.Errorf("got: %d, expect: 4", len(m))
+	}
+}
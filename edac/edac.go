@@ -1,6 +1,7 @@
 package edac
 
 import (
+	"context"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -17,7 +18,7 @@ func init() {
 
 // NewDecoder creates and initializes a Topology as Decoder
 func NewDecoder() *Topology {
-	return newTopology("/sys/devices/system/edac/mc")
+	return newTopology(util.RootPath("/sys/devices/system/edac/mc"))
 }
 
 // Topology represents a edac topology
@@ -28,10 +29,18 @@ type Topology struct {
 
 // Decode makes Topology satisfy the mox.Decoder interface
 func (t *Topology) Decode() error {
+	return t.DecodeContext(context.Background())
+}
+
+// DecodeContext makes Topology satisfy the mox.ContextDecoder interface
+func (t *Topology) DecodeContext(ctx context.Context) error {
 	for _, mcdir := range util.FilterPrefixedDirs(t.path, "mc") {
 		log.Debugf("scanning %s", mcdir)
 		mc := newMemoryController(mcdir)
-		err := mc.decode()
+		err := mc.decode(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			log.Debug(err)
 		}
@@ -67,29 +76,29 @@ type MemoryController struct {
 	CSRows        []*ChipSelectRow
 }
 
-func (m *MemoryController) decode() error {
+func (m *MemoryController) decode(ctx context.Context) error {
 	var err error
 
-	mcname, err := util.LoadString(filepath.Join(m.Path, "mc_name"))
+	mcname, err := util.LoadStringWithContext(ctx, filepath.Join(m.Path, "mc_name"))
 	if err != nil {
 		return err
 	}
 	m.Name = mcname
 
-	sizemb, err := util.LoadUint64(filepath.Join(m.Path, "size_mb"))
+	sizemb, err := util.LoadUint64WithContext(ctx, filepath.Join(m.Path, "size_mb"))
 	if err != nil {
 		return err
 	}
 	m.Size = sizemb * 1000 * 1000
 
-	m.CECount, _ = util.LoadUint64(filepath.Join(m.Path, "ce_count"))
-	m.CENoInfoCount, _ = util.LoadUint64(filepath.Join(m.Path, "ce_noinfo_count"))
-	m.UECount, _ = util.LoadUint64(filepath.Join(m.Path, "ue_count"))
-	m.UENoInfoCount, _ = util.LoadUint64(filepath.Join(m.Path, "ue_noinfo_count"))
+	m.CECount, _ = util.LoadUint64WithContext(ctx, filepath.Join(m.Path, "ce_count"))
+	m.CENoInfoCount, _ = util.LoadUint64WithContext(ctx, filepath.Join(m.Path, "ce_noinfo_count"))
+	m.UECount, _ = util.LoadUint64WithContext(ctx, filepath.Join(m.Path, "ue_count"))
+	m.UENoInfoCount, _ = util.LoadUint64WithContext(ctx, filepath.Join(m.Path, "ue_noinfo_count"))
 
 	for _, rowdir := range util.FilterPrefixedDirs(m.Path, "csrow") {
 		csrow := newChipSelectRow(rowdir)
-		err := csrow.decode()
+		err := csrow.decode(ctx)
 		if err != nil {
 			return err
 		}
@@ -115,16 +124,16 @@ type ChipSelectRow struct {
 	Channels []*Channel
 }
 
-func (c *ChipSelectRow) decode() error {
+func (c *ChipSelectRow) decode(ctx context.Context) error {
 	c.Name = filepath.Base(c.Path)
 
-	sizemb, err := util.LoadUint64(filepath.Join(c.Path, "size_mb"))
+	sizemb, err := util.LoadUint64WithContext(ctx, filepath.Join(c.Path, "size_mb"))
 	if err != nil {
 		return err
 	}
 	c.Size = sizemb * 1000 * 1000
-	c.CECount, _ = util.LoadUint64(filepath.Join(c.Path, "ce_count"))
-	c.UECount, _ = util.LoadUint64(filepath.Join(c.Path, "ue_count"))
+	c.CECount, _ = util.LoadUint64WithContext(ctx, filepath.Join(c.Path, "ce_count"))
+	c.UECount, _ = util.LoadUint64WithContext(ctx, filepath.Join(c.Path, "ue_count"))
 
 	log.Debugf("%s: size=%d, ce=%d, ue=%d", c.Name, c.Size, c.CECount, c.UECount)
 
@@ -147,9 +156,9 @@ func (c *ChipSelectRow) decode() error {
 
 		for _, file := range files {
 			if strings.HasSuffix(file, "_ce_count") {
-				ch.CECount, _ = util.LoadUint64(file)
+				ch.CECount, _ = util.LoadUint64WithContext(ctx, file)
 			} else if strings.HasSuffix(file, "_label") {
-				ch.Label, _ = util.LoadString(file)
+				ch.Label, _ = util.LoadStringWithContext(ctx, file)
 			}
 		}
 
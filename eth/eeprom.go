@@ -1,6 +1,11 @@
 package eth
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/moxspec/moxspec/capture"
+)
 
 const (
 	// NOTE:
@@ -63,6 +68,10 @@ func dumpEEPROM(ndev *netdev, kind uint32, size uint32) (*eeprom, error) {
 		return nil, fmt.Errorf("can not dump eeprom")
 	}
 
-	return e, nil
+	if isValidROMSize(e.size) {
+		name := string(bytes.TrimRight(ndev.name[:], "\x00"))
+		capture.Ioctl(name, fmt.Sprintf("ethtool-eeprom-%02x", kind), e.data[:e.size])
+	}
 
+	return e, nil
 }
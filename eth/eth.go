@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
 )
 
 var log *loglet.Logger
@@ -27,6 +28,10 @@ type Device struct {
 func (d *Device) Decode() error {
 	var err error
 
+	if util.Captured() {
+		return util.ErrLiveOnly
+	}
+
 	ndev, err := newNetdev(d.Name)
 	if err != nil {
 		return err
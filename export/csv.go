@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// WriteCSV writes the table as CSV with the header
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(t.Header)
+	if err != nil {
+		return err
+	}
+	err = cw.WriteAll(t.Rows)
+	if err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteCSVDir writes tables of the report as <table>.csv under the directory
+func WriteCSVDir(dir string, r *model.Report) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range Tables(r) {
+		err = writeCSVFile(filepath.Join(dir, t.Name+".csv"), t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSVFile(path string, t *Table) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = t.WriteCSV(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
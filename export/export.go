@@ -0,0 +1,179 @@
+// Package export flattens a report into tables of components for asset databases
+//
+// Every table has one row per component. A row starts with the host key, the component key and the key of the parent
+// (e.g. the controller of a drive), followed by the fields of the matching model type named after their json tags.
+// Fields of nested structs are prefixed with the name of the struct (e.g. currentLink.speed), slices are left to other tables.
+package export
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/moxspec/moxspec/schema"
+)
+
+// These are names of tables
+const (
+	Systems      = "systems"
+	Processors   = "processors"
+	DIMMs        = "dimms"
+	Drives       = "drives"
+	NVMe         = "nvme"
+	NICs         = "nics"
+	Transceivers = "transceivers"
+	GPUs         = "gpus"
+	PSUs         = "psus"
+	PCIDevices   = "pci_devices"
+)
+
+// These are columns every table starts with
+const (
+	HostColumn   = "host"
+	KeyColumn    = "key"
+	ParentColumn = "parent"
+)
+
+// Table represents rows of a kind of components
+type Table struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+
+	columns []column
+}
+
+// column is a field reached through the indices of nested structs
+type column struct {
+	name string
+	path [][]int
+}
+
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// newTable creates a table of the type of v, columns under the omitted names are left out
+func newTable(name string, v interface{}, omit ...string) *Table {
+	t := new(Table)
+	t.Name = name
+
+	for _, c := range columns(reflect.TypeOf(v), "", nil) {
+		if omitted(c.name, omit) {
+			continue
+		}
+		t.columns = append(t.columns, c)
+	}
+
+	t.Header = []string{HostColumn, KeyColumn, ParentColumn}
+	for _, c := range t.columns {
+		t.Header = append(t.Header, c.name)
+	}
+	return t
+}
+
+func omitted(name string, omit []string) bool {
+	for _, o := range omit {
+		if name == o || strings.HasPrefix(name, o+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// add appends a row of the component, v must be of the type of the table
+func (t *Table) add(host, key, parent string, v interface{}) {
+	rv := reflect.ValueOf(v)
+	row := []string{host, key, parent}
+	for _, c := range t.columns {
+		row = append(row, c.value(rv))
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// columns returns columns of the struct as encoding/json names fields
+func columns(t reflect.Type, prefix string, path [][]int) []column {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var cols []column
+	for _, f := range schema.Fields(t) {
+		name := prefix + f.Name
+		p := append(append([][]int{}, path...), f.Index)
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		switch {
+		case scalar(ft):
+			cols = append(cols, column{name: name, path: p})
+		case ft.Kind() == reflect.Struct:
+			cols = append(cols, columns(ft, name+".", p)...)
+		}
+	}
+	return cols
+}
+
+func scalar(t reflect.Type) bool {
+	if t.Implements(textMarshaler) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// value returns the field of the struct as a string, empty if a pointer on the way is nil
+func (c column) value(v reflect.Value) string {
+	for _, idx := range c.path {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return ""
+			}
+			v = v.Elem()
+		}
+		var err error
+		// an embedded pointer may be nil on the way
+		v, err = v.FieldByIndexErr(idx)
+		if err != nil {
+			return ""
+		}
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshaler) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.String:
+		return v.String()
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func testReport() *model.Report {
+	ahci := new(model.AHCIController)
+	ahci.Location.Bus = 0
+	ahci.Location.Device = 0x17
+	ahci.Drives = []*model.Drive{{Name: "sda", Model: "MZ7LH480", SerialNumber: "S45P"}}
+
+	raid := new(model.RAIDController)
+	raid.Location.Bus = 0x3b
+	pd := new(model.PhyDrive)
+	pd.Enclosure = "32"
+	pd.Slot = "1"
+	pd.Model = "ST8000NM"
+	pd.ErrorCount = 2
+	raid.LogDrives = []*model.LogDrive{{PhyDrives: []*model.PhyDrive{pd}}}
+
+	nic := new(model.EthController)
+	nic.Location.Bus = 0x5e
+	nic.Interfaces = []*model.NetInterface{
+		{Name: "eth0", HWAddr: "00:11:22:33:44:55", Speed: 25000, Module: &model.Module{VendorName: "FS", SerialNumber: "F1"}},
+		{Name: "eth1", HWAddr: "00:11:22:33:44:56"},
+	}
+
+	nvme := new(model.NVMeController)
+	nvme.Location.Bus = 0x86
+	nvme.Model = "PM1725b"
+	nvme.CurLink = &model.PCIeLink{Speed: 8, Width: 4}
+
+	return &model.Report{
+		System:   &model.System{Manufacturer: "Dell Inc.", SerialNumber: "ABC1234"},
+		BMC:      &model.BMC{IPAddr: "192.0.2.10"},
+		Hostname: "web01",
+		Processor: &model.ProcessorReport{
+			Packages: []*model.Package{{ID: 0, Socket: "CPU0", ProductName: "Xeon"}},
+		},
+		Memory: &model.MemoryReport{
+			Modules: []*model.MemoryModule{{Locator: "A1", Speed: 2933}},
+		},
+		Storage: &model.StorageReport{
+			AHCIControllers: []*model.AHCIController{ahci},
+			RAIDControllers: []*model.RAIDController{raid},
+			NVMeControllers: []*model.NVMeController{nvme},
+		},
+		Network:     &model.NetworkReport{EthControllers: []*model.EthController{nic}},
+		PowerSupply: []*model.PowerSupply{{ProductName: "PWR SPLY", Capacity: 750}},
+	}
+}
+
+// lookup returns the value of the column in the row with the key
+func lookup(t *Table, key, col string) (string, bool) {
+	ci := -1
+	for i, h := range t.Header {
+		if h == col {
+			ci = i
+		}
+	}
+	if ci < 0 {
+		return "", false
+	}
+	for _, row := range t.Rows {
+		if row[1] == key {
+			return row[ci], true
+		}
+	}
+	return "", false
+}
+
+func TestTables(t *testing.T) {
+	tables := make(map[string]*Table)
+	for _, tbl := range Tables(testReport()) {
+		tables[tbl.Name] = tbl
+	}
+
+	tests := []struct {
+		table string
+		key   string
+		col   string
+		want  string
+	}{
+		{Systems, "ABC1234", "manufacturer", "Dell Inc."},
+		{Systems, "ABC1234", "hostname", "web01"},
+		{Systems, "ABC1234", "bmc.ipaddr", "192.0.2.10"},
+		{Systems, "ABC1234", "chassis.serialNumber", ""},
+		{Processors, "ABC1234/cpu/CPU0", "productName", "Xeon"},
+		{Processors, "ABC1234/cpu/CPU0", ParentColumn, "ABC1234"},
+		{DIMMs, "ABC1234/dimm/A1", "speed", "2933"},
+		{Drives, "ABC1234/pci/0000:00:17.0/sda", "serialNumber", "S45P"},
+		{Drives, "ABC1234/pci/0000:00:17.0/sda", ParentColumn, "ABC1234/pci/0000:00:17.0"},
+		{Drives, "ABC1234/pci/0000:3b:00.0/32:1", "errorCount", "2"},
+		{NVMe, "ABC1234/pci/0000:86:00.0", "model", "PM1725b"},
+		{NVMe, "ABC1234/pci/0000:86:00.0", "currentLink.width", "4"},
+		{NVMe, "ABC1234/pci/0000:86:00.0", "maxLink.width", ""},
+		{NICs, "ABC1234/pci/0000:5e:00.0/eth0", "hwaddr", "00:11:22:33:44:55"},
+		{NICs, "ABC1234/pci/0000:5e:00.0/eth1", ParentColumn, "ABC1234/pci/0000:5e:00.0"},
+		{Transceivers, "ABC1234/pci/0000:5e:00.0/eth0/module", "serialNumber", "F1"},
+		{Transceivers, "ABC1234/pci/0000:5e:00.0/eth0/module", ParentColumn, "ABC1234/pci/0000:5e:00.0/eth0"},
+		{PSUs, "ABC1234/psu/0", "capacity", "750"},
+		{PSUs, "ABC1234/psu/0", HostColumn, "ABC1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			got, ok := lookup(tables[tt.table], tt.key, tt.col)
+			if !ok {
+				t.Fatalf("no %s in %s", tt.key, tt.table)
+			}
+			if got != tt.want {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, h := range tables[NICs].Header {
+		if strings.HasPrefix(h, "module.") {
+			t.Errorf("transceivers should be left to their own table: %s", h)
+		}
+	}
+	if n := len(tables[GPUs].Rows); n != 0 {
+		t.Errorf("got %d gpus, want 0", n)
+	}
+}
+
+func TestEmbeddedPointer(t *testing.T) {
+	type extra struct {
+		Serial string `json:"serial"`
+	}
+	type item struct {
+		Name string `json:"name"`
+		*extra
+	}
+
+	tbl := newTable("items", item{})
+	tbl.add("h", "h/0", "h", &item{Name: "a"})
+	tbl.add("h", "h/1", "h", &item{Name: "b", extra: &extra{Serial: "S1"}})
+
+	want := [][]string{{"h", "h/0", "h", "a", ""}, {"h", "h/1", "h", "b", "S1"}}
+	if !reflect.DeepEqual(tbl.Rows, want) {
+		t.Errorf("got: %v, want: %v", tbl.Rows, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tbl := newTable(PSUs, model.PowerSupply{})
+	tbl.add("h", "h/psu/0", "h", &model.PowerSupply{ProductName: "PWR, SPLY", Capacity: 750, Present: true})
+
+	var b bytes.Buffer
+	err := tbl.WriteCSV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "host,key,parent,manufacturer,productName,serialNumber,modelPartNumber,capacity,present,plugged,hotSwappable\n" +
+		"h,h/psu/0,h,,\"PWR, SPLY\",,,750,true,false,false\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// system is a row of the systems table, it gathers host wide information
+type system struct {
+	model.System
+	Chassis   *model.Chassis   `json:"chassis"`
+	Baseboard *model.Baseboard `json:"baseboard"`
+	Firmware  *model.Firmware  `json:"firmware"`
+	BMC       *model.BMC       `json:"bmc"`
+	OS        *model.OS        `json:"os"`
+	Hostname  string           `json:"hostname"`
+	Version   string           `json:"version"`
+	Timestamp int64            `json:"timestamp"`
+}
+
+// HostKey returns the key of the host, the serial number of the system or the hostname
+func HostKey(r *model.Report) string {
+	if r.System != nil && r.System.SerialNumber != "" {
+		return r.System.SerialNumber
+	}
+	return r.Hostname
+}
+
+// Tables returns tables of the components in the report
+func Tables(r *model.Report) []*Table {
+	host := HostKey(r)
+	pciKey := func(p model.PCIBaseSpec) string {
+		return fmt.Sprintf("%s/pci/%s", host, p.PCIID())
+	}
+
+	systems := newTable(Systems, system{})
+	processors := newTable(Processors, model.Package{})
+	dimms := newTable(DIMMs, model.MemoryModule{})
+	drives := newTable(Drives, model.PhyDrive{})
+	nvme := newTable(NVMe, model.NVMeController{})
+	nics := newTable(NICs, model.NetInterface{}, "module")
+	transceivers := newTable(Transceivers, model.Module{})
+	gpus := newTable(GPUs, model.GPU{})
+	psus := newTable(PSUs, model.PowerSupply{})
+	pcis := newTable(PCIDevices, model.PCIBaseSpec{})
+
+	sys := system{
+		Chassis:   r.Chassis,
+		Baseboard: r.Baseboard,
+		Firmware:  r.Firmware,
+		BMC:       r.BMC,
+		OS:        r.OS,
+		Hostname:  r.Hostname,
+		Version:   r.Version,
+		Timestamp: r.Timestamp,
+	}
+	if r.System != nil {
+		sys.System = *r.System
+	}
+	systems.add(host, host, "", sys)
+
+	if r.Processor != nil {
+		for _, pkg := range r.Processor.Packages {
+			id := pkg.Socket
+			if id == "" {
+				id = fmt.Sprintf("%d", pkg.ID)
+			}
+			processors.add(host, fmt.Sprintf("%s/cpu/%s", host, id), host, pkg)
+		}
+	}
+
+	if r.Memory != nil {
+		for _, m := range r.Memory.Modules {
+			dimms.add(host, fmt.Sprintf("%s/dimm/%s", host, m.Locator), host, m)
+		}
+	}
+
+	if r.Storage != nil {
+		st := r.Storage
+		addDrives := func(parent string, ds []*model.Drive) {
+			for _, d := range ds {
+				drives.add(host, fmt.Sprintf("%s/%s", parent, d.Name), parent, &model.PhyDrive{Drive: *d})
+			}
+		}
+
+		for _, ctl := range st.AHCIControllers {
+			addDrives(pciKey(ctl.PCIBaseSpec), ctl.Drives)
+		}
+		for _, ctl := range st.VirtControllers {
+			addDrives(pciKey(ctl.PCIBaseSpec), ctl.Drives)
+		}
+		for _, ctl := range st.RAIDControllers {
+			parent := pciKey(ctl.PCIBaseSpec)
+			var pds []*model.PhyDrive
+			for _, ld := range ctl.LogDrives {
+				pds = append(pds, ld.PhyDrives...)
+			}
+			pds = append(pds, ctl.PassthroughDrives...)
+			pds = append(pds, ctl.UnconfDrives...)
+			for _, pd := range pds {
+				drives.add(host, fmt.Sprintf("%s/%s", parent, pd.Pos()), parent, pd)
+			}
+		}
+		for _, ctl := range st.NonStdControllers {
+			parent := pciKey(ctl.PCIBaseSpec)
+			for _, d := range ctl.Drives {
+				pd := new(model.PhyDrive)
+				pd.ID = d.ID
+				pd.Name = d.Name
+				pd.Blocks = d.Blocks
+				pd.PhyBlockSize = d.PhyBlockSize
+				pd.LogBlockSize = d.LogBlockSize
+				pd.Scheduler = d.Scheduler
+				pd.StorageSizeSpec = d.StorageSizeSpec
+				drives.add(host, fmt.Sprintf("%s/%s", parent, d.Name), parent, pd)
+			}
+		}
+
+		for _, ctl := range st.NVMeControllers {
+			nvme.add(host, pciKey(ctl.PCIBaseSpec), host, ctl)
+		}
+	}
+
+	if r.Network != nil {
+		for _, ctl := range r.Network.EthControllers {
+			parent := pciKey(ctl.PCIBaseSpec)
+			for _, intf := range ctl.Interfaces {
+				key := fmt.Sprintf("%s/%s", parent, intf.Name)
+				nics.add(host, key, parent, intf)
+				if intf.Module != nil {
+					transceivers.add(host, key+"/module", key, intf.Module)
+				}
+			}
+		}
+	}
+
+	if r.Accelerator != nil {
+		for _, g := range r.Accelerator.GPUs {
+			gpus.add(host, pciKey(g.PCIBaseSpec), host, g)
+		}
+	}
+
+	for i, ps := range r.PowerSupply {
+		psus.add(host, fmt.Sprintf("%s/psu/%d", host, i), host, ps)
+	}
+
+	for _, p := range r.PCIDevice {
+		pcis.add(host, pciKey(*p), host, p)
+	}
+
+	return []*Table{systems, processors, dimms, drives, nvme, nics, transceivers, gpus, psus, pcis}
+}
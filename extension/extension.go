@@ -0,0 +1,229 @@
+// Package extension runs plugins reporting hardware which mox does not know
+//
+// A plugin is an executable in the plugin directory, its name is the file name without the extension.
+// Plugins sharing a name (e.g. ocp.sh and ocp.py) are not run and reported as failures.
+// It writes a JSON object up to DefaultMaxOutput bytes to stdout and exits with 0:
+//
+//	{
+//	  "data": {"boards": [{"slot": 1, "fw": "2.1"}]},
+//	  "diag": [{"id": "board1", "status": "warning", "details": ["fan1 is slow"]}]
+//	}
+//
+// data is kept in the report as it is and diag is shown by lsdiag, status is healthy, warning or critical.
+// A plugin runs in its own process with a timeout, so a broken one only leaves a failure of its own.
+// Plugins and the directory must be owned by root (or the user running mox) and not writable by group or others.
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/util"
+)
+
+var log *loglet.Logger
+
+func init() {
+	log = loglet.NewLogger("extension")
+}
+
+// DefaultDir is where plugins are looked up
+const DefaultDir = "/etc/mox/plugins.d"
+
+// DefaultTimeout limits the run of a plugin
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxOutput limits the output of a plugin
+const DefaultMaxOutput = 1 << 20
+
+// Plugin represents an executable plugin
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Failure represents a plugin which did not give its output
+type Failure struct {
+	Plugin *Plugin
+	Err    error
+}
+
+// Decoder runs plugins in a directory
+type Decoder struct {
+	Dir        string
+	Timeout    time.Duration
+	MaxOutput  int
+	Extensions map[string]*model.Extension
+	Failures   []*Failure
+}
+
+// NewDecoder creates and initializes a Decoder
+func NewDecoder(dir string) *Decoder {
+	d := new(Decoder)
+	d.Dir = dir
+	d.Timeout = DefaultTimeout
+	d.MaxOutput = DefaultMaxOutput
+	d.Extensions = make(map[string]*model.Extension)
+	return d
+}
+
+// Plugins returns executables in the directory, hidden and backup files are ignored
+// plugins which someone else could have replaced are skipped, see trusted
+func Plugins(dir string) ([]*Plugin, error) {
+	di, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = trusted(di); err != nil {
+		log.Warnf("skipping plugins in %s: %s", dir, err)
+		return nil, nil
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ps []*Plugin
+	for _, fi := range fis {
+		name := fi.Name()
+		if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+			log.Debugf("skipping %s, not an executable", name)
+			continue
+		}
+		if err = trusted(fi); err != nil {
+			log.Warnf("skipping %s: %s", filepath.Join(dir, name), err)
+			continue
+		}
+		ps = append(ps, &Plugin{
+			Name: strings.TrimSuffix(name, filepath.Ext(name)),
+			Path: filepath.Join(dir, name),
+		})
+	}
+
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	return ps, nil
+}
+
+// trusted returns an error if the file is owned by someone other than root and mox itself, or writable by group or others
+// otherwise a plugin run by root could be replaced by anyone who can write it
+func trusted(fi os.FileInfo) error {
+	if fi.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("writable by group or others (%s)", fi.Mode().Perm())
+	}
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unknown owner")
+	}
+	if st.Uid != 0 && int(st.Uid) != os.Geteuid() {
+		return fmt.Errorf("owned by uid %d", st.Uid)
+	}
+	return nil
+}
+
+// DecodeContext runs plugins at once, a missing directory means no plugins
+func (d *Decoder) DecodeContext(ctx context.Context) error {
+	ps, err := Plugins(d.Dir)
+	if os.IsNotExist(err) {
+		log.Debugf("%s does not exist", d.Dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// plugins sharing a name would overwrite the extension of each other
+	paths := make(map[string][]string)
+	for _, p := range ps {
+		paths[p.Name] = append(paths[p.Name], filepath.Base(p.Path))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, p := range ps {
+		if names := paths[p.Name]; len(names) > 1 {
+			d.Failures = append(d.Failures, &Failure{Plugin: p, Err: fmt.Errorf("name %s is shared by %s", p.Name, strings.Join(names, ", "))})
+			continue
+		}
+
+		wg.Add(1)
+		go func(p *Plugin) {
+			defer wg.Done()
+
+			ext, err := d.run(ctx, p)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Debugf("%s: %s", p.Name, err)
+				d.Failures = append(d.Failures, &Failure{Plugin: p, Err: err})
+				return
+			}
+			d.Extensions[p.Name] = ext
+		}(p)
+	}
+	wg.Wait()
+
+	sort.Slice(d.Failures, func(i, j int) bool {
+		a, b := d.Failures[i].Plugin, d.Failures[j].Plugin
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Path < b.Path
+	})
+	return nil
+}
+
+func (d *Decoder) run(ctx context.Context, p *Plugin) (*model.Extension, error) {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+
+	res, err := util.RunLimited(ctx, d.MaxOutput, p.Path)
+	if err == util.ErrOutputLimit {
+		return nil, fmt.Errorf("output exceeds %d bytes", d.MaxOutput)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %s", p.Path, ctx.Err())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse([]byte(res.Stdout))
+}
+
+// Parse parses the output of a plugin
+func Parse(b []byte) (*model.Extension, error) {
+	ext := new(model.Extension)
+	err := json.Unmarshal(b, ext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output: %s", err)
+	}
+
+	for _, dg := range ext.Diag {
+		switch dg.Status {
+		case model.ExtensionHealthy, model.ExtensionWarning, model.ExtensionCritical:
+		default:
+			return nil, fmt.Errorf("%s: unknown status: %q (available: %s, %s, %s)",
+				dg.ID, dg.Status, model.ExtensionHealthy, model.ExtensionWarning, model.ExtensionCritical)
+		}
+	}
+	return ext, nil
+}
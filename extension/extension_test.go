@@ -0,0 +1,170 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "extension")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) {
+	t.Helper()
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writePlugin(t, dir, "ocp.sh", "", 0755)
+	writePlugin(t, dir, "fpga", "", 0755)
+	writePlugin(t, dir, "ocp.sh~", "", 0755)
+	writePlugin(t, dir, ".hidden", "", 0755)
+	writePlugin(t, dir, "README", "", 0644)
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	writePlugin(t, dir, "writable", "", 0755)
+	os.Chmod(filepath.Join(dir, "writable"), 0775)
+	if os.Geteuid() == 0 {
+		writePlugin(t, dir, "foreign", "", 0755)
+		os.Chown(filepath.Join(dir, "foreign"), 1, 1)
+	}
+
+	ps, err := Plugins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ex := []*Plugin{
+		{Name: "fpga", Path: filepath.Join(dir, "fpga")},
+		{Name: "ocp", Path: filepath.Join(dir, "ocp.sh")},
+	}
+	if !reflect.DeepEqual(ps, ex) {
+		t.Errorf("got: %+v, expected: %+v", ps, ex)
+	}
+
+	// anyone could put a plugin in the directory
+	os.Chmod(dir, 0777)
+	ps, err = Plugins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 0 {
+		t.Errorf("plugins in a world-writable directory should be skipped, got: %+v", ps)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in  string
+		ex  *model.Extension
+		err bool
+	}{
+		{`{}`, &model.Extension{}, false},
+		{`{"data": {"fw": "2.1"}}`, &model.Extension{Data: []byte(`{"fw": "2.1"}`)}, false},
+		{
+			`{"diag": [{"id": "board1", "status": "warning", "details": ["fan1 is slow"]}]}`,
+			&model.Extension{Diag: []*model.ExtensionDiag{{ID: "board1", Status: "warning", Details: []string{"fan1 is slow"}}}},
+			false,
+		},
+		{`{"diag": [{"id": "board1", "status": "bad"}]}`, nil, true},
+		{`not json`, nil, true},
+		{``, nil, true},
+	}
+
+	for _, test := range tests {
+		tt := test
+
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			ext, err := Parse([]byte(tt.in))
+			if (err != nil) != tt.err {
+				t.Fatalf("got: %v, expected error: %t", err, tt.err)
+			}
+			if !reflect.DeepEqual(ext, tt.ex) {
+				t.Errorf("got: %+v, expected: %+v", ext, tt.ex)
+			}
+		})
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writePlugin(t, dir, "good", `echo '{"data": {"boards": 2}, "diag": [{"id": "board1", "status": "healthy"}]}'`, 0755)
+	writePlugin(t, dir, "broken", "echo '{'", 0755)
+	writePlugin(t, dir, "failing", "echo '{}'; exit 1", 0755)
+	writePlugin(t, dir, "slow", "sleep 5", 0755)
+	// children holding stdout must not keep mox waiting
+	writePlugin(t, dir, "forking", `sleep 30 & echo '{"data": {}}'`, 0755)
+	writePlugin(t, dir, "hanging", "sleep 30 & sleep 30", 0755)
+	// the same name would overwrite the extension of each other
+	writePlugin(t, dir, "twin.sh", `echo '{"data": 1}'`, 0755)
+	writePlugin(t, dir, "twin.py", `echo '{"data": 2}'`, 0755)
+	writePlugin(t, dir, "flooding", `printf '{"data": "'; yes x | head -c 2048 | tr -d "\\n"; echo '"}'`, 0755)
+	writePlugin(t, dir, "endless", "yes", 0755)
+
+	start := time.Now()
+	d := NewDecoder(dir)
+	d.Timeout = 200 * time.Millisecond
+	d.MaxOutput = 1024
+	err := d.DecodeContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("plugins should be given up after the timeout, took %s", time.Since(start))
+	}
+
+	if len(d.Extensions) != 2 || d.Extensions["good"] == nil || d.Extensions["forking"] == nil {
+		t.Fatalf("unexpected extensions: %+v", d.Extensions)
+	}
+	if string(d.Extensions["good"].Data) != `{"boards": 2}` {
+		t.Errorf("unexpected data: %s", d.Extensions["good"].Data)
+	}
+
+	var failed []string
+	for _, f := range d.Failures {
+		failed = append(failed, filepath.Base(f.Plugin.Path))
+		if strings.HasPrefix(f.Plugin.Name, "twin") && !strings.Contains(f.Err.Error(), "twin.py, twin.sh") {
+			t.Errorf("unexpected error: %s", f.Err)
+		}
+		if (f.Plugin.Name == "flooding" || f.Plugin.Name == "endless") && !strings.Contains(f.Err.Error(), "exceeds 1024 bytes") {
+			t.Errorf("unexpected error: %s", f.Err)
+		}
+	}
+	if strings.Join(failed, ",") != "broken,endless,failing,flooding,hanging,slow,twin.py,twin.sh" {
+		t.Errorf("unexpected failures: %v", failed)
+	}
+}
+
+func TestDecodeContextNoDir(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	d := NewDecoder(filepath.Join(dir, "none"))
+	err := d.DecodeContext(context.Background())
+	if err != nil {
+		t.Errorf("got: %s, expected no error", err)
+	}
+	if len(d.Extensions) != 0 || len(d.Failures) != 0 {
+		t.Errorf("unexpected results: %+v, %+v", d.Extensions, d.Failures)
+	}
+}
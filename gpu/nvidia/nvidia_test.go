@@ -39,3 +39,32 @@ func TestDecodeLog(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeRetiredPages(t *testing.T) {
+	in := `<nvidia_smi_log>
+	<gpu id="00000000:3B:00.0">
+		<retired_pages>
+			<multiple_single_bit_retirement>
+				<retired_count>2</retired_count>
+			</multiple_single_bit_retirement>
+			<double_bit_retirement>
+				<retired_count>1</retired_count>
+			</double_bit_retirement>
+			<pending_retirement>Yes</pending_retirement>
+		</retired_pages>
+	</gpu>
+</nvidia_smi_log>`
+
+	l, err := decodeLog(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.GPUs) != 1 {
+		t.Fatalf("num_gpu got:%d ex:1", len(l.GPUs))
+	}
+
+	rp := l.GPUs[0].RetiredPages
+	if rp.SingleBit != 2 || rp.DoubleBit != 1 || !rp.Pending {
+		t.Errorf("got: %+v, expect: {SingleBit:2 DoubleBit:1 Pending:true}", rp)
+	}
+}
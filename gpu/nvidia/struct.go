@@ -1,12 +1,17 @@
 package nvidia
 
 import (
+	"context"
 	"encoding/xml"
+	"strings"
+	"time"
 
 	"github.com/moxspec/moxspec/pci"
 	"github.com/moxspec/moxspec/util"
 )
 
+const smiDefaultTimeout = 30 * time.Second
+
 // Devices represents GPUs
 type Devices struct {
 	dict map[string]*GPU
@@ -14,8 +19,20 @@ type Devices struct {
 
 // Decode makes Device satisfy the mox.Decoder interface
 func (d *Devices) Decode() error {
+	ctx, cancel := context.WithTimeout(context.Background(), smiDefaultTimeout)
+	defer cancel()
+
+	return d.DecodeContext(ctx)
+}
+
+// DecodeContext makes Device satisfy the mox.ContextDecoder interface
+func (d *Devices) DecodeContext(ctx context.Context) error {
+	if !util.CanExec() {
+		return util.ErrLiveOnly
+	}
+
 	log.Debug("executing nvidia-smi")
-	res, err := util.Exec("nvidia-smi", "-q", "-x")
+	res, err := util.ExecWithContext(ctx, util.Path(util.NvidiaSMIPath, "nvidia-smi"), "-q", "-x")
 	if err != nil {
 		return err
 	}
@@ -64,6 +81,7 @@ type GPU struct {
 	eccErrorsSpec
 	tempSpec
 	powerSpec
+	retiredPagesSpec
 }
 
 // UnmarshalXML imprements xml.Unmarshaler interface
@@ -74,6 +92,7 @@ func (g *GPU) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		eccErrorsSpecRaw
 		tempSpecRaw
 		powerSpecRaw
+		retiredPagesSpecRaw
 	}{}
 
 	err := d.DecodeElement(&raw, &start)
@@ -87,6 +106,7 @@ func (g *GPU) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	g.eccErrorsSpec = raw.eccErrorsSpecRaw.convert()
 	g.tempSpec = raw.tempSpecRaw.convert()
 	g.powerSpec = raw.powerSpecRaw.convert()
+	g.retiredPagesSpec = raw.retiredPagesSpecRaw.convert()
 	return nil
 }
 
@@ -229,3 +249,27 @@ func (p powerSpecRaw) convert() powerSpec {
 	c.Power.Limit = convWattString(p.Power.Limit)
 	return c
 }
+
+type retiredPagesSpec struct {
+	RetiredPages struct {
+		SingleBit int
+		DoubleBit int
+		Pending   bool
+	}
+}
+
+type retiredPagesSpecRaw struct {
+	RetiredPages struct {
+		SingleBit string `xml:"multiple_single_bit_retirement>retired_count"`
+		DoubleBit string `xml:"double_bit_retirement>retired_count"`
+		Pending   string `xml:"pending_retirement"`
+	} `xml:"retired_pages"`
+}
+
+func (r retiredPagesSpecRaw) convert() retiredPagesSpec {
+	c := retiredPagesSpec{}
+	c.RetiredPages.SingleBit = convCountString(r.RetiredPages.SingleBit)
+	c.RetiredPages.DoubleBit = convCountString(r.RetiredPages.DoubleBit)
+	c.RetiredPages.Pending = (strings.TrimSpace(r.RetiredPages.Pending) == "Yes")
+	return c
+}
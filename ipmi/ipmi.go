@@ -1,11 +1,12 @@
 package ipmi
 
 import (
+	"context"
 	"fmt"
 	"net"
-	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/moxspec/moxspec/loglet"
 	"github.com/moxspec/moxspec/platform"
@@ -30,6 +31,8 @@ type Device struct {
 	VLANID        uint16
 }
 
+const ipmiDefaultTimeout = 60 * time.Second
+
 type addrSrcType string
 
 const (
@@ -43,38 +46,59 @@ const (
 // NewDecoder creates and initializes a Device as Decoder
 func NewDecoder() *Device {
 	d := new(Device)
-	d.Path = "/dev/ipmi0"
+	d.Path = util.Path(util.IPMIDevPath, "/dev/ipmi0")
 	return d
 }
 
+// ipmitool returns the command, which may be overridden by util.SetPaths
+func ipmitool() string {
+	return util.Path(util.IPMIToolPath, "ipmitool")
+}
+
 // Decode makes Device satisfy the mox.Decoder interface
 func (d *Device) Decode() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ipmiDefaultTimeout)
+	defer cancel()
+
+	return d.DecodeContext(ctx)
+}
+
+// DecodeContext makes Device satisfy the mox.ContextDecoder interface
+func (d *Device) DecodeContext(ctx context.Context) error {
+	if !util.CanExec() {
+		return util.ErrLiveOnly
+	}
+	if !util.Privileged() {
+		// ipmitool talks to the device only root can open
+		return util.ErrPrivileged
+	}
+
 	if !platform.IsLoadedModule("ipmi_devintf") {
 		return fmt.Errorf("kernel module for ipmi is not loaded")
 	}
 
-	if !util.Exists(d.Path) {
+	if !util.Exists(util.RootPath(d.Path)) {
 		return fmt.Errorf("ipmi device was not found")
 	}
 
-	_, err := exec.LookPath("ipmitool")
+	_, err := util.LookPath(ipmitool())
 	if err != nil {
 		return fmt.Errorf("ipmitool is not installed")
 	}
 
-	d.Firmware = getFirmwareRev()
-	d.MAC = getMACAddress()
-	d.IPAddr = getIPAddress()
-	d.Netmask = getSubnetMask()
-	d.Gateway = getDefaultGateway()
-	d.VLANID = getVLANID()
-	d.AddressSource = getAddressSource()
+	d.Firmware = getFirmwareRev(ctx)
+	d.MAC = getMACAddress(ctx)
+	d.IPAddr = getIPAddress(ctx)
+	d.Netmask = getSubnetMask(ctx)
+	d.Gateway = getDefaultGateway(ctx)
+	d.VLANID = getVLANID(ctx)
+	d.AddressSource = getAddressSource(ctx)
 
-	return nil
+	return ctx.Err()
 }
 
-func getFirmwareRev() string {
-	res, err := util.Exec("ipmitool", "raw", "0x06", "0x01")
+func getFirmwareRev(ctx context.Context) string {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x06", "0x01")
 	if err != nil {
 		return ""
 	}
@@ -92,8 +116,8 @@ func parseMcInfoRaw(in string) string {
 	return fmt.Sprintf("%d.%s", maj, min)
 }
 
-func getMACAddress() string {
-	res, err := util.Exec("ipmitool", "raw", "0x0c", "0x02", "0x01", "0x05", "  0x00", "0x00")
+func getMACAddress(ctx context.Context) string {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x0c", "0x02", "0x01", "0x05", "  0x00", "0x00")
 	if err != nil {
 		return ""
 	}
@@ -108,24 +132,24 @@ func parseMACAddressRaw(in string) string {
 	return strings.Join(codes[1:], ":")
 }
 
-func getIPAddress() string {
-	res, err := util.Exec("ipmitool", "raw", "0x0c", "0x02", "0x01", "0x03", "  0x00", "0x00")
+func getIPAddress(ctx context.Context) string {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x0c", "0x02", "0x01", "0x03", "  0x00", "0x00")
 	if err != nil {
 		return ""
 	}
 	return parseIPAddressRaw(res)
 }
 
-func getSubnetMask() string {
-	res, err := util.Exec("ipmitool", "raw", "0x0c", "0x02", "0x01", "0x06", "  0x00", "0x00")
+func getSubnetMask(ctx context.Context) string {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x0c", "0x02", "0x01", "0x06", "  0x00", "0x00")
 	if err != nil {
 		return ""
 	}
 	return parseIPAddressRaw(res)
 }
 
-func getDefaultGateway() string {
-	res, err := util.Exec("ipmitool", "raw", "0x0c", "0x02", "0x01", "0x0c", "  0x00", "0x00")
+func getDefaultGateway(ctx context.Context) string {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x0c", "0x02", "0x01", "0x0c", "  0x00", "0x00")
 	if err != nil {
 		return ""
 	}
@@ -145,8 +169,8 @@ func parseIPAddressRaw(in string) string {
 	return net.IPv4(byte(a), byte(b), byte(c), byte(d)).String()
 }
 
-func getVLANID() uint16 {
-	res, err := util.Exec("ipmitool", "raw", "0x0c", "0x02", "0x01", "0x14", "  0x00", "0x00")
+func getVLANID(ctx context.Context) uint16 {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x0c", "0x02", "0x01", "0x14", "  0x00", "0x00")
 	if err != nil {
 		return 0
 	}
@@ -168,8 +192,8 @@ func parseVLANID(in string) uint16 {
 	return ((uint16(d2)&0x0F)<<8 | uint16(d1))
 }
 
-func getAddressSource() addrSrcType {
-	res, err := util.Exec("ipmitool", "raw", "0x0c", "0x02", "0x01", "0x04", "  0x00", "0x00")
+func getAddressSource(ctx context.Context) addrSrcType {
+	res, err := util.ExecWithContext(ctx, ipmitool(), "raw", "0x0c", "0x02", "0x01", "0x04", "  0x00", "0x00")
 	if err != nil {
 		return "uns[ecified"
 	}
@@ -201,7 +225,7 @@ func parseAddressSource(in string) addrSrcType {
 // GetSEL returns system event list
 func GetSEL() []string {
 	log.Debug("running ipmitool sel list")
-	res, err := util.Exec("ipmitool", "sel", "list")
+	res, err := util.Exec(ipmitool(), "sel", "list")
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,145 @@
+// Package metrics writes health and wear counters in a report as OpenMetrics text
+// or as the Prometheus text format (0.0.4) read by the textfile collector of node_exporter
+//
+// The two differ in counters: OpenMetrics names the family without the _total suffix of its samples,
+// while the Prometheus text format names it the same as the samples, and has neither UNIT nor EOF.
+// Labels are taken from the report (e.g. serial numbers, PCI IDs, slots and models).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// These are formats of the output
+const (
+	OpenMetrics = "openmetrics"
+	Prometheus  = "prometheus"
+)
+
+// Formats returns the formats of the output
+func Formats() []string {
+	return []string{OpenMetrics, Prometheus}
+}
+
+// These are media types of the formats
+const (
+	ContentType           = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// These are types of metric families
+const (
+	gaugeType   = "gauge"
+	counterType = "counter"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+type family struct {
+	name    string
+	typ     string
+	unit    string
+	help    string
+	samples []sample
+}
+
+// add appends a sample, labels are given as pairs of a name and a value
+func (f *family) add(v float64, kvs ...string) {
+	var ls []label
+	for i := 0; i+1 < len(kvs); i += 2 {
+		ls = append(ls, label{name: kvs[i], value: kvs[i+1]})
+	}
+	f.samples = append(f.samples, sample{labels: ls, value: v})
+}
+
+// set keeps metric families in the order they are defined
+type set struct {
+	families []*family
+}
+
+func (s *set) define(typ, name, unit, help string) *family {
+	f := &family{name: name, typ: typ, unit: unit, help: help}
+	s.families = append(s.families, f)
+	return f
+}
+
+func (s *set) gauge(name, unit, help string) *family {
+	return s.define(gaugeType, name, unit, help)
+}
+
+func (s *set) counter(name, unit, help string) *family {
+	return s.define(counterType, name, unit, help)
+}
+
+func (s set) write(w io.Writer, format string) error {
+	bw := bufio.NewWriter(w)
+
+	for _, f := range s.families {
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		name := f.name
+		if f.typ == counterType {
+			name += "_total"
+		}
+
+		switch format {
+		case Prometheus:
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, f.help)
+			fmt.Fprintf(bw, "# TYPE %s %s\n", name, f.typ)
+		default:
+			fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+			if f.unit != "" {
+				fmt.Fprintf(bw, "# UNIT %s %s\n", f.name, f.unit)
+			}
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.name, f.help)
+		}
+
+		for _, s := range f.samples {
+			bw.WriteString(name)
+			if len(s.labels) > 0 {
+				var ls []string
+				for _, l := range s.labels {
+					ls = append(ls, fmt.Sprintf(`%s="%s"`, l.name, escape(l.value)))
+				}
+				fmt.Fprintf(bw, "{%s}", strings.Join(ls, ","))
+			}
+			fmt.Fprintf(bw, " %s\n", formatValue(s.value))
+		}
+	}
+	if format != Prometheus {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestSetWrite(t *testing.T) {
+	s := new(set)
+	g := s.gauge("mox_test_temperature_celsius", "celsius", "A test gauge.")
+	g.add(36.5, "serial", `S1"\`+"\n")
+	s.counter("mox_test_empty", "", "Never written since it has no samples.")
+	c := s.counter("mox_test_errors", "", "A test counter.")
+	c.add(3)
+
+	tests := []struct {
+		format string
+		expect string
+	}{
+		{OpenMetrics, `# TYPE mox_test_temperature_celsius gauge
+# UNIT mox_test_temperature_celsius celsius
+# HELP mox_test_temperature_celsius A test gauge.
+mox_test_temperature_celsius{serial="S1\"\\\n"} 36.5
+# TYPE mox_test_errors counter
+# HELP mox_test_errors A test counter.
+mox_test_errors_total 3
+# EOF
+`},
+		{Prometheus, `# HELP mox_test_temperature_celsius A test gauge.
+# TYPE mox_test_temperature_celsius gauge
+mox_test_temperature_celsius{serial="S1\"\\\n"} 36.5
+# HELP mox_test_errors_total A test counter.
+# TYPE mox_test_errors_total counter
+mox_test_errors_total 3
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var b bytes.Buffer
+			err := s.write(&b, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if b.String() != tt.expect {
+				t.Errorf("got:\n%s\nexpect:\n%s", b.String(), tt.expect)
+			}
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		in     float64
+		expect string
+	}{
+		{0, "0"},
+		{1.5, "1.5"},
+		{1e12, "1000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			got := formatValue(tt.in)
+			if got != tt.expect {
+				t.Errorf("got: %s, expect: %s", got, tt.expect)
+			}
+		})
+	}
+}
+
+func testReport() *model.Report {
+	used := byte(3)
+	spare := byte(100)
+
+	nvme := new(model.NVMeController)
+	nvme.Name = "nvme0"
+	nvme.Model = "PM1725b"
+	nvme.SerialNumber = "S3"
+	nvme.CurTemp = 40
+	nvme.ByteWritten = 1000
+	nvme.Used = &used
+	nvme.SpareSpace = &spare
+
+	sata := new(model.Drive)
+	sata.Name = "sda"
+	sata.Model = "HDD"
+	sata.SerialNumber = "S4"
+
+	ahci := new(model.AHCIController)
+	ahci.Drives = []*model.Drive{sata}
+
+	gpu := new(model.GPU)
+	gpu.ProductName = "A100"
+	gpu.UECount.Total = 2
+	gpu.Power.Current = 55.5
+
+	nic := new(model.PCIBaseSpec)
+	nic.VendorID = 0x8086
+	nic.DeviceID = 0x1572
+	nic.CurLink = &model.PCIeLink{Speed: 8, Width: 4}
+	nic.MaxLink = &model.PCIeLink{Speed: 8, Width: 8}
+
+	r := &model.Report{
+		Timestamp: 100,
+		Memory: &model.MemoryReport{
+			Controllers: []*model.MemoryController{
+				{Name: "mc0", CSRows: []*model.ChipSelectRow{{Name: "csrow0", CECount: 5}}},
+			},
+		},
+		Storage: &model.StorageReport{
+			NVMeControllers: []*model.NVMeController{nvme},
+			AHCIControllers: []*model.AHCIController{ahci},
+		},
+		Accelerator: &model.AcceleratorReport{GPUs: []*model.GPU{gpu}},
+		PCIDevice:   []*model.PCIBaseSpec{nic},
+		PowerSupply: []*model.PowerSupply{{SerialNumber: "P1", Present: true}},
+	}
+	return r
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	err := Write(&b, testReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	expects := []string{
+		`mox_report_timestamp_seconds{version="",hostname=""} 100`,
+		`mox_memory_corrected_errors_total{controller="mc0",csrow="csrow0"} 5`,
+		`mox_memory_uncorrected_errors_total{controller="mc0",csrow="csrow0"} 0`,
+		`mox_storage_used_percent{type="nvme",device="nvme0",slot="0000:00:00.0",model="PM1725b",serial="S3"} 3`,
+		`mox_storage_spare_space_percent{type="nvme",device="nvme0",slot="0000:00:00.0",model="PM1725b",serial="S3"} 100`,
+		`mox_storage_written_bytes_total{type="nvme",device="nvme0",slot="0000:00:00.0",model="PM1725b",serial="S3"} 1000`,
+		`mox_storage_temperature_celsius{type="nvme",device="nvme0",slot="0000:00:00.0",model="PM1725b",serial="S3"} 40`,
+		`mox_pcie_link_width_lanes{slot="0000:00:00.0",vendor_id="8086",device_id="1572",`,
+		`mox_gpu_uncorrected_ecc_errors_total{slot="0000:00:00.0",model="A100",serial=""} 2`,
+		`mox_gpu_power_watts{slot="0000:00:00.0",model="A100",serial=""} 55.5`,
+		`mox_psu_present{psu="0",model="",serial="P1"} 1`,
+		`mox_psu_plugged{psu="0",model="",serial="P1"} 0`,
+	}
+	for _, e := range expects {
+		if !strings.Contains(out, e) {
+			t.Errorf("%s is not in:\n%s", e, out)
+		}
+	}
+
+	// a drive not reporting wear must not be told as unused
+	unexpects := []string{
+		`serial="S4"`,
+		"mox_cpu_",
+		"mox_network_",
+		"mox_psu_capacity_watts",
+	}
+	for _, u := range unexpects {
+		if strings.Contains(out, u) {
+			t.Errorf("%s should not be in:\n%s", u, out)
+		}
+	}
+
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Errorf("the output should end with # EOF")
+	}
+}
+
+// TestWritePrometheus checks the rules of the Prometheus text format which the textfile collector parses with
+// a sample belongs to the family of the last TYPE only if it has the same name, and a family is typed once
+func TestWritePrometheus(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteFormat(&b, testReport(), Prometheus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typed := make(map[string]bool)
+	var family string
+	samples := 0
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			flds := strings.Fields(line)
+			if len(flds) < 3 || (flds[1] != "HELP" && flds[1] != "TYPE") {
+				t.Errorf("unexpected comment: %s", line)
+				continue
+			}
+			if flds[1] == "TYPE" {
+				if typed[flds[2]] {
+					t.Errorf("%s is typed twice", flds[2])
+				}
+				typed[flds[2]] = true
+				family = flds[2]
+			}
+			continue
+		}
+
+		name := strings.FieldsFunc(line, func(r rune) bool { return r == '{' || r == ' ' })[0]
+		if name != family {
+			t.Errorf("%s is not in the family typed last: %s", name, family)
+		}
+		samples++
+	}
+
+	if samples == 0 {
+		t.Errorf("no samples in:\n%s", b.String())
+	}
+	if !typed["mox_memory_corrected_errors_total"] {
+		t.Errorf("a counter should be typed with the name of its samples:\n%s", b.String())
+	}
+
+	err = WriteFormat(&b, testReport(), "json")
+	if err == nil {
+		t.Errorf("an unknown format should be an error")
+	}
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// Write writes the metrics in the report to w as OpenMetrics text
+func Write(w io.Writer, r *model.Report) error {
+	return WriteFormat(w, r, OpenMetrics)
+}
+
+// WriteFormat writes the metrics in the report to w in the format
+func WriteFormat(w io.Writer, r *model.Report, format string) error {
+	switch format {
+	case OpenMetrics, Prometheus:
+	default:
+		return fmt.Errorf("unknown format: %s (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+
+	s := new(set)
+
+	ts := s.gauge("mox_report_timestamp_seconds", "seconds", "Time the report was collected at.")
+	if r.Timestamp > 0 {
+		ts.add(float64(r.Timestamp), "version", r.Version, "hostname", r.Hostname)
+	}
+
+	writeProcessor(s, r)
+	writeMemory(s, r)
+	writeStorage(s, r)
+	writePCIe(s, r)
+	writeNetwork(s, r)
+	writeAccelerator(s, r)
+	writePowerSupply(s, r)
+
+	return s.write(w, format)
+}
+
+func writeProcessor(s *set, r *model.Report) {
+	pkgThrottles := s.counter("mox_cpu_package_throttles", "", "Number of thermal throttling events of a processor package.")
+	coreThrottles := s.counter("mox_cpu_core_throttles", "", "Number of thermal throttling events of a core.")
+	coreTemp := s.gauge("mox_cpu_core_temperature_celsius", "celsius", "Current temperature of a core.")
+
+	if r.Processor == nil {
+		return
+	}
+
+	for _, p := range r.Processor.Packages {
+		pkg := fmt.Sprintf("%d", p.ID)
+		pkgThrottles.add(float64(p.ThrottleCount), "package", pkg, "socket", p.Socket, "model", p.ProductName)
+
+		for _, n := range p.Nodes {
+			node := fmt.Sprintf("%d", n.ID)
+			for _, c := range n.Cores {
+				core := fmt.Sprintf("%d", c.ID)
+				coreThrottles.add(float64(c.ThrottleCount), "package", pkg, "node", node, "core", core)
+				if c.Temp != 0 {
+					coreTemp.add(float64(c.Temp), "package", pkg, "node", node, "core", core)
+				}
+			}
+		}
+	}
+}
+
+func writeMemory(s *set, r *model.Report) {
+	ce := s.counter("mox_memory_corrected_errors", "", "Number of corrected errors of a chip-select row reported by EDAC.")
+	ue := s.counter("mox_memory_uncorrected_errors", "", "Number of uncorrected errors of a chip-select row reported by EDAC.")
+
+	if r.Memory == nil {
+		return
+	}
+
+	for _, ctl := range r.Memory.Controllers {
+		for _, cs := range ctl.CSRows {
+			ce.add(float64(cs.CECount), "controller", ctl.Name, "csrow", cs.Name)
+			ue.add(float64(cs.UECount), "controller", ctl.Name, "csrow", cs.Name)
+		}
+	}
+}
+
+// drive gathers values common to every kind of drive
+type drive struct {
+	labels []string
+	temp   int16
+	read   uint64
+	write  uint64
+	wear   model.StorageWearSpec
+}
+
+func writeStorage(s *set, r *model.Report) {
+	used := s.gauge("mox_storage_used_percent", "percent", "Percentage of the endurance of a drive used, may exceed 100.")
+	spare := s.gauge("mox_storage_spare_space_percent", "percent", "Percentage of the spare space of a drive remaining.")
+	written := s.counter("mox_storage_written_bytes", "bytes", "Number of bytes written to a drive.")
+	read := s.counter("mox_storage_read_bytes", "bytes", "Number of bytes read from a drive.")
+	temp := s.gauge("mox_storage_temperature_celsius", "celsius", "Current temperature of a drive.")
+
+	if r.Storage == nil {
+		return
+	}
+
+	var drives []drive
+	for _, n := range r.Storage.NVMeControllers {
+		drives = append(drives, drive{
+			labels: []string{"type", "nvme", "device", n.Name, "slot", n.PCIID(), "model", n.Model, "serial", n.SerialNumber},
+			temp:   n.CurTemp,
+			read:   n.ByteRead,
+			write:  n.ByteWritten,
+			wear:   n.StorageWearSpec,
+		})
+	}
+	for _, ctl := range r.Storage.AHCIControllers {
+		for _, d := range ctl.Drives {
+			drives = append(drives, drive{
+				labels: []string{"type", "sata", "device", d.Name, "slot", ctl.PCIID(), "model", d.Model, "serial", d.SerialNumber},
+				temp:   d.CurTemp,
+				read:   d.ByteRead,
+				write:  d.ByteWritten,
+				wear:   d.StorageWearSpec,
+			})
+		}
+	}
+	for _, ctl := range r.Storage.RAIDControllers {
+		var pds []*model.PhyDrive
+		for _, ld := range ctl.LogDrives {
+			pds = append(pds, ld.PhyDrives...)
+		}
+		pds = append(pds, ctl.UnconfDrives...)
+		pds = append(pds, ctl.PassthroughDrives...)
+
+		for _, pd := range pds {
+			drives = append(drives, drive{
+				labels: []string{"type", "raid", "device", pd.Pos(), "slot", ctl.PCIID(), "model", pd.Model, "serial", pd.SerialNumber},
+				temp:   pd.CurTemp,
+				read:   pd.ByteRead,
+				write:  pd.ByteWritten,
+				wear:   pd.StorageWearSpec,
+			})
+		}
+	}
+
+	for _, d := range drives {
+		if d.wear.Used != nil {
+			used.add(float64(*d.wear.Used), d.labels...)
+		}
+		if d.wear.SpareSpace != nil {
+			spare.add(float64(*d.wear.SpareSpace), d.labels...)
+		}
+		if d.write > 0 {
+			written.add(float64(d.write), d.labels...)
+		}
+		if d.read > 0 {
+			read.add(float64(d.read), d.labels...)
+		}
+		if d.temp != 0 {
+			temp.add(float64(d.temp), d.labels...)
+		}
+	}
+}
+
+func writePCIe(s *set, r *model.Report) {
+	speed := s.gauge("mox_pcie_link_speed_gigatransfers", "gigatransfers", "Current link speed of a PCIe device in GT/s.")
+	maxSpeed := s.gauge("mox_pcie_link_max_speed_gigatransfers", "gigatransfers", "Maximum link speed of a PCIe device in GT/s.")
+	width := s.gauge("mox_pcie_link_width_lanes", "lanes", "Current link width of a PCIe device.")
+	maxWidth := s.gauge("mox_pcie_link_max_width_lanes", "lanes", "Maximum link width of a PCIe device.")
+
+	for _, p := range r.PCIDevice {
+		if !p.HasLinkStatus() {
+			continue
+		}
+
+		labels := []string{
+			"slot", p.PCIID(),
+			"vendor_id", fmt.Sprintf("%04x", p.VendorID),
+			"device_id", fmt.Sprintf("%04x", p.DeviceID),
+			"name", p.LongName(),
+			"serial", p.SerialNumber,
+		}
+		speed.add(float64(p.CurLink.Speed), labels...)
+		maxSpeed.add(float64(p.MaxLink.Speed), labels...)
+		width.add(float64(p.CurLink.Width), labels...)
+		maxWidth.add(float64(p.MaxLink.Width), labels...)
+	}
+}
+
+func writeNetwork(s *set, r *model.Report) {
+	rxErrors := s.counter("mox_network_receive_errors", "", "Number of receive errors of an interface.")
+	txErrors := s.counter("mox_network_transmit_errors", "", "Number of transmit errors of an interface.")
+	rxDropped := s.counter("mox_network_receive_dropped", "", "Number of dropped received packets of an interface.")
+	txDropped := s.counter("mox_network_transmit_dropped", "", "Number of dropped transmitted packets of an interface.")
+
+	if r.Network == nil {
+		return
+	}
+
+	for _, ctl := range r.Network.EthControllers {
+		for _, n := range ctl.Interfaces {
+			labels := []string{"interface", n.Name, "hwaddr", n.HWAddr, "slot", ctl.PCIID(), "name", ctl.LongName()}
+			rxErrors.add(float64(n.RxErrors), labels...)
+			txErrors.add(float64(n.TxErrors), labels...)
+			rxDropped.add(float64(n.RxDropped), labels...)
+			txDropped.add(float64(n.TxDropped), labels...)
+		}
+	}
+}
+
+func writeAccelerator(s *set, r *model.Report) {
+	ce := s.counter("mox_gpu_corrected_ecc_errors", "", "Number of corrected ECC errors of a GPU.")
+	ue := s.counter("mox_gpu_uncorrected_ecc_errors", "", "Number of uncorrected ECC errors of a GPU.")
+	power := s.gauge("mox_gpu_power_watts", "watts", "Current power draw of a GPU.")
+	limit := s.gauge("mox_gpu_power_limit_watts", "watts", "Power limit of a GPU.")
+	temp := s.gauge("mox_gpu_temperature_celsius", "celsius", "Current temperature of a GPU.")
+
+	if r.Accelerator == nil {
+		return
+	}
+
+	for _, g := range r.Accelerator.GPUs {
+		labels := []string{"slot", g.PCIID(), "model", g.ProductName, "serial", g.SerialNumber}
+		ce.add(float64(g.CECount.Total), labels...)
+		ue.add(float64(g.UECount.Total), labels...)
+		if g.Power.Current > 0 {
+			power.add(float64(g.Power.Current), labels...)
+		}
+		if g.Power.Limit > 0 {
+			limit.add(float64(g.Power.Limit), labels...)
+		}
+		if g.Temp.GPU > 0 {
+			temp.add(float64(g.Temp.GPU), labels...)
+		}
+	}
+}
+
+func writePowerSupply(s *set, r *model.Report) {
+	present := s.gauge("mox_psu_present", "", "Whether a power supply is present.")
+	plugged := s.gauge("mox_psu_plugged", "", "Whether a power supply is plugged.")
+	capacity := s.gauge("mox_psu_capacity_watts", "watts", "Maximum power capacity of a power supply.")
+
+	for i, p := range r.PowerSupply {
+		labels := []string{"psu", fmt.Sprintf("%d", i), "model", p.ModelPartNumber, "serial", p.SerialNumber}
+		present.add(boolValue(p.Present), labels...)
+		plugged.add(boolValue(p.Plugged), labels...)
+		if p.Capacity > 0 {
+			capacity.add(float64(p.Capacity), labels...)
+		}
+	}
+}
@@ -18,6 +18,7 @@ type GPU struct {
 	Temp        GPUTemp       `json:"temp,omitempty"`
 	CECount     GPUECCCounter `json:"ceCount,omitempty"`
 	UECount     GPUECCCounter `json:"ueCount,omitempty"`
+	Retired     GPURetired    `json:"retiredPages,omitempty"`
 }
 
 // IsHealthy returns whether the GPU  is healthy
@@ -110,6 +111,18 @@ type GPUUtil struct {
 	Memory float32 `json:"memory,omitempty"`
 }
 
+// GPURetired represents GPU memory pages retired due to ECC errors
+type GPURetired struct {
+	SingleBit int  `json:"singleBit,omitempty"` // retired due to multiple single bit errors
+	DoubleBit int  `json:"doubleBit,omitempty"` // retired due to double bit errors
+	Pending   bool `json:"pending,omitempty"`   // retirement takes effect on the next reboot
+}
+
+// Total returns the number of retired pages
+func (g GPURetired) Total() int {
+	return g.SingleBit + g.DoubleBit
+}
+
 // GPUECCCounter represents GPU ECC counters
 type GPUECCCounter struct {
 	DeviceMemory int `json:"deviceMemory,omitempty"`
@@ -16,7 +16,7 @@ type BondInterface struct {
 // LinkAttrs represents link attributes
 type LinkAttrs struct {
 	State   string       `json:"state,omitempty"`
-	HWAddr  string       `json:"hwaddr,omitempty"`
+	HWAddr  string       `json:"hwaddr,omitempty" mox:"sensitive"`
 	MTU     int          `json:"mtu"`
 	TxQLen  int          `json:"tx_qlen"`
 	IPAddrs []*IPAddress `json:"ipaddrs,omitempty"`
@@ -31,7 +31,7 @@ type BondAttrs struct {
 	DownDelay       int      `json:"downdelay,omitempty"`
 	UseCarrier      int      `json:"use_carrier,omitempty"`
 	ArpInterval     int      `json:"arp_interval,omitempty"`
-	ArpIPTargets    []net.IP `json:"arp_ip_target,omitempty"`
+	ArpIPTargets    []net.IP `json:"arp_ip_target,omitempty" mox:"sensitive"`
 	ArpValidate     string   `json:"arp_validate,omitempty"`
 	ArpAllTargets   string   `json:"arp_all_targets,omitempty"`
 	Primary         string   `json:"primary,omitempty"`
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecodeError represents a failure of a decoder
+// it tells a component which could not be inspected apart from absent hardware
+type DecodeError struct {
+	Component string `json:"component"`
+	Decoder   string `json:"decoder"`
+	PCIID     string `json:"pciID,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Message   string `json:"message"`
+}
+
+// Summary returns summarized string
+func (e DecodeError) Summary() string {
+	var target []string
+	if e.PCIID != "" {
+		target = append(target, e.PCIID)
+	}
+	if e.Path != "" {
+		target = append(target, e.Path)
+	}
+
+	if len(target) == 0 {
+		return fmt.Sprintf("%s: %s", e.Decoder, e.Message)
+	}
+	return fmt.Sprintf("%s (%s): %s", e.Decoder, strings.Join(target, ", "), e.Message)
+}
+
+// Matches returns true if the error is about a device with the given pci id or path
+func (e DecodeError) Matches(pciid, path string) bool {
+	if pciid != "" && e.PCIID == pciid {
+		return true
+	}
+	if path != "" && e.Path == path {
+		return true
+	}
+	return false
+}
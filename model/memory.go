@@ -88,7 +88,7 @@ type MemoryModule struct {
 	Locator         string  `json:"locator,omitempty"`
 	Manufacturer    string  `json:"manufacturer,omitempty"`
 	PartNumber      string  `json:"partNumber,omitempty"`
-	SerialNumber    string  `json:"serialNumber,omitempty"`
+	SerialNumber    string  `json:"serialNumber,omitempty" mox:"sensitive"`
 	FormFactor      string  `json:"formFactor,omitempty"`
 	Type            string  `json:"type,omitempty"`
 	TypeDetail      string  `json:"typeDetail,omitempty"`
@@ -1,9 +1,14 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
+// SchemaVersion is the version of the JSON format of Report, it is major.minor
+// the minor is raised when fields are added, the major when fields are removed, renamed or change their types
+const SchemaVersion = "1.1"
+
 // FirmwareType is used to indicate firmware type
 type FirmwareType string
 
@@ -14,32 +19,37 @@ const (
 )
 
 // Report represents actual data
+// fields identifying a host or a component are tagged `mox:"sensitive"` to be hidden by the redact package
 type Report struct {
-	System      *System            `json:"system,omitempty"`
-	Chassis     *Chassis           `json:"chassis,omitempty"`
-	Firmware    *Firmware          `json:"firmware,omitempty"`
-	Baseboard   *Baseboard         `json:"baseboard,omitempty"`
-	Processor   *ProcessorReport   `json:"processor,omitempty"`
-	Memory      *MemoryReport      `json:"memory,omitempty"`
-	Storage     *StorageReport     `json:"storage,omitempty"`
-	Network     *NetworkReport     `json:"network,omitempty"`
-	Accelerator *AcceleratorReport `json:"accelerator,omitempty"`
-	PCIDevice   []*PCIBaseSpec     `json:"pciDevices,omitempty"`
-	PowerSupply []*PowerSupply     `json:"powerSupply,omitempty"`
-	BMC         *BMC               `json:"bmc,omitempty"`
-	SAR         map[string][]SAR   `json:"sar,omitempty"`
-	OS          *OS                `json:"os,omitempty"`
-	Hostname    string             `json:"hostname,omitempty"`
-	Version     string             `json:"version"`
-	Timestamp   int64              `json:"timestamp"`
-	Datetime    string             `json:"datetime"`
+	System        *System               `json:"system,omitempty"`
+	Chassis       *Chassis              `json:"chassis,omitempty"`
+	Firmware      *Firmware             `json:"firmware,omitempty"`
+	Baseboard     *Baseboard            `json:"baseboard,omitempty"`
+	Processor     *ProcessorReport      `json:"processor,omitempty"`
+	Memory        *MemoryReport         `json:"memory,omitempty"`
+	Storage       *StorageReport        `json:"storage,omitempty"`
+	Network       *NetworkReport        `json:"network,omitempty"`
+	Accelerator   *AcceleratorReport    `json:"accelerator,omitempty"`
+	PCIDevice     []*PCIBaseSpec        `json:"pciDevices,omitempty"`
+	PowerSupply   []*PowerSupply        `json:"powerSupply,omitempty"`
+	BMC           *BMC                  `json:"bmc,omitempty"`
+	SAR           map[string][]SAR      `json:"sar,omitempty"`
+	OS            *OS                   `json:"os,omitempty"`
+	Extensions    map[string]*Extension `json:"extensions,omitempty"` // keyed by the name of the plugin
+	Hostname      string                `json:"hostname,omitempty" mox:"sensitive"`
+	Errors        []*DecodeError        `json:"errors,omitempty"`
+	Warnings      []*DecodeError        `json:"warnings,omitempty"`
+	Version       string                `json:"version"` // version of mox
+	SchemaVersion string                `json:"schemaVersion"`
+	Timestamp     int64                 `json:"timestamp"`
+	Datetime      string                `json:"datetime"`
 }
 
 // System represents a product
 type System struct {
 	Manufacturer string `json:"manufacturer,omitempty"`
 	ProductName  string `json:"productName,omitempty"`
-	SerialNumber string `json:"serialNumber,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty" mox:"sensitive"`
 }
 
 // Summary returns summarized string
@@ -50,7 +60,7 @@ func (s System) Summary() string {
 // Chassis represents a chassis
 type Chassis struct {
 	Manufacturer string `json:"manufacturer,omitempty"`
-	SerialNumber string `json:"serialNumber,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty" mox:"sensitive"`
 }
 
 // Firmware represents a system firmware
@@ -70,7 +80,7 @@ func (f Firmware) Summary() string {
 type Baseboard struct {
 	Manufacturer string `json:"manufacturer,omitempty"`
 	ProductName  string `json:"productName,omitempty"`
-	SerialNumber string `json:"serialNumber,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty" mox:"sensitive"`
 }
 
 // Summary returns summarized string
@@ -82,7 +92,7 @@ func (b Baseboard) Summary() string {
 type PowerSupply struct {
 	Manufacturer    string `json:"manufacturer,omitempty"`
 	ProductName     string `json:"productName,omitempty"`
-	SerialNumber    string `json:"serialNumber,omitempty"`
+	SerialNumber    string `json:"serialNumber,omitempty" mox:"sensitive"`
 	ModelPartNumber string `json:"modelPartNumber,omitempty"`
 	Capacity        uint16 `json:"capacity,omitempty"`
 	Present         bool   `json:"present"`
@@ -113,11 +123,11 @@ func (p PowerSupply) Stat() string {
 type BMC struct {
 	Type     string `json:"type,omitempty"`
 	Firmware string `json:"firmware,omitempty"`
-	MAC      string `json:"hwaddr,omitempty"`
-	IPAddr   string `json:"ipaddr,omitempty"`
+	MAC      string `json:"hwaddr,omitempty" mox:"sensitive"`
+	IPAddr   string `json:"ipaddr,omitempty" mox:"sensitive"`
 	Netmask  string `json:"netmask,omitempty"`
 	MaskSize int    `json:"masksize,omitempty"`
-	Gateway  string `json:"gateway,omitempty"`
+	Gateway  string `json:"gateway,omitempty" mox:"sensitive"`
 }
 
 // OS represents an operating system
@@ -126,6 +136,26 @@ type OS struct {
 	Kernel string `json:"kernel,omitempty"`
 }
 
+// Extension represents data given by a plugin
+type Extension struct {
+	Data json.RawMessage  `json:"data,omitempty" mox:"sensitive"` // any json the plugin wrote, cleared when redacted
+	Diag []*ExtensionDiag `json:"diag,omitempty"`
+}
+
+// These are statuses of a component diagnosed by a plugin
+const (
+	ExtensionHealthy  = "healthy"
+	ExtensionWarning  = "warning"
+	ExtensionCritical = "critical"
+)
+
+// ExtensionDiag represents a diagnosis of a component by a plugin
+type ExtensionDiag struct {
+	ID      string   `json:"id"`
+	Status  string   `json:"status"`
+	Details []string `json:"details,omitempty" mox:"sensitive"`
+}
+
 // SAR represents an sar results
 type SAR struct {
 	Time   string   `json:"time,omitempty"`
@@ -35,7 +35,7 @@ func (e EthController) Summary() string {
 type NetInterface struct {
 	State            string       `json:"state,omitempty"`
 	Name             string       `json:"name,omitempty"`
-	HWAddr           string       `json:"hwaddr,omitempty"`
+	HWAddr           string       `json:"hwaddr,omitempty" mox:"sensitive"`
 	Speed            uint32       `json:"speed"`
 	MTU              uint32       `json:"mtu"`
 	SupportedSpeed   []string     `json:"supportedSpeed,omitempty"`
@@ -71,11 +71,11 @@ func (n NetInterface) StatSummary() string {
 // IPAddress represents a network address
 type IPAddress struct {
 	Version   byte   `json:"version,omitempty"`
-	Addr      string `json:"addr,omitempty"`
+	Addr      string `json:"addr,omitempty" mox:"sensitive"`
 	Netmask   string `json:"netmask,omitempty"`
 	MaskSize  int    `json:"maskSize,omitempty"`
-	Broadcast string `json:"broadcast,omitempty"`
-	Network   string `json:"network,omitempty"`
+	Broadcast string `json:"broadcast,omitempty" mox:"sensitive"`
+	Network   string `json:"network,omitempty" mox:"sensitive"`
 }
 
 // Module represents a network module
@@ -83,7 +83,7 @@ type Module struct {
 	FormFactor   string `json:"formFactor,omimtempty"`
 	VendorName   string `json:"vendorName,omitempty"`
 	ProductName  string `json:"productName,omitempty"`
-	SerialNumber string `json:"serialNumber,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty" mox:"sensitive"`
 	CableLength  uint8  `json:"cableLength,omitempty"`
 	Connector    string `json:"connector,omitempty"`
 }
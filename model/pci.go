@@ -28,7 +28,7 @@ type PCIBaseSpec struct {
 	SubClassName      string    `json:"subClassName,omitempty"`
 	InterfaceID       byte      `json:"interfaceID"`
 	InterfaceName     string    `json:"interfaceName,omitempty"`
-	SerialNumber      string    `json:"serialNumber,omitempty"`
+	SerialNumber      string    `json:"serialNumber,omitempty" mox:"sensitive"`
 	Driver            string    `json:"driver,omitempty"`
 	Numa              byte      `json:"numa"`
 	CurLink           *PCIeLink `json:"currentLink,omitempty"`
@@ -32,7 +32,7 @@ type Package struct {
 	Socket        string   `json:"socket,omitempty"`
 	Manufacturer  string   `json:"manufacturer,omitempty"`
 	ProductName   string   `json:"productName,omitempty"`
-	SerialNumber  string   `json:"serialNumber,omitempty"`
+	SerialNumber  string   `json:"serialNumber,omitempty" mox:"sensitive"`
 	CoreCount     uint32   `json:"coreCount,omitempty"`
 	ThreadCount   uint32   `json:"threadCount,omitempty"`
 	ThrottleCount uint16   `json:"throttleCount,omitempty"`
@@ -115,9 +115,17 @@ type StoragePowerStatSpec struct {
 	UnsafeShutdownCount uint64 `json:"unsafeShutdownCount,omitempty"`
 }
 
+// StorageWearSpec represents wear of a solid state drive, nil means the drive does not report it
+type StorageWearSpec struct {
+	Used       *byte `json:"used,omitempty"`       // percentage of the endurance used, may exceed 100
+	SpareSpace *byte `json:"spareSpace,omitempty"` // percentage of the spare space remaining
+}
+
 // SMARTDiagSpec represents a diagnostic spec
 type SMARTDiagSpec struct {
-	ErrorRecords []*SMARTRecord `json:"errorRecords,omitempty"`
+	ErrorRecords       []*SMARTRecord `json:"errorRecords,omitempty"`
+	ReallocatedSectors uint64         `json:"reallocatedSectors,omitempty"`
+	PendingSectors     uint64         `json:"pendingSectors,omitempty"`
 }
 
 // IsHealthy returns whether a disk is healthy
@@ -195,7 +203,7 @@ type Drive struct {
 	ID           string `json:"id,omitempty"`   // maj:min
 	Name         string `json:"name,omitempty"` // kernel name, eg. sda
 	Model        string `json:"model,omitempty"`
-	SerialNumber string `json:"serialNumber,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty" mox:"sensitive"`
 	Firmware     string `json:"firmware,omitempty"`
 	Transport    string `json:"transport,omitempty"`
 	Blocks       uint64 `json:"blocks,omitempty"`
@@ -213,6 +221,7 @@ type Drive struct {
 	StorageTempSpec
 	StorageSizeSpec
 	StoragePowerStatSpec
+	StorageWearSpec
 	SMARTDiagSpec
 	SCSIAddressSpec
 }
@@ -273,6 +282,7 @@ type NVMeController struct {
 	StorageTempSpec
 	StorageSizeSpec
 	StoragePowerStatSpec
+	StorageWearSpec
 }
 
 // LongName returns pretty name
@@ -320,7 +330,7 @@ func (n Namespace) Summary() string {
 type RAIDController struct {
 	PCIBaseSpec
 	ProductName       string        `json:"productName,omitempty"`
-	SerialNumber      string        `json:"serialNumber,omitempty"`
+	SerialNumber      string        `json:"serialNumber,omitempty" mox:"sensitive"`
 	AdapterID         string        `json:"adapterId,omitempty"`
 	Firmware          string        `json:"firmware,omitempty"`
 	BIOS              string        `json:"bios,omitempty"`
@@ -381,8 +391,8 @@ type LogDrive struct {
 	StripeSize  uint64      `json:"stripeSize,omitempty"`
 	Status      string      `json:"status,omitempty"`
 	CachePolicy string      `json:"cachePolicy,omitempty"`
-	WWN         string      `json:"wwn,omitempty"`
-	SASAddress  string      `json:"sasAddress"`
+	WWN         string      `json:"wwn,omitempty" mox:"sensitive"`
+	SASAddress  string      `json:"sasAddress" mox:"sensitive"`
 	Degraded    bool        `json:"degraded"`
 	PhyDrives   []*PhyDrive `json:"phyDrives,omitempty"`
 }
@@ -443,8 +453,8 @@ type PhyDrive struct {
 	ErrorCount      uint16 `json:"errorCount,omitempty"`
 
 	// Pass-Through specific attributes
-	SASAddress string `json:"sasAddress"`
-	WWN        string `json:"wwn,omitempty"`
+	SASAddress string `json:"sasAddress" mox:"sensitive"`
+	WWN        string `json:"wwn,omitempty" mox:"sensitive"`
 }
 
 // Pos returns drive position string
@@ -498,7 +508,7 @@ type NonStdController struct {
 	PCIBaseSpec
 	Name         string         `json:"name,omitempty"` // kernel name, eg. fct0
 	Model        string         `json:"model,omitempty"`
-	SerialNumber string         `json:"serialNumber,omitempty"`
+	SerialNumber string         `json:"serialNumber,omitempty" mox:"sensitive"`
 	Firmware     string         `json:"firmware,omitempty"`
 	ErrorRecords []string       `json:"errors,omitempty"`
 	Drives       []*NonStdDrive `json:"drives,omitempty"`
@@ -1,12 +1,15 @@
-package main
+package mox
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/moxspec/moxspec/gpu/nvidia"
 	"github.com/moxspec/moxspec/model"
 	"github.com/moxspec/moxspec/pci"
 )
 
-func shapeAccelerater(r *model.Report, pcidevs *pci.Devices) {
+func shapeAccelerater(ctx context.Context, r *model.Report, pcidevs *pci.Devices) {
 	ar := new(model.AcceleratorReport)
 
 	// GPU
@@ -29,15 +32,21 @@ func shapeAccelerater(r *model.Report, pcidevs *pci.Devices) {
 
 	if len(nvGPUs) > 0 {
 		nvd := nvidia.NewDecoder()
-		err := nvd.Decode()
+		err := nvd.DecodeContext(ctx)
 		if err == nil {
 			for _, g := range nvGPUs {
 				ngpu := nvd.GetGPU(g.PCIID())
 				if ngpu == nil {
+					addError(r, pciIssue(acceleratorComponent, "nvidia", &g.PCIBaseSpec, fmt.Errorf("not found in nvidia-smi")))
 					continue
 				}
 				shapeNvidiaGPU(g, ngpu)
 			}
+		} else {
+			log.Debug(err)
+			for _, g := range nvGPUs {
+				addError(r, pciIssue(acceleratorComponent, "nvidia", &g.PCIBaseSpec, err))
+			}
 		}
 	}
 
@@ -71,6 +80,10 @@ func shapeNvidiaGPU(g *model.GPU, ngpu *nvidia.GPU) {
 
 	g.CECount = shapeNvidiaECCCounter(ngpu.ECCErrors.Aggregate.SingleBit)
 	g.UECount = shapeNvidiaECCCounter(ngpu.ECCErrors.Aggregate.DoubleBit)
+
+	g.Retired.SingleBit = ngpu.RetiredPages.SingleBit
+	g.Retired.DoubleBit = ngpu.RetiredPages.DoubleBit
+	g.Retired.Pending = ngpu.RetiredPages.Pending
 }
 
 func shapeNvidiaECCCounter(ne nvidia.ECCCounter) model.GPUECCCounter {
@@ -1,4 +1,4 @@
-package main
+package mox
 
 // Transport is used to indicate transport protocol
 type Transport string
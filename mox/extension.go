@@ -0,0 +1,35 @@
+package mox
+
+import (
+	"context"
+
+	"github.com/moxspec/moxspec/extension"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/util"
+)
+
+func shapeExtensions(ctx context.Context, r *model.Report, dir string) {
+	if util.Captured() {
+		// plugins look at the live system, not at the captured root
+		log.Debug("skipping plugins on a captured root")
+		return
+	}
+	if dir == "" {
+		dir = extension.DefaultDir
+	}
+
+	d := extension.NewDecoder(dir)
+	err := d.DecodeContext(ctx)
+	if err != nil {
+		addError(r, issue(extensionComponent, "plugin", dir, err))
+		return
+	}
+
+	for _, f := range d.Failures {
+		addError(r, issue(extensionComponent, "plugin", f.Plugin.Path, f.Err))
+	}
+
+	if len(d.Extensions) > 0 {
+		r.Extensions = d.Extensions
+	}
+}
@@ -0,0 +1,41 @@
+package mox
+
+import (
+	"context"
+	"fmt"
+)
+
+// Decoder is implemented by any value that provides information for mox
+type Decoder interface {
+	Decode() error
+}
+
+// ContextDecoder is implemented by any Decoder which can be cancelled by a context
+type ContextDecoder interface {
+	DecodeContext(ctx context.Context) error
+}
+
+// decodeWithContext decodes d with the context
+// a Decoder which does not implement ContextDecoder is abandoned when the context is done,
+// so the caller must not use d after an error is returned
+func decodeWithContext(ctx context.Context, d Decoder) error {
+	if cd, ok := d.(ContextDecoder); ok {
+		return cd.DecodeContext(ctx)
+	}
+
+	var err error
+
+	done := make(chan bool, 1)
+	go func() {
+		err = d.Decode()
+		done <- true
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%T: %s", d, ctx.Err())
+	case <-done:
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package mox
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type hungDecoder struct{}
+
+func (h hungDecoder) Decode() error {
+	time.Sleep(time.Minute)
+	return nil
+}
+
+type ctxDecoder struct {
+	called bool
+}
+
+func (c *ctxDecoder) Decode() error {
+	return nil
+}
+
+func (c *ctxDecoder) DecodeContext(ctx context.Context) error {
+	c.called = true
+	return ctx.Err()
+}
+
+func TestDecodeWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := decodeWithContext(ctx, hungDecoder{})
+	if err == nil {
+		t.Errorf("error should be not nil")
+	}
+
+	cd := new(ctxDecoder)
+	err = decodeWithContext(context.Background(), cd)
+	if err != nil {
+		t.Errorf("error should be nil, got: %s", err)
+	}
+	if !cd.called {
+		t.Errorf("DecodeContext should be called")
+	}
+}
@@ -1,16 +1,18 @@
-package main
+package mox
 
 import (
+	"context"
 	"github.com/moxspec/moxspec/ipmi"
 	"github.com/moxspec/moxspec/model"
 	"github.com/moxspec/moxspec/util"
 )
 
-func shapeBMC(r *model.Report) {
+func shapeBMC(ctx context.Context, r *model.Report) {
 	d := ipmi.NewDecoder()
-	err := d.Decode()
+	err := d.DecodeContext(ctx)
 	if err != nil {
 		log.Debug(err) // it's not fatal (eg. virtual machine)
+		addWarning(r, issue(bmcComponent, "ipmi", d.Path, err))
 		return
 	}
 
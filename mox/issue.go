@@ -0,0 +1,93 @@
+package mox
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// These are the components recorded in errors and warnings
+const (
+	systemComponent      = "System"
+	processorComponent   = "Processor"
+	memoryComponent      = "Memory"
+	storageComponent     = "Storage"
+	raidComponent        = "RAID Card"
+	nvmeComponent        = "NVMe Drive"
+	sataComponent        = "SATA Drive"
+	networkComponent     = "Network"
+	bondingComponent     = "Bonding"
+	acceleratorComponent = "Accelerator"
+	powerSupplyComponent = "Power Supply"
+	bmcComponent         = "BMC"
+	pciComponent         = "PCI"
+	extensionComponent   = "Extension"
+)
+
+var sectionComponents = map[Section]string{
+	SystemSection:      systemComponent,
+	ProcessorSection:   processorComponent,
+	MemorySection:      memoryComponent,
+	StorageSection:     storageComponent,
+	NetworkSection:     networkComponent,
+	AcceleratorSection: acceleratorComponent,
+	PowerSupplySection: powerSupplyComponent,
+	BMCSection:         bmcComponent,
+	PCISection:         pciComponent,
+	ExtensionSection:   extensionComponent,
+}
+
+// issueMu guards errors and warnings appended by concurrent workers
+var issueMu sync.Mutex
+
+// issue returns a DecodeError about a component
+func issue(component, decoder, path string, err error) *model.DecodeError {
+	return &model.DecodeError{
+		Component: component,
+		Decoder:   decoder,
+		Path:      path,
+		Message:   err.Error(),
+	}
+}
+
+// pciIssue returns a DecodeError about a pci device
+func pciIssue(component, decoder string, bspec *model.PCIBaseSpec, err error) *model.DecodeError {
+	e := issue(component, decoder, bspec.Path, err)
+	e.PCIID = bspec.PCIID()
+	return e
+}
+
+// addError records a failure which left a component uninspected
+func addError(r *model.Report, e *model.DecodeError) {
+	issueMu.Lock()
+	defer issueMu.Unlock()
+	r.Errors = append(r.Errors, e)
+}
+
+// addWarning records a failure which left a component partially inspected
+func addWarning(r *model.Report, e *model.DecodeError) {
+	issueMu.Lock()
+	defer issueMu.Unlock()
+	r.Warnings = append(r.Warnings, e)
+}
+
+// sortIssues sorts errors and warnings since workers append them in any order
+func sortIssues(es []*model.DecodeError) {
+	sort.SliceStable(es, func(i, j int) bool {
+		a, b := es[i], es[j]
+		if a.Component != b.Component {
+			return a.Component < b.Component
+		}
+		if a.PCIID != b.PCIID {
+			return a.PCIID < b.PCIID
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Decoder != b.Decoder {
+			return a.Decoder < b.Decoder
+		}
+		return a.Message < b.Message
+	})
+}
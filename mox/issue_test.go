@@ -0,0 +1,42 @@
+package mox
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestSortIssues(t *testing.T) {
+	es := []*model.DecodeError{
+		issue(sataComponent, "spc-acs", "/dev/sdb", fmt.Errorf("timeout")),
+		issue(networkComponent, "eth", "", fmt.Errorf("no such device")),
+		issue(sataComponent, "spc-acs", "/dev/sda", fmt.Errorf("timeout")),
+	}
+	sortIssues(es)
+
+	expect := []string{networkComponent + " ", sataComponent + " /dev/sda", sataComponent + " /dev/sdb"}
+	for i, e := range es {
+		got := e.Component + " " + e.Path
+		if got != expect[i] {
+			t.Errorf("index %d got: %s, expect: %s", i, got, expect[i])
+		}
+	}
+}
+
+func TestMergeIssues(t *testing.T) {
+	dst := new(model.Report)
+	addError(dst, issue(bmcComponent, "mox", "", fmt.Errorf("timed out")))
+
+	src := new(model.Report)
+	addError(src, issue(sataComponent, "spc-acs", "/dev/sda", fmt.Errorf("timeout")))
+	addWarning(src, issue(networkComponent, "eth", "", fmt.Errorf("no such device")))
+
+	merge(dst, src)
+	if len(dst.Errors) != 2 {
+		t.Errorf("errors got: %d, expect: 2", len(dst.Errors))
+	}
+	if len(dst.Warnings) != 1 {
+		t.Errorf("warnings got: %d, expect: 1", len(dst.Warnings))
+	}
+}
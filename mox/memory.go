@@ -1,12 +1,13 @@
-package main
+package mox
 
 import (
+	"context"
 	"github.com/moxspec/moxspec/edac"
 	"github.com/moxspec/moxspec/model"
 	"github.com/moxspec/moxspec/smbios"
 )
 
-func shapeMemory(r *model.Report, sm []*smbios.MemoryDevice) {
+func shapeMemory(ctx context.Context, r *model.Report, sm []*smbios.MemoryDevice) {
 	if sm == nil {
 		return
 	}
@@ -44,12 +45,17 @@ func shapeMemory(r *model.Report, sm []*smbios.MemoryDevice) {
 	r.Memory.Modules = memories
 
 	edacd := edac.NewDecoder()
-	err := edacd.Decode()
+	err := edacd.DecodeContext(ctx)
 	if err != nil {
 		log.Debug(err)
+		addError(r, issue(memoryComponent, "edac", "", err))
 		return
 	}
 
+	r.Memory.Controllers = shapeMemoryControllers(edacd)
+}
+
+func shapeMemoryControllers(edacd *edac.Topology) []*model.MemoryController {
 	var ctls []*model.MemoryController
 	for _, mc := range edacd.Controllers {
 		ctl := new(model.MemoryController)
@@ -82,5 +88,5 @@ func shapeMemory(r *model.Report, sm []*smbios.MemoryDevice) {
 		ctls = append(ctls, ctl)
 	}
 
-	r.Memory.Controllers = ctls
+	return ctls
 }
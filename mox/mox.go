@@ -0,0 +1,472 @@
+// Package mox collects hardware information through the decoders and shapes it into a model.Report
+package mox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/pci"
+	"github.com/moxspec/moxspec/smbios"
+	"github.com/moxspec/moxspec/util"
+)
+
+var log *loglet.Logger
+
+func init() {
+	log = loglet.NewLogger("mox")
+}
+
+// Section represents a top-level section of a report
+type Section string
+
+// These are the sections of a report
+const (
+	SystemSection      Section = "system"
+	ProcessorSection   Section = "processor"
+	MemorySection      Section = "memory"
+	StorageSection     Section = "storage"
+	NetworkSection     Section = "network"
+	AcceleratorSection Section = "accelerator"
+	PowerSupplySection Section = "powersupply"
+	BMCSection         Section = "bmc"
+	PCISection         Section = "pci"
+	ExtensionSection   Section = "extensions"
+)
+
+// AllSections returns all sections in the order they are collected
+func AllSections() []Section {
+	return []Section{
+		SystemSection,
+		ProcessorSection,
+		MemorySection,
+		StorageSection,
+		NetworkSection,
+		AcceleratorSection,
+		PowerSupplySection,
+		BMCSection,
+		PCISection,
+		ExtensionSection,
+	}
+}
+
+// Options represents options for Collect
+type Options struct {
+	// Sections limits the sections to be collected, nil means all sections
+	Sections []Section
+	// Skip excludes sections from being collected
+	Skip []Section
+	// NoRAIDCLI disables running RAID utilities
+	NoRAIDCLI bool
+	// Timeout limits the whole collection, zero means no limit
+	Timeout time.Duration
+	// Timeouts limits the time spent in each section
+	Timeouts map[Section]time.Duration
+	// Workers limits the number of devices decoded at once within a section, zero means the default
+	Workers int
+	// Root is the directory a captured sysfs/procfs/dev tree is placed in, empty means the live system
+	// it is applied to the whole process, and the decoders which need the live system are skipped
+	Root string
+	// Runner runs external commands (e.g. RAID utilities, ipmitool), nil means running them on this system
+	// it is applied to the whole process, a cmdrec.Replayer makes them available in a captured root
+	Runner util.Runner
+	// Paths overrides the paths of tools and devices named by util.PathNames (e.g. ipmitool)
+	// it is applied to the whole process like Root
+	Paths map[string]string
+	// PluginDir is the directory plugins are run from, empty means extension.DefaultDir
+	PluginDir string
+	// Unprivileged skips the decoders which need root privileges (e.g. MSR, SG_IO, RAID utilities)
+	// and records them as errors or warnings, it is applied to the whole process and ignored with Root
+	Unprivileged bool
+	// Version is recorded as the client version in the report
+	Version string
+}
+
+func (o Options) enabled(s Section) bool {
+	for _, e := range o.Skip {
+		if e == s {
+			return false
+		}
+	}
+	if o.Sections == nil {
+		return true
+	}
+	for _, e := range o.Sections {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// anyEnabled returns true if any of ss is going to be collected
+func (o Options) anyEnabled(ss ...Section) bool {
+	for _, s := range ss {
+		if o.enabled(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldSections are sections filling top-level fields of a report
+var fieldSections = map[string][]Section{
+	"system":        {SystemSection},
+	"chassis":       {SystemSection},
+	"firmware":      {SystemSection},
+	"baseboard":     {SystemSection},
+	"processor":     {ProcessorSection},
+	"memory":        {MemorySection},
+	"storage":       {StorageSection},
+	"network":       {NetworkSection},
+	"accelerator":   {AcceleratorSection},
+	"pciDevices":    {PCISection},
+	"powerSupply":   {PowerSupplySection},
+	"bmc":           {BMCSection},
+	"extensions":    {ExtensionSection},
+	"sar":           {},
+	"os":            {},
+	"hostname":      {},
+	"version":       {},
+	"schemaVersion": {},
+	"timestamp":     {},
+	"datetime":      {},
+}
+
+// FieldSections returns the sections filling the top-level field of a report named by its json tag
+// an empty list means that no section is needed, nil means that every section is (e.g. errors)
+func FieldSections(field string) []Section {
+	return fieldSections[field]
+}
+
+// ParseSection returns the section named by name
+func ParseSection(name string) (Section, error) {
+	for _, s := range AllSections() {
+		if string(s) == strings.ToLower(strings.TrimSpace(name)) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown section: %s", name)
+}
+
+// ParseSections returns the sections named by a comma separated list such as "storage,network"
+func ParseSections(list string) ([]Section, error) {
+	var ss []Section
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		s, err := ParseSection(name)
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+// TimeoutError is returned with a partial report when some sections could not be collected in time
+type TimeoutError struct {
+	Sections []Section
+}
+
+func (e TimeoutError) Error() string {
+	var names []string
+	for _, s := range e.Sections {
+		names = append(names, string(s))
+	}
+	return fmt.Sprintf("timed out while collecting %s", strings.Join(names, ", "))
+}
+
+// abandonGrace is how long a timed-out section is waited for to return what it has collected
+const abandonGrace = time.Second
+
+// applyOptions sets the process-wide options, see Options
+func applyOptions(opts *Options) {
+	util.SetRoot(opts.Root)
+	util.SetRunner(opts.Runner)
+	util.SetPaths(opts.Paths)
+	util.SetPrivileged(!opts.Unprivileged || opts.Root != "")
+	if !util.CanExec() {
+		// RAID utilities talk to the live controllers
+		opts.NoRAIDCLI = true
+	}
+}
+
+// Collect decodes the hardware and returns the report
+// when some sections time out, the partial report is returned with a TimeoutError
+// calls of Collect and Refresh must not overlap, since Root, Runner, Paths and Unprivileged are set process-wide
+func Collect(ctx context.Context, opts Options) (*model.Report, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = defaultWorkers()
+	}
+
+	applyOptions(&opts)
+
+	spec := smbios.NewDecoder()
+	pcidevs := pci.NewDecoder()
+
+	// the shared decoders run only when a section needs them
+	// and the sections depending on a failed one are recorded as errors instead of being shaped
+	shared := []struct {
+		name     string
+		decoder  Decoder
+		sections []Section
+	}{
+		{"smbios", spec, []Section{SystemSection, ProcessorSection, MemorySection, PowerSupplySection}},
+		{"pci", pcidevs, []Section{StorageSection, NetworkSection, AcceleratorSection, PCISection}},
+	}
+	errs := make([]error, len(shared))
+	forEach(len(shared), len(shared), func(i int) {
+		if opts.anyEnabled(shared[i].sections...) {
+			errs[i] = decodeWithContext(ctx, shared[i].decoder)
+		}
+	})
+	sharedTimedout := ctx.Err() != nil
+	failed := make(map[Section]*model.DecodeError)
+	failedDecoders := make(map[string]bool)
+	for i, sd := range shared {
+		if errs[i] == nil {
+			continue
+		}
+		log.Warnf("%s: %s", sd.name, errs[i])
+		failedDecoders[sd.name] = true
+		for _, sec := range sd.sections {
+			failed[sec] = issue(sectionComponents[sec], sd.name, "", errs[i])
+		}
+	}
+
+	shapers := []struct {
+		sec   Section
+		shape func(context.Context, *model.Report)
+	}{
+		{SystemSection, func(ctx context.Context, r *model.Report) {
+			shapeSystem(r, spec.GetSystem())
+			shapeChassis(r, spec.GetChassis())
+			shapeFirmware(r, spec.GetBIOS())
+			shapeBaseboard(r, spec.GetBaseboard())
+		}},
+		{ProcessorSection, func(ctx context.Context, r *model.Report) {
+			procs := spec.GetProcessor()
+			if spec.Sysfs {
+				procs = kernelProcessors(ctx)
+			}
+			shapeProcessor(ctx, r, procs)
+		}},
+		{MemorySection, func(ctx context.Context, r *model.Report) { shapeMemory(ctx, r, spec.GetMemoryDevice()) }},
+		{StorageSection, func(ctx context.Context, r *model.Report) { shapeDisk(ctx, r, pcidevs, opts.NoRAIDCLI, workers) }},
+		{NetworkSection, func(ctx context.Context, r *model.Report) { shapeNetwork(ctx, r, pcidevs, workers) }},
+		{AcceleratorSection, func(ctx context.Context, r *model.Report) { shapeAccelerater(ctx, r, pcidevs) }},
+		{PowerSupplySection, func(ctx context.Context, r *model.Report) { shapePowerSupply(r, spec.GetPowerSupply()) }},
+		{PCISection, func(ctx context.Context, r *model.Report) { shapeAllPCIDevices(r, pcidevs) }},
+		{BMCSection, func(ctx context.Context, r *model.Report) { shapeBMC(ctx, r) }},
+		{ExtensionSection, func(ctx context.Context, r *model.Report) { shapeExtensions(ctx, r, opts.PluginDir) }},
+	}
+
+	// sections are independent of each other, so all of them run at once
+	// and the results are merged in the order above to keep the report deterministic
+	results := make([]*model.Report, len(shapers))
+	errs = make([]error, len(shapers))
+	var wg sync.WaitGroup
+	for i, s := range shapers {
+		if !opts.enabled(s.sec) || failed[s.sec] != nil {
+			log.Debugf("skipping %s", s.sec)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, sec Section, shape func(context.Context, *model.Report)) {
+			defer wg.Done()
+			results[i], errs[i] = shapeSection(ctx, sec, opts.Timeouts[sec], shape)
+		}(i, s.sec, s.shape)
+	}
+	wg.Wait()
+
+	r := new(model.Report)
+	var timedout []Section
+	for i, s := range shapers {
+		if e := failed[s.sec]; e != nil && opts.enabled(s.sec) {
+			addError(r, e)
+			if sharedTimedout {
+				timedout = append(timedout, s.sec)
+			}
+			continue
+		}
+		if errs[i] != nil {
+			log.Warnf("%s: %s", s.sec, errs[i])
+			timedout = append(timedout, s.sec)
+			addError(r, issue(sectionComponents[s.sec], "mox", "", errs[i]))
+		}
+		merge(r, results[i])
+	}
+	shapeMisc(r)
+
+	// a failed decoder may still be running, so that it is not read any more
+	if !failedDecoders["smbios"] {
+		shapeSmbiosIssues(r, spec, opts)
+	}
+	if !failedDecoders["pci"] {
+		shapePCIIssues(r, pcidevs)
+	}
+	sortIssues(r.Errors)
+	sortIssues(r.Warnings)
+
+	r.Version = opts.Version
+	r.SchemaVersion = model.SchemaVersion
+
+	tm := time.Now()
+	r.Timestamp = tm.Unix()
+	r.Datetime = tm.Format(time.RFC1123Z)
+
+	if len(timedout) > 0 {
+		return r, TimeoutError{Sections: timedout}
+	}
+
+	return r, nil
+}
+
+// shapeSmbiosIssues records what the smbios decoder could not read
+func shapeSmbiosIssues(r *model.Report, spec *smbios.Spec, opts Options) {
+	for _, e := range spec.Errors {
+		addWarning(r, issue(systemComponent, "smbios", "", e))
+	}
+	if spec.Sysfs {
+		// serial numbers, processors, memory devices and power supplies are in the tables only root can read
+		reason := util.ErrPrivileged
+		if util.Captured() {
+			reason = fmt.Errorf("the capture has no DMI tables")
+		}
+		for _, s := range []Section{SystemSection, ProcessorSection, MemorySection, PowerSupplySection} {
+			if opts.enabled(s) {
+				addWarning(r, issue(sectionComponents[s], "smbios", "", reason))
+			}
+		}
+	}
+}
+
+// shapePCIIssues records what the pci decoder could not read
+func shapePCIIssues(r *model.Report, pcidevs *pci.Devices) {
+	if err := pcidevs.DBError(); err != nil {
+		addError(r, issue(pciComponent, "pci", "", fmt.Errorf("names of devices: %s", err)))
+	}
+	if n := pcidevs.HeaderOnlyCount(); n > 0 {
+		// only root reads past the header, so that a capture taken without root has none either
+		reason := util.ErrPrivileged
+		if util.Captured() {
+			reason = fmt.Errorf("the capture has no extended config space")
+		}
+		addWarning(r, issue(pciComponent, "pci", "", fmt.Errorf("capabilities of %d devices (e.g. AER, serial number): %s", n, reason)))
+	}
+}
+
+// busySections are the sections whose shaper has been abandoned and is still running
+// a section is not shaped again until it returns, so that a hung decoder does not pile up goroutines
+var (
+	busyMu       sync.Mutex
+	busySections = make(map[Section]bool)
+)
+
+// errBusy is returned for a section whose previous shaper has not returned yet
+var errBusy = fmt.Errorf("the previous collection has not returned yet")
+
+// shapeSection runs shape for sec into a new report within the timeout
+// the report is nil if shape did not return in time or the previous one for sec is still running
+func shapeSection(ctx context.Context, sec Section, timeout time.Duration, shape func(context.Context, *model.Report)) (*model.Report, error) {
+	busyMu.Lock()
+	if busySections[sec] {
+		busyMu.Unlock()
+		return nil, errBusy
+	}
+	busySections[sec] = true
+	busyMu.Unlock()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	sr := new(model.Report)
+	done := make(chan bool, 1)
+	go func() {
+		shape(ctx, sr)
+
+		busyMu.Lock()
+		delete(busySections, sec)
+		busyMu.Unlock()
+
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// context-aware decoders return soon after the context is done
+		select {
+		case <-done:
+		case <-time.After(abandonGrace):
+			return nil, ctx.Err()
+		}
+	}
+
+	return sr, ctx.Err()
+}
+
+// merge copies the sections src has into dst
+func merge(dst, src *model.Report) {
+	if src == nil {
+		return
+	}
+	if src.System != nil {
+		dst.System = src.System
+	}
+	if src.Chassis != nil {
+		dst.Chassis = src.Chassis
+	}
+	if src.Firmware != nil {
+		dst.Firmware = src.Firmware
+	}
+	if src.Baseboard != nil {
+		dst.Baseboard = src.Baseboard
+	}
+	if src.Processor != nil {
+		dst.Processor = src.Processor
+	}
+	if src.Memory != nil {
+		dst.Memory = src.Memory
+	}
+	if src.Storage != nil {
+		dst.Storage = src.Storage
+	}
+	if src.Network != nil {
+		dst.Network = src.Network
+	}
+	if src.Accelerator != nil {
+		dst.Accelerator = src.Accelerator
+	}
+	if src.PCIDevice != nil {
+		dst.PCIDevice = src.PCIDevice
+	}
+	if src.PowerSupply != nil {
+		dst.PowerSupply = src.PowerSupply
+	}
+	if src.BMC != nil {
+		dst.BMC = src.BMC
+	}
+	if src.Extensions != nil {
+		dst.Extensions = src.Extensions
+	}
+	dst.Errors = append(dst.Errors, src.Errors...)
+	dst.Warnings = append(dst.Warnings, src.Warnings...)
+}
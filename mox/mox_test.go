@@ -0,0 +1,280 @@
+package mox
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moxspec/moxspec/capture"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/util"
+)
+
+func TestParseSection(t *testing.T) {
+	tests := []struct {
+		in  string
+		ex  Section
+		err bool
+	}{
+		{"storage", StorageSection, false},
+		{" BMC ", BMCSection, false},
+		{"pci", PCISection, false},
+		{"disk", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSection(tt.in)
+		if tt.err && err == nil {
+			t.Errorf("test: %+v, error should be not nil", tt)
+		}
+		if !tt.err && err != nil {
+			t.Errorf("test: %+v, error should be nil, got: %s", tt, err)
+		}
+		if got != tt.ex {
+			t.Errorf("test: %+v, got: %s, expect: %s", tt, got, tt.ex)
+		}
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	tests := []struct {
+		in  string
+		ex  []Section
+		err bool
+	}{
+		{"", nil, false},
+		{"storage", []Section{StorageSection}, false},
+		{"storage, network,", []Section{StorageSection, NetworkSection}, false},
+		{"storage,disk", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSections(tt.in)
+		if tt.err && err == nil {
+			t.Errorf("test: %+v, error should be not nil", tt)
+		}
+		if !tt.err && err != nil {
+			t.Errorf("test: %+v, error should be nil, got: %s", tt, err)
+		}
+		if !reflect.DeepEqual(got, tt.ex) {
+			t.Errorf("test: %+v, got: %v, expect: %v", tt, got, tt.ex)
+		}
+	}
+}
+
+func TestFieldSections(t *testing.T) {
+	rt := reflect.TypeOf(model.Report{})
+	for i := 0; i < rt.NumField(); i++ {
+		name := strings.Split(rt.Field(i).Tag.Get("json"), ",")[0]
+		if name == "errors" || name == "warnings" {
+			if FieldSections(name) != nil {
+				t.Errorf("%s should need every section", name)
+			}
+			continue
+		}
+		if FieldSections(name) == nil {
+			t.Errorf("no sections for %s", name)
+		}
+	}
+}
+
+func TestOptionsEnabled(t *testing.T) {
+	tests := []struct {
+		opts Options
+		sec  Section
+		ex   bool
+	}{
+		{Options{}, BMCSection, true},
+		{Options{Sections: []Section{StorageSection}}, StorageSection, true},
+		{Options{Sections: []Section{StorageSection}}, BMCSection, false},
+		{Options{Skip: []Section{BMCSection}}, BMCSection, false},
+		{Options{Skip: []Section{BMCSection}}, PCISection, true},
+		{Options{Sections: []Section{BMCSection}, Skip: []Section{BMCSection}}, BMCSection, false},
+	}
+
+	for _, tt := range tests {
+		got := tt.opts.enabled(tt.sec)
+		if got != tt.ex {
+			t.Errorf("test: %+v, got: %t, expect: %t", tt, got, tt.ex)
+		}
+	}
+}
+
+func TestCollectCapturedRoot(t *testing.T) {
+	defer util.SetRoot("")
+
+	opts := Options{
+		Sections: []Section{NetworkSection, PCISection},
+		Root:     "testdata/captured",
+	}
+	r, err := Collect(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	if len(r.PCIDevice) != 1 {
+		t.Fatalf("pci devices got: %d, expect: 1", len(r.PCIDevice))
+	}
+	dev := r.PCIDevice[0]
+	if dev.Path != "/sys/devices/pci0000:00/0000:00:03.0" {
+		t.Errorf("path got: %s", dev.Path)
+	}
+	if dev.VendorID != 0x8086 || dev.DeviceID != 0x100e || dev.Driver != "e1000" {
+		t.Errorf("got: %04x:%04x (%s), expect: 8086:100e (e1000)", dev.VendorID, dev.DeviceID, dev.Driver)
+	}
+
+	if r.Network == nil || len(r.Network.EthControllers) != 1 || len(r.Network.EthControllers[0].Interfaces) != 1 {
+		t.Fatalf("an interface should be found, got: %+v", r.Network)
+	}
+	intf := r.Network.EthControllers[0].Interfaces[0]
+	if intf.Name != "eth0" || intf.HWAddr != "52:54:00:12:34:56" || intf.MTU != 1500 || intf.Speed != 1000 {
+		t.Errorf("got: %+v", intf)
+	}
+	if intf.RxErrors != 3 || intf.RxDropped != 7 {
+		t.Errorf("rx errors got: %d, rx dropped got: %d, expect: 3, 7", intf.RxErrors, intf.RxDropped)
+	}
+
+	var found bool
+	for _, w := range r.Warnings {
+		if w.Component == "PCI" && w.Message == "capabilities of 1 devices (e.g. AER, serial number): the capture has no extended config space" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("a warning about the config space should be recorded, got: %+v", r.Warnings)
+	}
+
+	if r.Hostname != "host1.example.com" {
+		t.Errorf("hostname got: %s", r.Hostname)
+	}
+	if r.OS == nil || r.OS.Distro != "Rocky Linux 9.0 (Blue Onyx)" || r.OS.Kernel != "5.14.0-70.el9.x86_64" {
+		t.Errorf("os got: %+v", r.OS)
+	}
+}
+
+func TestCollectSharedDecoderFailure(t *testing.T) {
+	defer util.SetRoot("")
+
+	dir, err := ioutil.TempDir("", "mox-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// no pci devices are under the root
+	opts := Options{
+		Sections: []Section{NetworkSection, PCISection},
+		Root:     dir,
+	}
+	r, err := Collect(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+	if r == nil {
+		t.Fatal("a partial report should be returned")
+	}
+	if r.Network != nil || r.PCIDevice != nil {
+		t.Errorf("sections depending on pci should not be shaped, got: %+v %+v", r.Network, r.PCIDevice)
+	}
+
+	var got []string
+	for _, e := range r.Errors {
+		got = append(got, e.Component+"/"+e.Decoder)
+	}
+	ex := []string{"Network/pci", "PCI/pci"}
+	if !reflect.DeepEqual(got, ex) {
+		t.Errorf("errors got: %v, expect: %v", got, ex)
+	}
+}
+
+func TestCollectBundleRoundTrip(t *testing.T) {
+	defer util.SetRoot("")
+
+	opts := Options{
+		Sections: []Section{NetworkSection, PCISection},
+		Root:     "testdata/captured",
+	}
+
+	capture.Start()
+	orig, err := Collect(context.Background(), opts)
+	b := capture.Stop()
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = b.WriteTarGz(&buf)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "mox-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = extractTarGz(&buf, dir)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	opts.Root = filepath.Join(dir, "root")
+	got, err := Collect(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(got.PCIDevice, orig.PCIDevice) {
+		t.Errorf("pci devices got: %+v, expect: %+v", got.PCIDevice, orig.PCIDevice)
+	}
+	if !reflect.DeepEqual(got.Network, orig.Network) {
+		t.Errorf("network got: %+v, expect: %+v", got.Network, orig.Network)
+	}
+	if got.Hostname != orig.Hostname || !reflect.DeepEqual(got.OS, orig.OS) {
+		t.Errorf("platform got: %s %+v, expect: %s %+v", got.Hostname, got.OS, orig.Hostname, orig.OS)
+	}
+}
+
+func extractTarGz(r io.Reader, dir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		p := filepath.Join(dir, h.Name)
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(p, 0755)
+		case tar.TypeSymlink:
+			err = os.Symlink(h.Linkname, p)
+		case tar.TypeReg:
+			var data []byte
+			data, err = ioutil.ReadAll(tr)
+			if err == nil {
+				err = ioutil.WriteFile(p, data, 0644)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -1,8 +1,9 @@
-package main
+package mox
 
 import (
 	"github.com/moxspec/moxspec/model"
 	"github.com/moxspec/moxspec/pci"
+	"github.com/moxspec/moxspec/util"
 )
 
 func shapeAllPCIDevices(r *model.Report, devs *pci.Devices) {
@@ -15,7 +16,7 @@ func shapeAllPCIDevices(r *model.Report, devs *pci.Devices) {
 func shapePCIDevice(dev *pci.Device) *model.PCIBaseSpec {
 	p := new(model.PCIBaseSpec)
 
-	p.Path = dev.Path
+	p.Path = util.TrimRoot(dev.Path)
 	p.Location.Domain = dev.Domain
 	p.Location.Bus = dev.Bus
 	p.Location.Device = dev.Device
@@ -0,0 +1,45 @@
+package mox
+
+import (
+	"runtime"
+	"sync"
+)
+
+// defaultWorkers returns the worker count used when Options.Workers is not set
+func defaultWorkers() int {
+	n := runtime.NumCPU()
+	if n > 8 {
+		return 8
+	}
+	return n
+}
+
+// forEach calls fn for each index in [0, n) with at most workers goroutines
+// fn should write its result into the index of a pre-allocated slice to keep the order deterministic
+func forEach(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		idx <- i
+	}
+	close(idx)
+
+	wg.Wait()
+}
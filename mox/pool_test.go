@@ -0,0 +1,39 @@
+package mox
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 100} {
+		var running, peak int32
+		res := make([]int, 50)
+
+		forEach(len(res), workers, func(i int) {
+			cur := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if cur <= p || atomic.CompareAndSwapInt32(&peak, p, cur) {
+					break
+				}
+			}
+			res[i] = i * 2
+			atomic.AddInt32(&running, -1)
+		})
+
+		for i, r := range res {
+			if r != i*2 {
+				t.Errorf("workers: %d, index %d got: %d, expect: %d", workers, i, r, i*2)
+			}
+		}
+
+		limit := int32(workers)
+		if limit < 1 {
+			limit = 1
+		}
+		if peak > limit {
+			t.Errorf("workers: %d, %d goroutines ran at once", workers, peak)
+		}
+	}
+}
@@ -1,6 +1,8 @@
-package main
+package mox
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/moxspec/moxspec/cpu"
@@ -11,7 +13,7 @@ import (
 	"github.com/moxspec/moxspec/util"
 )
 
-func shapeProcessor(r *model.Report, sm []*smbios.Processor) {
+func shapeProcessor(ctx context.Context, r *model.Report, sm []*smbios.Processor) {
 	if sm == nil {
 		return
 	}
@@ -47,6 +49,7 @@ func shapeProcessor(r *model.Report, sm []*smbios.Processor) {
 	if err != nil {
 		log.Debug(err)
 		log.Info("using smbibos as primary data source")
+		addWarning(r, issue(processorComponent, "cpuid", "", err))
 	} else {
 		for i, p := range pkgs {
 			if cpuidd.BrandString != "" {
@@ -73,14 +76,15 @@ func shapeProcessor(r *model.Report, sm []*smbios.Processor) {
 	}
 
 	cput := cpu.NewDecoder()
-	err = cput.Decode()
+	err = cput.DecodeContext(ctx)
 	if err != nil {
 		log.Error(err)
 		log.Error("could not parse processor information from the kernel")
+		addError(r, issue(processorComponent, "cpu", "", err))
 		return
 	}
 
-	shapeProcessorNode(pkgs, cput)
+	shapeProcessorNode(r, pkgs, cput)
 
 	var cores, threads uint32
 	for _, p := range pkgs {
@@ -93,6 +97,22 @@ func shapeProcessor(r *model.Report, sm []*smbios.Processor) {
 	r.Processor.Packages = pkgs
 }
 
+// kernelProcessors stands in for the smbios records which require privileges, a record per package the kernel knows
+func kernelProcessors(ctx context.Context) []*smbios.Processor {
+	cput := cpu.NewDecoder()
+	err := cput.DecodeContext(ctx)
+	if err != nil {
+		log.Debug(err)
+		return nil
+	}
+
+	var procs []*smbios.Processor
+	for _, p := range cput.Packages() {
+		procs = append(procs, &smbios.Processor{SocketDesignation: fmt.Sprintf("CPU%d", p.ID)})
+	}
+	return procs
+}
+
 func shapeProcessorCache(p *model.Package, cpuidd *cpuid.Processor) {
 	for _, c := range cpuidd.GetCaches() {
 		size := c.Size
@@ -136,14 +156,18 @@ func shapeProcessorTLB(p *model.Package, cpuidd *cpuid.Processor) {
 	}
 }
 
-func shapeProcessorNode(pkgs []*model.Package, cput *cpu.Topology) {
+func shapeProcessorNode(r *model.Report, pkgs []*model.Package, cput *cpu.Topology) {
 	cputPkgs := cput.Packages()
 	if len(cputPkgs) != len(pkgs) {
 		log.Warn("package count reported by the kernel is not match with smbios report")
 		log.Warn("please check your kernel implementation")
+		addWarning(r, issue(processorComponent, "cpu", "", fmt.Errorf("package count %d does not match smbios count %d", len(cputPkgs), len(pkgs))))
 		return
 	}
 
+	// a msr failure is recorded once since it fails on every core for the same reason
+	var msrErr error
+
 	for i, p := range cputPkgs {
 		if i >= len(pkgs) {
 			break
@@ -168,31 +192,43 @@ func shapeProcessorNode(pkgs []*model.Package, cput *cpu.Topology) {
 				c.ID = cr.ID
 				c.ThrottleCount = cr.ThrottleCount
 
-				r := msr.NewDecoder(cr.Threads[0], msr.INTEL)
-				err := r.Decode()
+				md := msr.NewDecoder(cr.Threads[0], msr.INTEL)
+				err := md.Decode()
 				if err == nil {
-					c.Temp = r.Temp
+					c.Temp = md.Temp
+				} else if msrErr == nil {
+					msrErr = err
 				}
 
 				n.Cores = append(n.Cores, c)
 			}
 
-			var cnt uint32
-			var tsum float64
-			for _, c := range n.Cores {
-				cnt++
-				if c.Temp > 0 {
-					tsum = tsum + float64(c.Temp)
-				}
-			}
-			if cnt > 0 {
-				n.AvgTemp = util.Round(tsum/float64(cnt), 1)
-			}
-			n.CoreCount = cnt
+			n.CoreCount = uint32(len(n.Cores))
+			n.AvgTemp = avgTemp(n.Cores)
 
 			pkgs[i].Nodes = append(pkgs[i].Nodes, n)
 		}
 
 		pkgs[i].ID = p.ID
 	}
+
+	if msrErr != nil {
+		log.Debug(msrErr)
+		addWarning(r, issue(processorComponent, "msr", "", msrErr))
+	}
+}
+
+// avgTemp returns the average temperature of cores, a core without the temperature counts as 0
+func avgTemp(cores []*model.Core) float64 {
+	if len(cores) == 0 {
+		return 0
+	}
+
+	var tsum float64
+	for _, c := range cores {
+		if c.Temp > 0 {
+			tsum = tsum + float64(c.Temp)
+		}
+	}
+	return util.Round(tsum/float64(len(cores)), 1)
 }
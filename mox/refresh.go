@@ -0,0 +1,336 @@
+package mox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/moxspec/moxspec/cpu"
+	"github.com/moxspec/moxspec/edac"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/msr"
+	"github.com/moxspec/moxspec/netlink"
+	"github.com/moxspec/moxspec/nvmeadm"
+	"github.com/moxspec/moxspec/spc/acs"
+)
+
+// refreshDecoder is the decoder recorded for a section Refresh could not finish
+const refreshDecoder = "refresh"
+
+// counterSources are the components and decoders whose errors and warnings are replaced by Refresh
+var counterSources = map[Section][][2]string{
+	ProcessorSection: {{processorComponent, "cpu"}, {processorComponent, "msr"}},
+	MemorySection:    {{memoryComponent, "edac"}},
+	StorageSection:   {{nvmeComponent, "nvmeadm"}, {sataComponent, "spc-acs"}},
+	NetworkSection:   {{networkComponent, "netlink"}},
+}
+
+// CounterSections returns the sections holding counters which change while the system is running
+// (thermal throttling, EDAC, SMART and interface statistics)
+func CounterSections() []Section {
+	return []Section{
+		ProcessorSection,
+		MemorySection,
+		StorageSection,
+		NetworkSection,
+	}
+}
+
+// Refresh reads the counters of the devices in prev again and returns a copy of prev with them updated
+// nothing else is decoded, so that devices added or removed since prev are left to the next Collect
+// RAID controllers are left as they are since RAID utilities are too slow to run often
+// it must not overlap with Collect, see Collect
+func Refresh(ctx context.Context, prev *model.Report, opts Options) (*model.Report, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = defaultWorkers()
+	}
+
+	applyOptions(&opts)
+
+	refreshers := []struct {
+		sec     Section
+		refresh func(context.Context, *model.Report) error
+	}{
+		{ProcessorSection, func(ctx context.Context, r *model.Report) error {
+			if prev.Processor == nil {
+				return nil
+			}
+			r.Processor = new(model.ProcessorReport)
+			if err := copyReport(r.Processor, prev.Processor); err != nil {
+				return err
+			}
+			refreshProcessor(ctx, r, r.Processor)
+			return nil
+		}},
+		{MemorySection, func(ctx context.Context, r *model.Report) error {
+			if prev.Memory == nil {
+				return nil
+			}
+			r.Memory = new(model.MemoryReport)
+			if err := copyReport(r.Memory, prev.Memory); err != nil {
+				return err
+			}
+			refreshMemory(ctx, r, r.Memory)
+			return nil
+		}},
+		{StorageSection, func(ctx context.Context, r *model.Report) error {
+			if prev.Storage == nil {
+				return nil
+			}
+			// RAID controllers are shared with prev since they are not refreshed
+			st := *prev.Storage
+			if err := copyReport(&st.NVMeControllers, prev.Storage.NVMeControllers); err != nil {
+				return err
+			}
+			if err := copyReport(&st.AHCIControllers, prev.Storage.AHCIControllers); err != nil {
+				return err
+			}
+			r.Storage = &st
+			refreshStorage(ctx, r, r.Storage, workers)
+			return nil
+		}},
+		{NetworkSection, func(ctx context.Context, r *model.Report) error {
+			if prev.Network == nil {
+				return nil
+			}
+			r.Network = new(model.NetworkReport)
+			if err := copyReport(r.Network, prev.Network); err != nil {
+				return err
+			}
+			refreshNetwork(ctx, r, r.Network, workers)
+			return nil
+		}},
+	}
+
+	// the sections are refreshed at once like Collect, see Collect
+	results := make([]*model.Report, len(refreshers))
+	errs := make([]error, len(refreshers))
+	var wg sync.WaitGroup
+	for i, s := range refreshers {
+		if !opts.enabled(s.sec) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, sec Section, refresh func(context.Context, *model.Report) error) {
+			defer wg.Done()
+			var err error
+			results[i], errs[i] = shapeSection(ctx, sec, opts.Timeouts[sec], func(ctx context.Context, r *model.Report) {
+				err = refresh(ctx, r)
+			})
+			if errs[i] == nil && err != nil {
+				results[i], errs[i] = nil, err
+			}
+		}(i, s.sec, s.refresh)
+	}
+	wg.Wait()
+
+	replaced := make(map[[2]string]bool)
+	for _, s := range refreshers {
+		if opts.enabled(s.sec) {
+			for _, src := range counterSources[s.sec] {
+				replaced[src] = true
+			}
+			replaced[[2]string{sectionComponents[s.sec], refreshDecoder}] = true
+		}
+	}
+	keep := func(e *model.DecodeError) bool { return !replaced[[2]string{e.Component, e.Decoder}] }
+
+	r := new(model.Report)
+	*r = *prev
+	r.Errors = filterIssues(prev.Errors, keep)
+	r.Warnings = filterIssues(prev.Warnings, keep)
+
+	var timedout []Section
+	for i, s := range refreshers {
+		if errs[i] != nil {
+			log.Warnf("%s: %s", s.sec, errs[i])
+			if ctx.Err() != nil || errs[i] == errBusy {
+				timedout = append(timedout, s.sec)
+			}
+			addError(r, issue(sectionComponents[s.sec], refreshDecoder, "", errs[i]))
+			continue
+		}
+		merge(r, results[i])
+	}
+	sortIssues(r.Errors)
+	sortIssues(r.Warnings)
+
+	tm := time.Now()
+	r.Timestamp = tm.Unix()
+	r.Datetime = tm.Format(time.RFC1123Z)
+
+	if len(timedout) > 0 {
+		return r, TimeoutError{Sections: timedout}
+	}
+
+	return r, nil
+}
+
+// copyReport deep copies a part of a report, so that the copy can be updated without touching the original
+func copyReport(dst, src interface{}) error {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+// refreshProcessor reads the throttle counters and the core temperatures into proc
+func refreshProcessor(ctx context.Context, r *model.Report, proc *model.ProcessorReport) {
+	cput := cpu.NewDecoder()
+	err := cput.DecodeContext(ctx)
+	if err != nil {
+		log.Debug(err)
+		addError(r, issue(processorComponent, "cpu", "", err))
+		return
+	}
+
+	cputPkgs := cput.Packages()
+	if len(cputPkgs) != len(proc.Packages) {
+		addWarning(r, issue(processorComponent, "cpu", "", fmt.Errorf("package count %d does not match smbios count %d", len(cputPkgs), len(proc.Packages))))
+		return
+	}
+
+	// a msr failure is recorded once since it fails on every core for the same reason
+	var msrErr error
+
+	for i, p := range cputPkgs {
+		pkg := proc.Packages[i]
+		if pkg.ID != p.ID {
+			continue
+		}
+		pkg.ThrottleCount = p.ThrottleCount
+
+		for _, nd := range p.Nodes() {
+			n := findNode(pkg.Nodes, nd.ID)
+			if n == nil {
+				continue
+			}
+
+			for _, cr := range nd.Cores() {
+				c := findCore(n.Cores, cr.ID)
+				if c == nil {
+					continue
+				}
+				c.ThrottleCount = cr.ThrottleCount
+
+				md := msr.NewDecoder(cr.Threads[0], msr.INTEL)
+				err := md.Decode()
+				if err == nil {
+					c.Temp = md.Temp
+				} else if msrErr == nil {
+					msrErr = err
+				}
+			}
+
+			n.AvgTemp = avgTemp(n.Cores)
+		}
+	}
+
+	if msrErr != nil {
+		log.Debug(msrErr)
+		addWarning(r, issue(processorComponent, "msr", "", msrErr))
+	}
+}
+
+func findNode(ns []*model.Node, id uint16) *model.Node {
+	for _, n := range ns {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+func findCore(cs []*model.Core, id uint16) *model.Core {
+	for _, c := range cs {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// refreshMemory reads the EDAC counters into mem
+func refreshMemory(ctx context.Context, r *model.Report, mem *model.MemoryReport) {
+	edacd := edac.NewDecoder()
+	err := edacd.DecodeContext(ctx)
+	if err != nil {
+		log.Debug(err)
+		addError(r, issue(memoryComponent, "edac", "", err))
+		return
+	}
+
+	mem.Controllers = shapeMemoryControllers(edacd)
+}
+
+// refreshStorage reads the SMART counters of NVMe controllers and SATA drives into st
+func refreshStorage(ctx context.Context, r *model.Report, st *model.StorageReport, workers int) {
+	forEach(len(st.NVMeControllers), workers, func(i int) {
+		ctl := st.NVMeControllers[i]
+		admd := nvmeadm.NewDecoder("/dev/" + ctl.Name)
+		err := admd.DecodeSmartContext(ctx)
+		if err != nil {
+			log.Debugf("nvmeadm: %s", err)
+			addError(r, pciIssue(nvmeComponent, "nvmeadm", &ctl.PCIBaseSpec, err))
+			return
+		}
+		shapeNVMeSmart(ctl, admd)
+	})
+
+	var drvs []*model.Drive
+	for _, ctl := range st.AHCIControllers {
+		drvs = append(drvs, ctl.Drives...)
+	}
+	forEach(len(drvs), workers, func(i int) {
+		drv := drvs[i]
+		acsd := acs.NewDecoder("/dev/" + drv.Name)
+		err := acsd.DecodeCountersContext(ctx)
+		if err != nil {
+			log.Warnf("spc-acs: %s", err)
+			addError(r, issue(sataComponent, "spc-acs", "/dev/"+drv.Name, err))
+			return
+		}
+		shapeACSCounters(drv, acsd)
+	})
+}
+
+// refreshNetwork reads the interface statistics into nw
+func refreshNetwork(ctx context.Context, r *model.Report, nw *model.NetworkReport, workers int) {
+	forEach(len(nw.EthControllers), workers, func(i int) {
+		c := nw.EthControllers[i]
+		for _, intf := range c.Interfaces {
+			nl := netlink.NewDecoder(intf.Name)
+			err := decodeWithContext(ctx, nl)
+			if err != nil {
+				log.Debug(err)
+				addWarning(r, pciIssue(networkComponent, "netlink", &c.PCIBaseSpec, err))
+				continue
+			}
+			intf.RxErrors = nl.Stats.RxErrors
+			intf.TxErrors = nl.Stats.TxErrors
+			intf.RxDropped = nl.Stats.RxDropped
+			intf.TxDropped = nl.Stats.TxDropped
+		}
+	})
+}
+
+func filterIssues(es []*model.DecodeError, keep func(*model.DecodeError) bool) []*model.DecodeError {
+	var kept []*model.DecodeError
+	for _, e := range es {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+package mox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/util"
+)
+
+func TestRefresh(t *testing.T) {
+	defer util.SetRoot("")
+
+	ctl := new(model.RAIDController)
+	ctl.ProductName = "PERC H730P Mini"
+
+	prev := new(model.Report)
+	prev.System = &model.System{ProductName: "R1"}
+	prev.Storage = &model.StorageReport{RAIDControllers: []*model.RAIDController{ctl}}
+	eth := new(model.EthController)
+	eth.Path = "/sys/devices/pci0000:00/0000:00:03.0"
+	eth.Interfaces = []*model.NetInterface{{Name: "eth0", RxErrors: 1}}
+	prev.Network = &model.NetworkReport{EthControllers: []*model.EthController{eth}}
+	prev.Errors = []*model.DecodeError{
+		{Component: raidComponent, Decoder: "megacli", Message: "timed out"},
+		{Component: networkComponent, Decoder: "netlink", Message: "stale"},
+		{Component: powerSupplyComponent, Decoder: "smbios", Message: "broken"},
+	}
+	prev.Timestamp = 1
+
+	opts := Options{
+		Skip: []Section{ProcessorSection, MemorySection},
+		Root: "testdata/captured",
+	}
+	r, err := Refresh(context.Background(), prev, opts)
+	if err != nil {
+		t.Fatalf("error should be nil, got: %s", err)
+	}
+
+	if r == prev {
+		t.Fatal("prev should not be modified")
+	}
+	if prev.Timestamp != 1 || len(prev.Errors) != 3 {
+		t.Errorf("prev should not be modified, got: %+v", prev)
+	}
+
+	if r.System == nil || r.System.ProductName != "R1" {
+		t.Errorf("system should be kept, got: %+v", r.System)
+	}
+	if r.Storage == nil || len(r.Storage.RAIDControllers) != 1 || r.Storage.RAIDControllers[0] != ctl {
+		t.Errorf("raid controllers should be kept, got: %+v", r.Storage)
+	}
+	if r.Network == nil || len(r.Network.EthControllers) != 1 {
+		t.Fatalf("network should be refreshed, got: %+v", r.Network)
+	}
+	if intf := r.Network.EthControllers[0].Interfaces[0]; intf.RxErrors != 3 {
+		t.Errorf("rx errors got: %d, expect: 3", intf.RxErrors)
+	}
+	if intf := prev.Network.EthControllers[0].Interfaces[0]; intf.RxErrors != 1 {
+		t.Errorf("prev should not be modified, got rx errors: %d", intf.RxErrors)
+	}
+
+	var comps []string
+	for _, e := range r.Errors {
+		comps = append(comps, e.Component)
+	}
+	if len(comps) != 2 || comps[0] != powerSupplyComponent || comps[1] != raidComponent {
+		t.Errorf("errors got: %v, expect: [%s %s]", comps, powerSupplyComponent, raidComponent)
+	}
+
+	if r.Timestamp == prev.Timestamp {
+		t.Errorf("timestamp should be updated")
+	}
+}
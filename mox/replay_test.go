@@ -0,0 +1,202 @@
+package mox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/moxspec/moxspec/cmdrec"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/util"
+)
+
+// replay makes the decoders read the tree and replay the commands recorded under dir
+func replay(dir string) func() {
+	util.SetRoot(dir)
+	util.SetRunner(cmdrec.NewReplayer(dir))
+
+	return func() {
+		util.SetRoot("")
+		util.SetRunner(nil)
+	}
+}
+
+func logDrive(name string, tgt uint16, wwn, sas string) *model.LogDrive {
+	l := new(model.LogDrive)
+	l.Name = name
+	l.SCSITarget = tgt
+	l.WWN = wwn
+	l.SASAddress = sas
+	return l
+}
+
+func slots(pds []*model.PhyDrive) string {
+	var s []string
+	for _, p := range pds {
+		s = append(s, fmt.Sprintf("%s:%s(%s)", p.Enclosure, p.Slot, p.Name))
+	}
+	return fmt.Sprint(s)
+}
+
+func TestShapeMegaRAIDControllerReplay(t *testing.T) {
+	defer replay("testdata/replay/megaraid")()
+
+	r := new(model.Report)
+	ctl := new(model.RAIDController)
+	ctl.Location.Bus = 1
+	ctl.LogDrives = []*model.LogDrive{
+		logDrive("sda", 0, "", ""),
+		logDrive("sdb", 1, "5000CCA2731373AB", ""),
+		logDrive("sdc", 2, "5000CCA26ADE95BB", ""),
+	}
+
+	shapeMegaRAIDController(context.Background(), r, ctl, 4)
+
+	if len(r.Errors) != 0 {
+		t.Fatalf("errors got: %+v", r.Errors)
+	}
+	if ctl.ProductName != "LSI MegaRAID SAS 9270-8i" || ctl.Firmware != "3.460.05-4565" || ctl.SerialNumber != "SV54952556" {
+		t.Errorf("controller got: %s %s %s", ctl.ProductName, ctl.Firmware, ctl.SerialNumber)
+	}
+	if ctl.AdapterID != "0" || ctl.Battery != model.BatteryPresent {
+		t.Errorf("adapter got: %s, battery got: %s", ctl.AdapterID, ctl.Battery)
+	}
+
+	if len(ctl.LogDrives) != 1 {
+		t.Fatalf("log drives got: %d, expect: 1", len(ctl.LogDrives))
+	}
+	ld := ctl.LogDrives[0]
+	if ld.Name != "sda" || ld.RAIDLv != "RAID 1" || ld.Status != "Optimal" || ld.Degraded {
+		t.Errorf("log drive got: %s %s %s degraded:%t", ld.Name, ld.RAIDLv, ld.Status, ld.Degraded)
+	}
+	if got := slots(ld.PhyDrives); got != "[32:1() 32:2()]" {
+		t.Errorf("phy drives got: %s", got)
+	}
+	if got := slots(ctl.PassthroughDrives); got != "[32:0(sdb) 32:3(sdc)]" {
+		t.Errorf("pass-through drives got: %s", got)
+	}
+
+	// SMART is read via ioctl which is not replayed
+	if len(r.Warnings) != 4 {
+		t.Errorf("warnings got: %d, expect: 4", len(r.Warnings))
+	}
+}
+
+func TestShapeMPTRAIDControllerReplay(t *testing.T) {
+	defer replay("testdata/replay/mpt")()
+
+	r := new(model.Report)
+	ctl := new(model.RAIDController)
+	ctl.Location.Bus = 0x61
+	ctl.LogDrives = []*model.LogDrive{
+		logDrive("sda", 0, "", "0x0ea1ba3e1c0c5b43"),
+		logDrive("sdb", 1, "", "0x4433221102000000"),
+		logDrive("sdc", 2, "", ""),
+	}
+
+	shapeMPTRAIDController(context.Background(), r, ctl)
+
+	if len(r.Errors) != 0 {
+		t.Fatalf("errors got: %+v", r.Errors)
+	}
+	if ctl.Firmware != "16.00.01.00" || ctl.BIOS != "8.37.00.00" || ctl.AdapterID != "0" {
+		t.Errorf("controller got: %s %s %s", ctl.Firmware, ctl.BIOS, ctl.AdapterID)
+	}
+
+	if len(ctl.LogDrives) != 1 {
+		t.Fatalf("log drives got: %d, expect: 1", len(ctl.LogDrives))
+	}
+	ld := ctl.LogDrives[0]
+	if ld.Name != "sda" || ld.RAIDLv != "RAID 1" || ld.GroupLabel != "vol:323" || ld.Degraded {
+		t.Errorf("log drive got: %s %s %s degraded:%t", ld.Name, ld.RAIDLv, ld.GroupLabel, ld.Degraded)
+	}
+	if got := slots(ld.PhyDrives); got != "[2:0() 2:1()]" {
+		t.Errorf("phy drives got: %s", got)
+	}
+	if got := slots(ctl.PassthroughDrives); got != "[2:2(sdb)]" {
+		t.Errorf("pass-through drives got: %s", got)
+	}
+}
+
+func TestShapeHPSARAIDControllerReplay(t *testing.T) {
+	defer replay("testdata/replay/hpsa")()
+
+	r := new(model.Report)
+	ctl := new(model.RAIDController)
+	ctl.Location.Bus = 4
+	ctl.LogDrives = []*model.LogDrive{
+		logDrive("sda", 0, "", ""),
+	}
+
+	shapeHPSARAIDController(context.Background(), r, ctl)
+
+	if len(r.Errors) != 0 {
+		t.Fatalf("errors got: %+v", r.Errors)
+	}
+	if ctl.ProductName != "Smart Array P420" || ctl.Firmware != "6.68" || ctl.SerialNumber != "PDSXK0ARH5O18K" {
+		t.Errorf("controller got: %s %s %s", ctl.ProductName, ctl.Firmware, ctl.SerialNumber)
+	}
+	if ctl.AdapterID != "2" || ctl.Battery != model.BatteryPresent {
+		t.Errorf("adapter got: %s, battery got: %s", ctl.AdapterID, ctl.Battery)
+	}
+
+	ld := ctl.LogDrives[0]
+	if ld.RAIDLv != "RAID 1+0" || ld.Status != "OK" || ld.Degraded {
+		t.Errorf("log drive got: %s %s degraded:%t", ld.RAIDLv, ld.Status, ld.Degraded)
+	}
+	if got := slots(ld.PhyDrives); got != "[1:1() 1:2() 1:3() 1:4()]" {
+		t.Errorf("phy drives got: %s", got)
+	}
+}
+
+func TestShapeRAIDControllerNotInstalled(t *testing.T) {
+	// no RAID utility is recorded there
+	defer replay("testdata/replay/bmc")()
+
+	tests := []struct {
+		shape   func(context.Context, *model.Report, *model.RAIDController)
+		decoder string
+	}{
+		{func(ctx context.Context, r *model.Report, ctl *model.RAIDController) {
+			shapeMegaRAIDController(ctx, r, ctl, 1)
+		}, "megacli"},
+		{shapeMPTRAIDController, "sas3ircu"},
+		{shapeHPSARAIDController, "hpacucli"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.decoder, func(t *testing.T) {
+			r := new(model.Report)
+			ctl := new(model.RAIDController)
+			tt.shape(context.Background(), r, ctl)
+
+			if len(r.Errors) != 1 || r.Errors[0].Decoder != tt.decoder {
+				t.Fatalf("errors got: %+v", r.Errors)
+			}
+		})
+	}
+}
+
+func TestShapeBMCReplay(t *testing.T) {
+	defer replay("testdata/replay/bmc")()
+
+	r := new(model.Report)
+	shapeBMC(context.Background(), r)
+
+	if len(r.Warnings) != 0 {
+		t.Fatalf("warnings got: %+v", r.Warnings)
+	}
+
+	ex := &model.BMC{
+		Type:     "IPMI",
+		Firmware: "1.17",
+		MAC:      "0c:42:a1:4d:91:c4",
+		IPAddr:   "10.23.253.139",
+		Netmask:  "255.0.0.0",
+		MaskSize: 8,
+		Gateway:  "10.23.253.1",
+	}
+	if r.BMC == nil || *r.BMC != *ex {
+		t.Errorf("got: %+v, expect: %+v", r.BMC, ex)
+	}
+}
@@ -1,6 +1,7 @@
-package main
+package mox
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -33,7 +34,7 @@ func matcher(f func(string) bool, t string, ts ...string) bool {
 	return false
 }
 
-func shapeDisk(r *model.Report, pcidevs *pci.Devices, cli *app) {
+func shapeDisk(ctx context.Context, r *model.Report, pcidevs *pci.Devices, noRAIDCLI bool, workers int) {
 	r.Storage = new(model.StorageReport)
 
 	var nvmeCtls []*model.NVMeController
@@ -42,6 +43,7 @@ func shapeDisk(r *model.Report, pcidevs *pci.Devices, cli *app) {
 	var virtCtls []*model.VirtController
 	var nstdCtls []*model.NonStdController
 
+	var nvmeSpecs []*model.PCIBaseSpec
 	for _, ctl := range pcidevs.FilterByClass(pci.MassStorageController) {
 		bspec := shapePCIDevice(ctl)
 
@@ -57,36 +59,54 @@ func shapeDisk(r *model.Report, pcidevs *pci.Devices, cli *app) {
 			}, t, ts...)
 		}
 
-		var err error
 		switch {
 		case equal("nvme"):
-			c, err := shapeNVMeController(bspec)
-			if err == nil && c != nil {
-				nvmeCtls = append(nvmeCtls, c)
-			}
+			nvmeSpecs = append(nvmeSpecs, bspec)
 		case equal("ahci", "ata_piix", "isci"):
-			c, err := shapeAHCIController(bspec)
+			c, err := shapeAHCIController(ctx, r, bspec, workers)
 			if err == nil && c != nil {
 				ahciCtls = append(ahciCtls, c)
+			} else if err != nil {
+				log.Warn(err.Error())
+				addError(r, pciIssue(storageComponent, "ahci", bspec, err))
 			}
 		case equal("virtio-pci"):
 			c, err := shapeVirtController(bspec)
 			if err == nil && c != nil {
 				virtCtls = append(virtCtls, c)
+			} else if err != nil {
+				log.Warn(err.Error())
+				addError(r, pciIssue(storageComponent, "virtio", bspec, err))
 			}
 		case prefix("mpt", "megaraid", "hpvsa", "hpsa"):
-			c, err := shapeRAIDController(bspec, cli.getBool("noraidcli"))
+			c, err := shapeRAIDController(ctx, r, bspec, noRAIDCLI, workers)
 			if err == nil && c != nil {
 				raidCtls = append(raidCtls, c)
+			} else if err != nil {
+				log.Warn(err.Error())
+				addError(r, pciIssue(raidComponent, "raid", bspec, err))
 			}
 		default:
 			log.Warnf("unsupported mass storage controller found: %s (driver: %s)", bspec.LongName(), bspec.Driver)
+			addWarning(r, pciIssue(storageComponent, "mox", bspec, fmt.Errorf("unsupported mass storage controller (driver: %s)", bspec.Driver)))
 		}
+	}
 
+	// NVMe controllers are read in parallel, every controller fills only its own entry
+	shaped := make([]*model.NVMeController, len(nvmeSpecs))
+	forEach(len(nvmeSpecs), workers, func(i int) {
+		c, err := shapeNVMeController(ctx, r, nvmeSpecs[i])
 		if err != nil {
 			log.Warn(err.Error())
+			addError(r, pciIssue(nvmeComponent, "nvme", nvmeSpecs[i], err))
+			return
+		}
+		shaped[i] = c
+	})
+	for _, c := range shaped {
+		if c != nil {
+			nvmeCtls = append(nvmeCtls, c)
 		}
-
 	}
 
 	if len(nvmeCtls) != 0 {
@@ -106,10 +126,10 @@ func shapeDisk(r *model.Report, pcidevs *pci.Devices, cli *app) {
 	}
 }
 
-func shapeAHCIController(bspec *model.PCIBaseSpec) (*model.AHCIController, error) {
+func shapeAHCIController(ctx context.Context, r *model.Report, bspec *model.PCIBaseSpec, workers int) (*model.AHCIController, error) {
 	var err error
 
-	ahcid := ahci.NewDecoder(bspec.Path)
+	ahcid := ahci.NewDecoder(util.RootPath(bspec.Path))
 	err = ahcid.Decode()
 	if err != nil {
 		return nil, err
@@ -129,11 +149,14 @@ func shapeAHCIController(bspec *model.PCIBaseSpec) (*model.AHCIController, error
 		drv.Scheduler = disk.Scheduler
 		drv.Driver = disk.Driver
 
-		shapeACSDrive(drv)
-
 		ctl.Drives = append(ctl.Drives, drv)
 	}
 
+	// SMART is read from each drive in parallel, every drive fills only its own entry
+	forEach(len(ctl.Drives), workers, func(i int) {
+		shapeACSDrive(ctx, r, ctl.Drives[i])
+	})
+
 	sort.Slice(ctl.Drives, func(i, j int) bool {
 		return util.BlkLabelAscSorter(ctl.Drives[i].Name, ctl.Drives[j].Name)
 	})
@@ -141,33 +164,46 @@ func shapeAHCIController(bspec *model.PCIBaseSpec) (*model.AHCIController, error
 	return ctl, nil
 }
 
-func shapeACSDrive(drv *model.Drive) {
+func shapeACSDrive(ctx context.Context, r *model.Report, drv *model.Drive) {
 	acsd := acs.NewDecoder("/dev/" + drv.Name)
-	err := acsd.Decode()
+	err := acsd.DecodeContext(ctx)
 	if err != nil {
 		log.Warnf("spc-acs: %s", err)
+		addError(r, issue(sataComponent, "spc-acs", "/dev/"+drv.Name, err))
 		return
 	}
 
-	drv.CurTemp = acsd.CurTemp
-	drv.MaxTemp = acsd.MaxTemp
-	drv.MinTemp = acsd.MinTemp
 	drv.FormFactor = acsd.FormFactor
 	drv.Rotation = acsd.Rotation
 	drv.Firmware = acsd.FirmwareRevision
 	drv.SerialNumber = acsd.SerialNumber
 	drv.Model = acsd.ModelNumber
 	drv.Transport = acsd.Transport
-	drv.ByteRead = uint64(acsd.TotalLBARead) * uint64(drv.LogBlockSize)
-	drv.ByteWritten = uint64(acsd.TotalLBAWritten) * uint64(drv.LogBlockSize)
 	drv.NegSpeed = acsd.NegSpeed
 	drv.SigSpeed = acsd.SigSpeed
+	drv.SelfTest = acsd.SelfTestSupport
+	drv.ErrorLogging = acsd.ErrorLoggingSupport
+
+	shapeACSCounters(drv, acsd)
+}
+
+// shapeACSCounters copies the counters of a SATA drive, which Refresh reads again
+func shapeACSCounters(drv *model.Drive, acsd *spc.Device) {
+	drv.CurTemp = acsd.CurTemp
+	drv.MaxTemp = acsd.MaxTemp
+	drv.MinTemp = acsd.MinTemp
+	drv.ByteRead = uint64(acsd.TotalLBARead) * uint64(drv.LogBlockSize)
+	drv.ByteWritten = uint64(acsd.TotalLBAWritten) * uint64(drv.LogBlockSize)
 	drv.PowerCycleCount = acsd.PowerCycleCount
 	drv.PowerOnHours = acsd.PowerOnHours
 	drv.UnsafeShutdownCount = acsd.UnsafeShutdownCount
-	drv.SelfTest = acsd.SelfTestSupport
-	drv.ErrorLogging = acsd.ErrorLoggingSupport
+	drv.ReallocatedSectors = acsd.ReallocatedSectors
+	drv.PendingSectors = acsd.PendingSectors
+	if drv.IsSSD() {
+		shapeWear(&drv.StorageWearSpec, acsd)
+	}
 
+	drv.ErrorRecords = nil
 	for _, rec := range acsd.ErrorRecords {
 		e := new(model.SMARTRecord)
 		e.ID = rec.ID
@@ -180,10 +216,24 @@ func shapeACSDrive(drv *model.Drive) {
 	}
 }
 
-func shapeRAIDController(bspec *model.PCIBaseSpec, noRaidCli bool) (*model.RAIDController, error) {
+// shapeWear copies the wear attributes a SATA drive reports
+func shapeWear(w *model.StorageWearSpec, d *spc.Device) {
+	if d.WearSupport {
+		w.Used = byteRef(d.Used)
+	}
+	if d.SpareSpaceSupport {
+		w.SpareSpace = byteRef(d.SpareSpace)
+	}
+}
+
+func byteRef(v byte) *byte {
+	return &v
+}
+
+func shapeRAIDController(ctx context.Context, r *model.Report, bspec *model.PCIBaseSpec, noRaidCli bool, workers int) (*model.RAIDController, error) {
 	var err error
 
-	raidd := raid.NewDecoder(bspec.Path)
+	raidd := raid.NewDecoder(util.RootPath(bspec.Path))
 	err = raidd.Decode()
 	if err != nil {
 		return nil, err
@@ -222,30 +272,33 @@ func shapeRAIDController(bspec *model.PCIBaseSpec, noRaidCli bool) (*model.RAIDC
 			return strings.HasPrefix(ctl.Driver, l)
 		}, t, ts...)
 	}
-	if !noRaidCli {
-		switch {
-		case prefix("megaraid"):
-			shapeMegaRAIDController(ctl)
-		case prefix("mpt"):
-			shapeMPTRAIDController(ctl)
-		case prefix("hpvsa", "hpsa"):
-			shapeHPSARAIDController(ctl)
-		}
+	switch {
+	case noRaidCli:
+	case !util.Privileged():
+		addError(r, pciIssue(raidComponent, "raidcli", &ctl.PCIBaseSpec, util.ErrPrivileged))
+	case prefix("megaraid"):
+		shapeMegaRAIDController(ctx, r, ctl, workers)
+	case prefix("mpt"):
+		shapeMPTRAIDController(ctx, r, ctl)
+	case prefix("hpvsa", "hpsa"):
+		shapeHPSARAIDController(ctx, r, ctl)
 	}
 	return ctl, nil
 }
 
-func shapeMegaRAIDController(ctl *model.RAIDController) {
+func shapeMegaRAIDController(ctx context.Context, r *model.Report, ctl *model.RAIDController, workers int) {
 	var err error
 
 	if !megacli.Available() {
 		log.Info("megacli is not installed")
+		addError(r, pciIssue(raidComponent, "megacli", &ctl.PCIBaseSpec, fmt.Errorf("megacli is not installed")))
 		return
 	}
 
-	mctls, err := megacli.GetControllers()
+	mctls, err := megacli.GetControllersWithContext(ctx)
 	if err != nil {
 		log.Debugf("do not parse megaraid due to %s", err)
+		addError(r, pciIssue(raidComponent, "megacli", &ctl.PCIBaseSpec, err))
 		return
 	}
 
@@ -259,12 +312,14 @@ func shapeMegaRAIDController(ctl *model.RAIDController) {
 	}
 	if mctl == nil {
 		log.Debug("the controller not found in megacli")
+		addError(r, pciIssue(raidComponent, "megacli", &ctl.PCIBaseSpec, fmt.Errorf("the controller not found in megacli")))
 		return
 	}
 
-	err = mctl.Decode()
+	err = mctl.DecodeContext(ctx)
 	if err != nil {
 		log.Debugf("failed to decode megacli due to %s", err)
+		addError(r, pciIssue(raidComponent, "megacli", &ctl.PCIBaseSpec, err))
 		return
 	}
 
@@ -280,6 +335,16 @@ func shapeMegaRAIDController(ctl *model.RAIDController) {
 	ctl.SerialNumber = mctl.SerialNumber
 	ctl.AdapterID = fmt.Sprintf("%d", mctl.Number)
 
+	// SMART is read after the drives are shaped, from each drive in parallel
+	var pds []*megacli.PhyDrive
+	var ps []*model.PhyDrive
+	phyDisk := func(pd *megacli.PhyDrive) *model.PhyDrive {
+		p := shapeMegaRAIDPhyDisk(pd)
+		pds = append(pds, pd)
+		ps = append(ps, p)
+		return p
+	}
+
 	ldrvGroupMap := make(map[uint]bool)
 	log.Debug("scanning log drives")
 
@@ -297,7 +362,7 @@ func shapeMegaRAIDController(ctl *model.RAIDController) {
 			log.Debug("scanning wwn")
 			ptpd := mctl.GetPTPhyDriveByWWN(ldrv.WWN)
 			if ptpd != nil {
-				p := shapeMegaRAIDPhyDisk(ptpd, mctl)
+				p := phyDisk(ptpd)
 				p.Name = ldrv.Name
 				p.Scheduler = ldrv.Scheduler
 				p.Driver = ldrv.Driver
@@ -339,7 +404,7 @@ func shapeMegaRAIDController(ctl *model.RAIDController) {
 		}
 
 		for _, pd := range ld.PhyDrives {
-			p := shapeMegaRAIDPhyDisk(pd, mctl)
+			p := phyDisk(pd)
 			log.Debugf("found phy drive: %s", p.Model)
 			ldrv.PhyDrives = append(ldrv.PhyDrives, p)
 		}
@@ -347,7 +412,7 @@ func shapeMegaRAIDController(ctl *model.RAIDController) {
 	}
 
 	for _, pd := range mctl.UnconfDrives {
-		p := shapeMegaRAIDPhyDisk(pd, mctl)
+		p := phyDisk(pd)
 		log.Debugf("found unconfigured phy drive: %s", p.Model)
 		ctl.UnconfDrives = append(ctl.UnconfDrives, p)
 	}
@@ -361,13 +426,17 @@ func shapeMegaRAIDController(ctl *model.RAIDController) {
 		ldrv.Degraded = true
 
 		for _, pd := range ld.PhyDrives {
-			p := shapeMegaRAIDPhyDisk(pd, mctl)
+			p := phyDisk(pd)
 			log.Debugf("found phy drive: %s", p.Model)
 			ldrv.PhyDrives = append(ldrv.PhyDrives, p)
 		}
 
 		ctl.LogDrives = append(ctl.LogDrives, ldrv)
 	}
+
+	forEach(len(ps), workers, func(i int) {
+		shapeMegaRAIDSMART(ctx, r, ps[i], pds[i], mctl, &ctl.PCIBaseSpec)
+	})
 }
 
 func shapeMegaRAIDLogDrive(ld *megacli.LogDrive) *model.LogDrive {
@@ -381,7 +450,7 @@ func shapeMegaRAIDLogDrive(ld *megacli.LogDrive) *model.LogDrive {
 	return l
 }
 
-func shapeMegaRAIDPhyDisk(pd *megacli.PhyDrive, ctl *megacli.Controller) *model.PhyDrive {
+func shapeMegaRAIDPhyDisk(pd *megacli.PhyDrive) *model.PhyDrive {
 	p := new(model.PhyDrive)
 	p.Enclosure = pd.EnclosureID
 	p.Slot = pd.SlotNumber
@@ -393,12 +462,21 @@ func shapeMegaRAIDPhyDisk(pd *megacli.PhyDrive, ctl *megacli.Controller) *model.
 	p.Transport = pd.Type
 	p.SolidStateDrive = pd.SolidStateDrive
 	p.ErrorCount = pd.MediaErrorCount
+	return p
+}
 
+// shapeMegaRAIDSMART fills p with SMART read from the drive behind the controller
+func shapeMegaRAIDSMART(ctx context.Context, r *model.Report, p *model.PhyDrive, pd *megacli.PhyDrive, ctl *megacli.Controller, bspec *model.PCIBaseSpec) {
 	d := megaraid.NewDecoder(ctl.Number, int(pd.DeviceID), spc.CastDiskType(pd.Type))
-	err := d.Decode()
+	if d == nil {
+		return
+	}
+
+	err := d.DecodeContext(ctx)
 	if err != nil {
 		log.Debugf("failed to decode spc/megaraid due to %s", err)
-		return p
+		addWarning(r, pciIssue(raidComponent, "spc-megaraid", bspec, fmt.Errorf("adapter %d, device %d: %s", ctl.Number, pd.DeviceID, err)))
+		return
 	}
 
 	blockSize := uint64(pd.LogBlockSize)
@@ -415,6 +493,11 @@ func shapeMegaRAIDPhyDisk(pd *megacli.PhyDrive, ctl *megacli.Controller) *model.
 	p.PowerCycleCount = d.PowerCycleCount
 	p.PowerOnHours = d.PowerOnHours
 	p.UnsafeShutdownCount = d.UnsafeShutdownCount
+	p.ReallocatedSectors = d.ReallocatedSectors
+	p.PendingSectors = d.PendingSectors
+	if p.SolidStateDrive {
+		shapeWear(&p.StorageWearSpec, d)
+	}
 
 	for _, rec := range d.ErrorRecords {
 		e := new(model.SMARTRecord)
@@ -426,21 +509,21 @@ func shapeMegaRAIDPhyDisk(pd *megacli.PhyDrive, ctl *megacli.Controller) *model.
 		e.Name = rec.Name
 		p.ErrorRecords = append(p.ErrorRecords, e)
 	}
-
-	return p
 }
 
-func shapeMPTRAIDController(ctl *model.RAIDController) {
+func shapeMPTRAIDController(ctx context.Context, r *model.Report, ctl *model.RAIDController) {
 	var err error
 
 	if !sas3ircu.Available() {
 		log.Info("sas3ircu is not installed")
+		addError(r, pciIssue(raidComponent, "sas3ircu", &ctl.PCIBaseSpec, fmt.Errorf("sas3ircu is not installed")))
 		return
 	}
 
-	sctls, err := sas3ircu.GetControllers()
+	sctls, err := sas3ircu.GetControllersWithContext(ctx)
 	if err != nil {
 		log.Debugf("do not parse sas3ircu due to %s", err)
+		addError(r, pciIssue(raidComponent, "sas3ircu", &ctl.PCIBaseSpec, err))
 		return
 	}
 
@@ -454,12 +537,14 @@ func shapeMPTRAIDController(ctl *model.RAIDController) {
 	}
 	if sctl == nil {
 		log.Debug("the controller not found in sas3ircu")
+		addError(r, pciIssue(raidComponent, "sas3ircu", &ctl.PCIBaseSpec, fmt.Errorf("the controller not found in sas3ircu")))
 		return
 	}
 
-	err = sctl.Decode()
+	err = sctl.DecodeContext(ctx)
 	if err != nil {
 		log.Debugf("failed to decode sas3ircu due to %s", err)
+		addError(r, pciIssue(raidComponent, "sas3ircu", &ctl.PCIBaseSpec, err))
 		return
 	}
 
@@ -545,17 +630,19 @@ func shapeMPTRAIDPhyDisk(pd *sas3ircu.PhyDrive) *model.PhyDrive {
 	return p
 }
 
-func shapeHPSARAIDController(ctl *model.RAIDController) {
+func shapeHPSARAIDController(ctx context.Context, r *model.Report, ctl *model.RAIDController) {
 	var err error
 
 	if !hpacucli.Available() {
 		log.Info("hpssacli is not installed")
+		addError(r, pciIssue(raidComponent, "hpacucli", &ctl.PCIBaseSpec, fmt.Errorf("hpssacli is not installed")))
 		return
 	}
 
-	hctls, err := hpacucli.GetControllers()
+	hctls, err := hpacucli.GetControllersWithContext(ctx)
 	if err != nil {
 		log.Debugf("do not parse hpacucli due to %s", err)
+		addError(r, pciIssue(raidComponent, "hpacucli", &ctl.PCIBaseSpec, err))
 		return
 	}
 
@@ -563,9 +650,10 @@ func shapeHPSARAIDController(ctl *model.RAIDController) {
 
 	var hctl *hpacucli.Controller
 	for _, h := range hctls {
-		err := h.Decode()
+		err := h.DecodeContext(ctx)
 		if err != nil {
 			log.Debugf("failed to decode hpacucli due to %s", err)
+			addError(r, pciIssue(raidComponent, "hpacucli", &ctl.PCIBaseSpec, err))
 			return
 		}
 
@@ -577,6 +665,7 @@ func shapeHPSARAIDController(ctl *model.RAIDController) {
 
 	if hctl == nil {
 		log.Debug("the controller not found in hpacucli")
+		addError(r, pciIssue(raidComponent, "hpacucli", &ctl.PCIBaseSpec, fmt.Errorf("the controller not found in hpacucli")))
 		return
 	}
 
@@ -641,10 +730,10 @@ func shapeHPSARAIDPhyDisk(pd *hpacucli.PhyDrive) *model.PhyDrive {
 	return p
 }
 
-func shapeNVMeController(bspec *model.PCIBaseSpec) (*model.NVMeController, error) {
+func shapeNVMeController(ctx context.Context, r *model.Report, bspec *model.PCIBaseSpec) (*model.NVMeController, error) {
 	var err error
 
-	nvmed := nvme.NewDecoder(bspec.Path)
+	nvmed := nvme.NewDecoder(util.RootPath(bspec.Path))
 	err = nvmed.Decode()
 	if err != nil {
 		return nil, err
@@ -655,27 +744,29 @@ func shapeNVMeController(bspec *model.PCIBaseSpec) (*model.NVMeController, error
 	ctl.PCIBaseSpec = *bspec
 
 	admd := nvmeadm.NewDecoder("/dev/" + ctl.Name)
-	err = admd.Decode()
-	if err == nil {
-		ctl.CurTemp = admd.CurTemp
+	err = admd.DecodeContext(ctx)
+	if err != nil {
+		log.Debugf("nvmeadm: %s", err)
+		addError(r, pciIssue(nvmeComponent, "nvmeadm", bspec, err))
+		admd = nil
+	} else {
 		ctl.WarnTemp = admd.WarnTemp
 		ctl.CritTemp = admd.CritTemp
 		ctl.SerialNumber = admd.SerialNumber
 		ctl.Model = admd.ModelNumber
 		ctl.Firmware = admd.FirmwareRevision
-		ctl.ByteRead = admd.ByteRead
-		ctl.ByteWritten = admd.ByteWritten
 		ctl.Size = admd.Size
-		ctl.PowerCycleCount = admd.PowerCycleCount
-		ctl.PowerOnHours = admd.PowerOnHours
-		ctl.UnsafeShutdownCount = admd.UnsafeShutdownCount
+		shapeNVMeSmart(ctl, admd)
 	}
 
 	for _, n := range nvmed.Namespaces {
 		ns := new(model.Namespace)
 		ns.Name = n.Name
 
-		sz := admd.GetNamespaceSize(n.ID())
+		var sz uint64
+		if admd != nil {
+			sz = admd.GetNamespaceSize(n.ID())
+		}
 		if sz > 0 {
 			ns.Size = sz
 		} else {
@@ -696,10 +787,22 @@ func shapeNVMeController(bspec *model.PCIBaseSpec) (*model.NVMeController, error
 	return ctl, nil
 }
 
+// shapeNVMeSmart copies the SMART log of a NVMe controller, which Refresh reads again
+func shapeNVMeSmart(ctl *model.NVMeController, admd *nvmeadm.Device) {
+	ctl.CurTemp = admd.CurTemp
+	ctl.ByteRead = admd.ByteRead
+	ctl.ByteWritten = admd.ByteWritten
+	ctl.PowerCycleCount = admd.PowerCycleCount
+	ctl.PowerOnHours = admd.PowerOnHours
+	ctl.UnsafeShutdownCount = admd.UnsafeShutdownCount
+	ctl.Used = byteRef(admd.Used)
+	ctl.SpareSpace = byteRef(admd.SpareSpace)
+}
+
 func shapeVirtController(bspec *model.PCIBaseSpec) (*model.VirtController, error) {
 	var err error
 
-	virtd := virtio.NewDecoder(bspec.Path)
+	virtd := virtio.NewDecoder(util.RootPath(bspec.Path))
 	err = virtd.Decode()
 	if err != nil {
 		return nil, err
@@ -1,4 +1,4 @@
-package main
+package mox
 
 import (
 	"github.com/moxspec/moxspec/model"
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/moxspec/moxspec/capture"
 	"github.com/moxspec/moxspec/loglet"
 	"github.com/moxspec/moxspec/util"
 )
@@ -41,6 +42,12 @@ func NewDecoder(id uint16, v vendor) *Register {
 
 // Decode makes Register satisfy the mox.Decoder interface
 func (r *Register) Decode() error {
+	if util.Captured() {
+		return util.ErrLiveOnly
+	}
+	if !util.Privileged() {
+		return util.ErrPrivileged
+	}
 	path := fmt.Sprintf("/dev/cpu/%d/msr", r.ID)
 	fd, err := os.OpenFile(path, os.O_RDONLY, os.ModeDevice)
 	if err != nil {
@@ -51,6 +58,7 @@ func (r *Register) Decode() error {
 	rdr := func(ptr int64) uint64 {
 		d := make([]byte, 8, 8)
 		fd.ReadAt(d, ptr)
+		capture.MSR(r.ID, ptr, d)
 		ret := util.BytesToUint64(d)
 		log.Debugf("msr addr: 0x%x => got: %v (%d)", ptr, d, ret)
 		return ret
@@ -2,6 +2,7 @@ package netlink
 
 import (
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
 )
 
 var log *loglet.Logger
@@ -47,6 +48,10 @@ type RtnlLinkStats64 struct {
 
 // Decode make Interface satisfy the mox.Decoder interface
 func (intf *Interface) Decode() error {
+	if util.Captured() {
+		return intf.decodeSysfs()
+	}
+
 	nli, err := newNetlinkInterface()
 	if err != nil {
 		return err
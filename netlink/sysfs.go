@@ -0,0 +1,48 @@
+package netlink
+
+import (
+	"path/filepath"
+
+	"github.com/moxspec/moxspec/util"
+)
+
+// decodeSysfs reads the stats from sysfs since netlink is not available in a captured root
+func (intf *Interface) decodeSysfs() error {
+	dir := util.RootPath(filepath.Join("/sys/class/net", intf.Name, "statistics"))
+	if !util.Exists(dir) {
+		return util.ErrLiveOnly
+	}
+
+	load := func(name string) uint64 {
+		v, _ := util.LoadUint64(filepath.Join(dir, name))
+		return v
+	}
+
+	s := &intf.Stats
+	s.RxPackets = load("rx_packets")
+	s.TxPackets = load("tx_packets")
+	s.RxBytes = load("rx_bytes")
+	s.TxBytes = load("tx_bytes")
+	s.RxErrors = load("rx_errors")
+	s.TxErrors = load("tx_errors")
+	s.RxDropped = load("rx_dropped")
+	s.TxDropped = load("tx_dropped")
+	s.Multicast = load("multicast")
+	s.Collisions = load("collisions")
+	s.RxLengthErrors = load("rx_length_errors")
+	s.RxOverErrors = load("rx_over_errors")
+	s.RxCrcErrors = load("rx_crc_errors")
+	s.RxFrameErrors = load("rx_frame_errors")
+	s.RxFifoErrors = load("rx_fifo_errors")
+	s.RxMissedErrors = load("rx_missed_errors")
+	s.TxAbortedErrors = load("tx_aborted_errors")
+	s.TxCarrierErrors = load("tx_carrier_errors")
+	s.TxFifoErrors = load("tx_fifo_errors")
+	s.TxHeartbeatErrors = load("tx_heartbeat_errors")
+	s.TxWindowErrors = load("tx_window_errors")
+	s.RxCompressed = load("rx_compressed")
+	s.TxCompressed = load("tx_compressed")
+	s.RxNohandler = load("rx_nohandler")
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"syscall"
 	"unsafe"
+
+	"github.com/moxspec/moxspec/capture"
 )
 
 const (
@@ -27,3 +29,8 @@ func post(fd *os.File, cmdptr *nvmeAdminCmd) error {
 	}
 	return nil
 }
+
+// record captures the data returned by an admin command
+func record(fd *os.File, cmd *nvmeAdminCmd, data []byte) {
+	capture.Ioctl(fd.Name(), fmt.Sprintf("admin-%02x-%08x-%08x", cmd.opcode, cmd.nsid, cmd.cdw10), data)
+}
@@ -30,6 +30,7 @@ func identifyController(fd *os.File, d *Device) error {
 		return err
 	}
 	log.Debugf("result: %+v", data)
+	record(fd, &cmd, data)
 
 	d.SerialNumber = util.SanitizeString(fmt.Sprintf("%s", data[4:24]))
 	d.ModelNumber = util.SanitizeString(fmt.Sprintf("%s", data[24:64]))
@@ -76,6 +77,7 @@ func identifyNamespaceSize(fd *os.File, id uint32) (uint64, error) {
 		return 0, err
 	}
 	log.Debugf("result: %+v", data)
+	record(fd, &cmd, data)
 
 	return parseNamespaceSize(data)
 }
@@ -1,12 +1,19 @@
 package nvmeadm
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
 )
 
+const ioctlDefaultTimeout = 30 * time.Second
+
 var log *loglet.Logger
 
 func init() {
@@ -27,6 +34,84 @@ func NewDecoder(p string) *Device {
 
 // Decode makes Device satisfy the mox.Decoder interface
 func (d *Device) Decode() error {
+	if util.Captured() {
+		return util.ErrLiveOnly
+	}
+	if !util.Privileged() {
+		return util.ErrPrivileged
+	}
+	return d.open()
+}
+
+// DecodeContext makes Device satisfy the mox.ContextDecoder interface
+// an ioctl can not be interrupted, so DecodeContext returns without waiting for it when the context is done
+// or after ioctlDefaultTimeout if the context has no deadline
+func (d *Device) DecodeContext(ctx context.Context) error {
+	return d.run(ctx, decode)
+}
+
+// DecodeSmartContext reads only the SMART log of a device decoded before
+// the identity (e.g. model, serial number, capacity) is left as it is
+func (d *Device) DecodeSmartContext(ctx context.Context) error {
+	return d.run(ctx, readSmartLog)
+}
+
+// busy holds the devices whose ioctl has been abandoned and is still running
+// a device is not read again until it returns, so that a hung device does not pile up goroutines
+var (
+	busyMu sync.Mutex
+	busy   = make(map[string]bool)
+)
+
+func (d *Device) run(ctx context.Context, decode func(*os.File, *Device) error) error {
+	var err error
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ioctlDefaultTimeout)
+		defer cancel()
+	}
+
+	if util.Captured() {
+		return util.ErrLiveOnly
+	}
+	if !util.Privileged() {
+		return util.ErrPrivileged
+	}
+
+	busyMu.Lock()
+	if busy[d.path] {
+		busyMu.Unlock()
+		return fmt.Errorf("%s: the previous ioctl has not returned yet", d.path)
+	}
+	busy[d.path] = true
+	busyMu.Unlock()
+
+	done := make(chan bool, 1)
+	go func() {
+		err = d.openWith(decode)
+
+		busyMu.Lock()
+		delete(busy, d.path)
+		busyMu.Unlock()
+
+		done <- true
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %s", d.path, ctx.Err())
+	case <-done:
+	}
+
+	return err
+}
+
+func (d *Device) open() error {
+	return d.openWith(decode)
+}
+
+func (d *Device) openWith(decode func(*os.File, *Device) error) error {
 	var err error
 	fd, err := os.OpenFile(d.path, os.O_RDONLY, os.ModeDevice)
 	if err != nil {
@@ -30,6 +30,7 @@ func readSmartLog(fd *os.File, d *Device) error {
 		return err
 	}
 	log.Debugf("result: %+v", data)
+	record(fd, &cmd, data)
 
 	d.CurTemp = int16(binary.LittleEndian.Uint16(data[1:3]))
 	if d.CurTemp > 0 {
@@ -77,6 +77,33 @@ func (c *Controller) Decode() error {
 	p := new(Port)
 	p.Name = ifname
 
+	// ip addresses are not in sysfs, so they are missing in a captured root
+	if util.Captured() {
+		p.MTU, _ = util.LoadUint32(filepath.Join(c.netDir, p.Name, "mtu"))
+	} else {
+		err := p.decodeInterface()
+		if err != nil {
+			return err
+		}
+	}
+
+	p.Path = filepath.Join(c.netDir, p.Name)
+	p.HWAddr, _ = util.LoadString(filepath.Join(p.Path, "address"))
+	p.Speed, _ = util.LoadUint32(filepath.Join(p.Path, "speed"))
+	p.State, _ = util.LoadString(filepath.Join(p.Path, "operstate"))
+
+	cr, _ := util.LoadByte(filepath.Join(p.Path, "carrier"))
+	if cr == 1 {
+		p.Carrier = true
+	}
+
+	c.Port = *p
+
+	return nil
+}
+
+// decodeInterface reads the mtu and ip addresses from the live system
+func (p *Port) decodeInterface() error {
 	intf, err := net.InterfaceByName(p.Name)
 	if err != nil {
 		return err
@@ -120,18 +147,6 @@ func (c *Controller) Decode() error {
 		p.IPAddrs = append(p.IPAddrs, i)
 	}
 
-	p.Path = filepath.Join(c.netDir, p.Name)
-	p.HWAddr, _ = util.LoadString(filepath.Join(p.Path, "address"))
-	p.Speed, _ = util.LoadUint32(filepath.Join(p.Path, "speed"))
-	p.State, _ = util.LoadString(filepath.Join(p.Path, "operstate"))
-
-	cr, _ := util.LoadByte(filepath.Join(p.Path, "carrier"))
-	if cr == 1 {
-		p.Carrier = true
-	}
-
-	c.Port = *p
-
 	return nil
 }
 
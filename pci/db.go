@@ -3,7 +3,6 @@ package pci
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
 	"strings"
 
 	"github.com/moxspec/moxspec/util"
@@ -52,25 +51,39 @@ var (
 	cdb classDB
 )
 
+// initDB loads names from pci.ids, the databases are left empty on an error so that devices are decoded without names
 func initDB(path string) error {
-	pciids, _ := ioutil.ReadFile(path)
+	vdb = make(vendorDB)
+	cdb = make(classDB)
+
+	if path == "" {
+		return fmt.Errorf("no pci.ids found")
+	}
+	pciids, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// kept in a bundle, so that it is decoded with the same names
+	util.CaptureFile(path, pciids)
 
 	// first half = vendor, device, subsystem
 	// second half = class, sub_class, programming if
 	blocks := strings.Split(string(pciids), "List of known device classes, subclasses and programming interfaces")
 	if len(blocks) != 2 {
-		fmt.Println("bad pci.ids format")
-		os.Exit(1)
+		return fmt.Errorf("%s: bad pci.ids format", path)
 	}
 
-	var err error
-	vdb, err = genVendorDB(blocks[0])
+	v, err := genVendorDB(blocks[0])
+	if err != nil {
+		return err
+	}
+	c, err := genClassDB(blocks[1])
 	if err != nil {
 		return err
 	}
 
-	cdb, err = genClassDB(blocks[1])
-	return err
+	vdb, cdb = v, c
+	return nil
 }
 
 func genVendorDB(lines string) (map[string]string, error) {
@@ -88,6 +101,9 @@ func genVendorDB(lines string) (map[string]string, error) {
 
 		indent := strings.Count(l, "\t")
 		flds := strings.Fields(l)
+		if len(flds) < 2 || (indent == 2 && len(flds) < 3) {
+			continue
+		}
 
 		if indent == 0 {
 			id, _ := parseHexStr(flds[0])
@@ -129,6 +145,10 @@ func genClassDB(lines string) (map[string]string, error) {
 
 		indent := strings.Count(l, "\t")
 		flds := strings.Fields(l)
+		// a class line is "C <id> <name>"
+		if len(flds) < 2 || (indent == 0 && len(flds) < 3) {
+			continue
+		}
 
 		if indent == 0 {
 			id, _ := parseHexStr(flds[1])
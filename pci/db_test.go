@@ -0,0 +1,65 @@
+package pci
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		in     string
+		err    bool
+		vendor string
+		class  string
+	}{
+		{
+			"8086  Intel Corporation\n\t1572  Ethernet Controller X710\n\t\t8086 0001  X710-DA4\n" +
+				"# List of known device classes, subclasses and programming interfaces\nC 02  Network controller\n\t00  Ethernet controller\n",
+			false, "Intel", "Network controller",
+		},
+		// short lines are skipped
+		{
+			"8086\n\t1572\n\t\t8086 0001\n" +
+				"# List of known device classes, subclasses and programming interfaces\nC 02\nC\n\t00\n",
+			false, "", "",
+		},
+		{"8086  Intel Corporation\n", true, "", ""},
+		{"", true, "", ""},
+	}
+
+	for i, test := range tests {
+		tt := test
+
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("pci.ids.%d", i))
+			err := ioutil.WriteFile(path, []byte(tt.in), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = initDB(path)
+			if (err != nil) != tt.err {
+				t.Errorf("test: %+v, got: %v, expect error: %v", tt, err, tt.err)
+			}
+			if got := vdb.vendorName(0x8086); got != tt.vendor {
+				t.Errorf("test: %+v, got: %s, expect: %s", tt, got, tt.vendor)
+			}
+			if got := cdb.className(0x02); got != tt.class {
+				t.Errorf("test: %+v, got: %s, expect: %s", tt, got, tt.class)
+			}
+		})
+	}
+
+	if err := initDB(filepath.Join(dir, "none")); err == nil {
+		t.Errorf("got: nil, expect an error for a missing pci.ids")
+	}
+}
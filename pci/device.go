@@ -2,7 +2,6 @@ package pci
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -44,12 +43,9 @@ func (d *Device) Decode() error {
 	log.Debugf("ven: %s dev: %s subsys: %s class: %s", d.VendorName, d.DeviceName, d.SubSystemName, d.ClassName)
 
 	d.Numa, _ = util.LoadUint16(filepath.Join(d.Path, "numa_node"))
-	drvDir := filepath.Join(d.Path, "driver")
-	if util.Exists(drvDir) {
-		linkPath, err := os.Readlink(drvDir)
-		if err == nil {
-			d.Driver = filepath.Base(linkPath)
-		}
+	linkPath, err := util.Readlink(filepath.Join(d.Path, "driver"))
+	if err == nil {
+		d.Driver = filepath.Base(linkPath)
 	}
 
 	return nil
@@ -31,7 +31,16 @@ func NewDecoder() *Devices {
 func (devs *Devices) Decode() error {
 	var err error
 	var pciids string
-	for _, possible := range pciidsPossible {
+	// a captured root may carry its own pci.ids, the one on this system is used otherwise
+	var possibles []string
+	if util.Captured() {
+		for _, possible := range util.PathList(util.PCIIDsPath, pciidsPossible) {
+			possibles = append(possibles, util.RootPath(possible))
+		}
+	}
+	possibles = append(possibles, util.PathList(util.PCIIDsPath, pciidsPossible)...)
+
+	for _, possible := range possibles {
 		if util.Exists(possible) {
 			pciids = possible
 			log.Debugf("found pciids: %s", pciids)
@@ -40,11 +49,13 @@ func (devs *Devices) Decode() error {
 	}
 	err = initDB(pciids)
 	if err != nil {
-		return err
+		// devices are still worth decoding without names
+		log.Warn(err.Error())
+		devs.dbErr = err
 	}
 
 	// TODO: accessing via /sys/bus is DEPRECATED, to be fixed to use /sys/class/pci_bus
-	syspath := "/sys/bus/pci/devices"
+	syspath := util.RootPath("/sys/bus/pci/devices")
 	dirs, err := ioutil.ReadDir(syspath)
 	if err != nil {
 		return err
@@ -52,7 +63,7 @@ func (devs *Devices) Decode() error {
 
 	var oldDB bool
 	for _, d := range dirs {
-		bpath, err := filepath.EvalSymlinks(filepath.Join(syspath, d.Name()))
+		bpath, err := util.EvalSymlinks(filepath.Join(syspath, d.Name()))
 		if err != nil {
 			log.Warnf("could not read %s (%s)", bpath, err)
 		}
@@ -8,10 +8,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/moxspec/moxspec/util"
 )
 
 const (
 	pcieConfigSpaceSize = 4096
+	pciHeaderSize       = 64 // sysfs gives only the header to users other than root
 	pcieDeviceIDBitSize = 16
 	pcieVendorIDBitSize = 16
 )
@@ -25,13 +28,19 @@ func NewConfig(p string) *Config {
 	}
 	defer fd.Close()
 
+	size := int64(pcieConfigSpaceSize)
+	if !util.Privileged() {
+		size = pciHeaderSize
+	}
+
 	c := new(Config)
 	c.path = p
-	c.br, err = ioutil.ReadAll(io.LimitReader(fd, pcieConfigSpaceSize))
+	c.br, err = ioutil.ReadAll(io.LimitReader(fd, size))
 	if err != nil {
 		log.Warnf("could not read %s. %s", p, err)
 		return nil
 	}
+	util.CaptureFile(p, c.br)
 
 	return c
 }
@@ -79,6 +88,14 @@ func parseConfig(dev *Device, path string) error {
 		return nil
 	}
 
+	if conf == nil || len(conf.br) <= pciHeaderSize {
+		// capabilities follow the header, the link is read from sysfs instead
+		log.Debugf("could not read capabilities of %s", dev.Path)
+		dev.HeaderOnly = true
+		parseSysfsLink(dev)
+		return nil
+	}
+
 	var err error
 	err = parseBasicCapabilities(dev, conf)
 	if err != nil {
@@ -98,6 +115,45 @@ func parseConfig(dev *Device, path string) error {
 	return nil
 }
 
+// parseSysfsLink reads the link speed and width of a PCIe device, they are given without privileges
+func parseSysfsLink(dev *Device) {
+	speed, err := util.LoadString(filepath.Join(dev.Path, "current_link_speed"))
+	if err != nil {
+		return // not PCIe
+	}
+	dev.Express = true
+	dev.LinkGen, dev.LinkSpeed = parseSysfsLinkSpeed(speed)
+	width, _ := util.LoadUint16(filepath.Join(dev.Path, "current_link_width"))
+	dev.LinkWidth = byte(width)
+
+	speed, _ = util.LoadString(filepath.Join(dev.Path, "max_link_speed"))
+	dev.MaxGen, dev.MaxSpeed = parseSysfsLinkSpeed(speed)
+	width, _ = util.LoadUint16(filepath.Join(dev.Path, "max_link_width"))
+	dev.MaxWidth = byte(width)
+
+	log.Debugf("gen: %d / speed: %.1f / width: %d (sysfs)", dev.LinkGen, dev.LinkSpeed, dev.LinkWidth)
+}
+
+// parseSysfsLinkSpeed parses a link speed such as "8.0 GT/s PCIe"
+func parseSysfsLinkSpeed(in string) (gen byte, speed float32) {
+	flds := strings.Fields(in)
+	if len(flds) == 0 {
+		return
+	}
+
+	s, err := strconv.ParseFloat(flds[0], 32)
+	if err != nil {
+		return // e.g. "Unknown"
+	}
+
+	for g, sp := range linkSpeeds {
+		if sp == float32(s) {
+			return byte(g), sp
+		}
+	}
+	return 0, float32(s)
+}
+
 func readSysfsValue(path string, object string, bitSize int) (uint64, error) {
 	p := filepath.Join(path, object)
 
@@ -238,18 +294,20 @@ func parseSlotPowerLimit(reg uint32) float32 {
 	return val
 }
 
+// linkSpeeds are the speeds in GT/s indexed by the generation
+var linkSpeeds = []float32{
+	0.0,  // 0: unknown
+	2.5,  // 1: Gen 1
+	5.0,  // 2: Gen 2
+	8.0,  // 3: Gen 3
+	16.0, // 4: Gen 4
+	32.0, // 4: Gen 5
+}
+
 func parseLinkSpec(reg uint32) (gen byte, speed float32, width byte) {
 	gen = byte(reg & 0x1F)
-	speeds := []float32{
-		0.0,  // 0: unknown
-		2.5,  // 1: Gen 1
-		5.0,  // 2: Gen 2
-		8.0,  // 3: Gen 3
-		16.0, // 4: Gen 4
-		32.0, // 4: Gen 5
-	}
-	if gen < byte(len(speeds)) {
-		speed = speeds[gen]
+	if gen < byte(len(linkSpeeds)) {
+		speed = linkSpeeds[gen]
 	}
 
 	w := (reg >> 4) & 0x3F
@@ -79,3 +79,46 @@ func TestReadSysfsValue(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSysfsLinkSpeed(t *testing.T) {
+	tests := []struct {
+		in    string
+		gen   byte
+		speed float32
+	}{
+		{"2.5 GT/s PCIe", 1, 2.5},
+		{"8.0 GT/s PCIe", 3, 8.0},
+		{"16.0 GT/s", 4, 16.0},
+		{"32.0 GT/s PCIe", 5, 32.0},
+		{"64.0 GT/s PCIe", 0, 64.0},
+		{"Unknown", 0, 0},
+		{"", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			gen, speed := parseSysfsLinkSpeed(tt.in)
+			if gen != tt.gen || speed != tt.speed {
+				t.Errorf("got: gen=%d speed=%.1f, expect: gen=%d speed=%.1f", gen, speed, tt.gen, tt.speed)
+			}
+		})
+	}
+}
+
+func TestParseConfigHeaderOnly(t *testing.T) {
+	dev := NewDevice("testdata/header_only")
+	err := parseConfig(dev, "testdata/header_only/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dev.VendorID != 0x8086 || dev.DeviceID != 0x1572 || dev.ClassID != 0x02 || dev.SubSystemDeviceID != 0x0006 {
+		t.Errorf("got: ven=%04x dev=%04x class=%02x subdev=%04x", dev.VendorID, dev.DeviceID, dev.ClassID, dev.SubSystemDeviceID)
+	}
+	if !dev.HeaderOnly || len(dev.BasicCaps) != 0 {
+		t.Errorf("got: header only=%t caps=%d, expect: header only without caps", dev.HeaderOnly, len(dev.BasicCaps))
+	}
+	if !dev.Express || dev.LinkGen != 3 || dev.LinkWidth != 4 || dev.MaxGen != 3 || dev.MaxWidth != 8 {
+		t.Errorf("got: express=%t link=gen%d x%d max=gen%d x%d", dev.Express, dev.LinkGen, dev.LinkWidth, dev.MaxGen, dev.MaxWidth)
+	}
+}
@@ -39,6 +39,7 @@ type Device struct {
 	UncorrectableErrs []string
 	CorrectableErrs   []string
 	Express           bool // indicates PCIe
+	HeaderOnly        bool // capabilities could not be read, they require privileges
 	BasicCaps         []*BasicCap
 	ExtCaps           []*ExtCap
 }
@@ -109,6 +110,12 @@ type ExtCap struct {
 type Devices struct {
 	all     []*Device
 	classes map[byte][]*Device
+	dbErr   error
+}
+
+// DBError returns the error which left devices without names, or nil if pci.ids was loaded
+func (db *Devices) DBError() error {
+	return db.dbErr
 }
 
 func (db *Devices) append(dev *Device) {
@@ -136,3 +143,14 @@ func (db Devices) FilterByClass(cids ...byte) []*Device {
 func (db Devices) AllDevices() []*Device {
 	return db.all
 }
+
+// HeaderOnlyCount returns the number of devices whose capabilities could not be read
+func (db Devices) HeaderOnlyCount() int {
+	var n int
+	for _, d := range db.all {
+		if d.HeaderOnly {
+			n++
+		}
+	}
+	return n
+}
@@ -1,10 +1,10 @@
 package platform
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"strings"
 
 	"github.com/moxspec/moxspec/util"
@@ -22,15 +22,20 @@ func GetDistroName() string {
 
 	// http://0pointer.de/blog/projects/os-release
 	log.Debug("parsing /etc/os-release")
-	fd, err := os.Open("/etc/os-release")
+	bs, err := util.LoadBytes(util.RootPath("/etc/os-release"))
 	if err == nil {
-		name = parseOSRelease(fd)
+		name = parseOSRelease(bytes.NewReader(bs))
 		if name != "" {
 			return name
 		}
 	}
 
-	rel, err := util.ScanPathList(releases)
+	var paths []string
+	for _, rel := range releases {
+		paths = append(paths, util.RootPath(rel))
+	}
+
+	rel, err := util.ScanPathList(paths)
 	if err != nil {
 		return ""
 	}
@@ -8,7 +8,7 @@ import (
 
 // IsLoadedModule returns if a module is loaded
 func IsLoadedModule(name string) bool {
-	list, err := util.LoadString("/proc/modules")
+	list, err := util.LoadString(util.RootPath("/proc/modules"))
 	if err != nil {
 		return false
 	}
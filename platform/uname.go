@@ -8,7 +8,16 @@ import (
 )
 
 // Uname returns system name, release, nodename and machine
+// they are read from procfs in a captured root
 func Uname() (sysname, release, nodename, machine string) {
+	if util.Captured() {
+		load := func(name string) string {
+			s, _ := util.LoadString(util.RootPath("/proc/sys/kernel/" + name))
+			return util.SanitizeString(s)
+		}
+		return load("ostype"), load("osrelease"), load("hostname"), load("arch")
+	}
+
 	uts := syscall.Utsname{}
 	syscall.Uname(&uts)
 
@@ -0,0 +1,387 @@
+// Package push delivers reports to an HTTP collector
+//
+// A body is POSTed as gzip-compressed JSON along with these headers:
+//
+//	Content-Type: application/json
+//	Content-Encoding: gzip
+//	X-Mox-Kind: report or diag
+//	X-Mox-Host: the system serial number, or the hostname
+//
+// A failed push is retried with backoff, and kept in the spool directory if the collector is still down.
+// Spooled bodies are sent before the next push, the oldest first.
+package push
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moxspec/moxspec/loglet"
+)
+
+var log *loglet.Logger
+
+func init() {
+	log = loglet.NewLogger("push")
+}
+
+// These are kinds of bodies
+const (
+	ReportKind = "report"
+	DiagKind   = "diag"
+)
+
+// These are defaults of Options
+const (
+	DefaultRetries  = 3
+	DefaultBackoff  = time.Second
+	DefaultTimeout  = 30 * time.Second
+	DefaultSpoolDir = "/var/lib/mox/spool"
+	DefaultMaxSpool = 100
+)
+
+// maxBackoff limits the wait between retries
+const maxBackoff = time.Minute
+
+// Options represents options for a Pusher
+type Options struct {
+	// URL is where bodies are POSTed
+	URL string
+	// Headers are added to every request
+	Headers map[string]string
+	// TokenFile has a token sent as "Authorization: Bearer <token>", it is read on every push to follow rotations
+	TokenFile string
+	// CACert verifies the collector instead of the system roots
+	CACert string
+	// Cert and Key are the client certificate for mutual TLS
+	Cert string
+	Key  string
+	// Retries is the number of retries after the first attempt, negative means no retries, zero means the default
+	Retries int
+	// Backoff is the first wait between retries, it doubles on every retry, zero means the default
+	Backoff time.Duration
+	// Timeout limits each request, zero means the default
+	Timeout time.Duration
+	// SpoolDir keeps bodies which could not be pushed, empty means no spool
+	SpoolDir string
+	// MaxSpool limits the number of spooled bodies, the oldest are dropped, zero means the default
+	MaxSpool int
+}
+
+// Pusher pushes bodies to a collector
+type Pusher struct {
+	opts   Options
+	client *http.Client
+}
+
+// New creates and initializes a Pusher
+func New(opts Options) (*Pusher, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("no url")
+	}
+	if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+		return nil, fmt.Errorf("invalid url: %s (http or https is needed)", opts.URL)
+	}
+	if opts.Retries == 0 {
+		opts.Retries = DefaultRetries
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxSpool <= 0 {
+		opts.MaxSpool = DefaultMaxSpool
+	}
+
+	tc, err := tlsConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Pusher)
+	p.opts = opts
+	p.client = &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tc},
+	}
+	return p, nil
+}
+
+func tlsConfig(opts Options) (*tls.Config, error) {
+	tc := new(tls.Config)
+
+	if opts.CACert != "" {
+		pem, err := ioutil.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates", opts.CACert)
+		}
+		tc.RootCAs = pool
+	}
+
+	if (opts.Cert == "") != (opts.Key == "") {
+		return nil, fmt.Errorf("both a client certificate and its key are needed")
+	}
+	if opts.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, err
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return tc, nil
+}
+
+// Push sends v as JSON after the spooled bodies, v is spooled if it could not be sent
+// host identifies the system to the collector
+func (p *Pusher) Push(ctx context.Context, kind, host string, v interface{}) error {
+	body, err := encode(v)
+	if err != nil {
+		return err
+	}
+
+	err = p.Flush(ctx)
+	if err != nil {
+		log.Debugf("spool is kept: %s", err)
+		return p.spool(kind, host, body, err)
+	}
+
+	err = p.send(ctx, kind, host, body)
+	if err != nil {
+		return p.spool(kind, host, body, err)
+	}
+	return nil
+}
+
+// Flush sends the spooled bodies, the oldest first, and stops at the first failure
+func (p *Pusher) Flush(ctx context.Context) error {
+	sps, err := p.spooled()
+	if err != nil {
+		return err
+	}
+
+	for _, sp := range sps {
+		body, err := ioutil.ReadFile(sp.path)
+		if err != nil {
+			return err
+		}
+
+		err = p.send(ctx, sp.kind, sp.host, body)
+		if permanent(err) {
+			// the collector will never take it
+			log.Warnf("dropping %s: %s", sp.path, err)
+		} else if err != nil {
+			return err
+		}
+
+		err = os.Remove(sp.path)
+		if err != nil {
+			return err
+		}
+		log.Debugf("flushed %s", sp.path)
+	}
+	return nil
+}
+
+func encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+
+	err := json.NewEncoder(zw).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// StatusError is returned when the collector responds with a status other than 2xx
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("collector responded %s", e.Status)
+}
+
+// permanent returns true if a retry would fail in the same way
+func permanent(err error) bool {
+	se, ok := err.(*StatusError)
+	if !ok {
+		return false
+	}
+	switch se.Code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return se.Code >= 400 && se.Code < 500
+}
+
+// send posts the body, it is retried with backoff unless the failure is permanent
+func (p *Pusher) send(ctx context.Context, kind, host string, body []byte) error {
+	wait := p.opts.Backoff
+
+	var err error
+	for i := 0; ; i++ {
+		err = p.post(ctx, kind, host, body)
+		if err == nil || permanent(err) || i >= p.opts.Retries {
+			return err
+		}
+
+		log.Debugf("retrying in %s: %s", wait, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		wait *= 2
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+	}
+}
+
+func (p *Pusher) post(ctx context.Context, kind, host string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, p.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for name, v := range p.opts.Headers {
+		req.Header.Set(name, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("X-Mox-Kind", kind)
+	if host != "" {
+		req.Header.Set("X-Mox-Host", host)
+	}
+
+	if p.opts.TokenFile != "" {
+		tok, err := ioutil.ReadFile(p.opts.TokenFile)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(tok)))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &StatusError{Code: res.StatusCode, Status: res.Status}
+	}
+	return nil
+}
+
+// spooled represents a body kept in the spool directory
+// the file is named <unix nano>_<kind>_<host>.json.gz, the host is escaped since a serial number may be e.g. N/A
+type spooled struct {
+	path string
+	kind string
+	host string
+}
+
+// spool keeps the body which could not be sent, cause is returned as it is if it is kept
+func (p *Pusher) spool(kind, host string, body []byte, cause error) error {
+	if p.opts.SpoolDir == "" || permanent(cause) {
+		return cause
+	}
+
+	err := os.MkdirAll(p.opts.SpoolDir, 0700)
+	if err != nil {
+		return fmt.Errorf("%s, and could not be spooled: %s", cause, err)
+	}
+
+	name := fmt.Sprintf("%d_%s_%s.json.gz", time.Now().UnixNano(), kind, url.PathEscape(host))
+	path := filepath.Join(p.opts.SpoolDir, name)
+	err = ioutil.WriteFile(path, body, 0600)
+	if err != nil {
+		return fmt.Errorf("%s, and could not be spooled: %s", cause, err)
+	}
+	log.Debugf("spooled %s", path)
+
+	sps, err := p.spooled()
+	if err == nil && len(sps) > p.opts.MaxSpool {
+		for _, sp := range sps[:len(sps)-p.opts.MaxSpool] {
+			log.Warnf("dropping %s, the spool is full", sp.path)
+			os.Remove(sp.path)
+		}
+	}
+
+	return &SpooledError{Path: path, Err: cause}
+}
+
+// SpooledError is returned when a body could not be pushed but is kept in the spool
+type SpooledError struct {
+	Path string
+	Err  error
+}
+
+func (e *SpooledError) Error() string {
+	return fmt.Sprintf("%s, spooled to %s", e.Err, e.Path)
+}
+
+func (p *Pusher) spooled() ([]*spooled, error) {
+	if p.opts.SpoolDir == "" {
+		return nil, nil
+	}
+
+	fis, err := ioutil.ReadDir(p.opts.SpoolDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sps []*spooled
+	for _, fi := range fis {
+		name := fi.Name()
+		if !fi.Mode().IsRegular() || !strings.HasSuffix(name, ".json.gz") {
+			continue
+		}
+		flds := strings.SplitN(strings.TrimSuffix(name, ".json.gz"), "_", 3)
+		if len(flds) != 3 {
+			continue
+		}
+		host, err := url.PathUnescape(flds[2])
+		if err != nil {
+			continue
+		}
+		sps = append(sps, &spooled{path: filepath.Join(p.opts.SpoolDir, name), kind: flds[1], host: host})
+	}
+
+	// names start with the time they were spooled
+	sort.Slice(sps, func(i, j int) bool { return sps[i].path < sps[j].path })
+	return sps, nil
+}
@@ -0,0 +1,360 @@
+package push
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector records bodies and responds with the given statuses in order, 200 after them
+type collector struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   []map[string]string
+	headers  []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.statuses) > 0 {
+		code := c.statuses[0]
+		c.statuses = c.statuses[1:]
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+	}
+
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var body map[string]string
+	err = json.NewDecoder(zr).Decode(&body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.bodies = append(c.bodies, body)
+	c.headers = append(c.headers, r.Header)
+}
+
+func (c *collector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.bodies)
+}
+
+func TestPush(t *testing.T) {
+	dir, err := ioutil.TempDir("", "push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	token := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(token, []byte("secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	col := new(collector)
+	srv := httptest.NewServer(col)
+	defer srv.Close()
+
+	p, err := New(Options{
+		URL:       srv.URL,
+		Headers:   map[string]string{"X-Site": "tokyo"},
+		TokenFile: token,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Push(context.Background(), ReportKind, "SN0001", map[string]string{"hostname": "host1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if col.count() != 1 {
+		t.Fatalf("want 1 body, got %d", col.count())
+	}
+	if col.bodies[0]["hostname"] != "host1" {
+		t.Errorf("want host1, got %+v", col.bodies[0])
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Content-Type", "application/json"},
+		{"Content-Encoding", "gzip"},
+		{"X-Mox-Kind", ReportKind},
+		{"X-Mox-Host", "SN0001"},
+		{"X-Site", "tokyo"},
+		{"Authorization", "Bearer secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			got := col.headers[0].Get(tt.name)
+			if got != tt.want {
+				t.Errorf("want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPushRetry(t *testing.T) {
+	tests := []struct {
+		statuses []int
+		retries  int
+		wantErr  bool
+		wantSent int
+	}{
+		{[]int{http.StatusServiceUnavailable}, 0, false, 1},
+		{[]int{http.StatusTooManyRequests, http.StatusBadGateway}, 2, false, 1},
+		{[]int{http.StatusBadGateway, http.StatusBadGateway}, 1, true, 0},
+		{[]int{http.StatusBadGateway}, -1, true, 0},
+		{[]int{http.StatusUnauthorized}, 3, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			col := &collector{statuses: tt.statuses}
+			srv := httptest.NewServer(col)
+			defer srv.Close()
+
+			p, err := New(Options{URL: srv.URL, Retries: tt.retries, Backoff: time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.Push(context.Background(), DiagKind, "", map[string]string{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error %t, got %v", tt.wantErr, err)
+			}
+			if col.count() != tt.wantSent {
+				t.Errorf("want %d bodies, got %d", tt.wantSent, col.count())
+			}
+		})
+	}
+}
+
+func TestSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	col := &collector{statuses: []int{
+		http.StatusServiceUnavailable, // host1
+		http.StatusServiceUnavailable, // host1 from the spool, then N/A_1 is spooled
+	}}
+	srv := httptest.NewServer(col)
+	defer srv.Close()
+
+	p, err := New(Options{URL: srv.URL, Retries: -1, SpoolDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a placeholder serial number must not be taken as a directory
+	for _, host := range []string{"host1", "N/A_1"} {
+		err = p.Push(context.Background(), ReportKind, host, map[string]string{"hostname": host})
+		if _, ok := err.(*SpooledError); !ok {
+			t.Fatalf("want a SpooledError, got %v", err)
+		}
+	}
+
+	sps, err := p.spooled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sps) != 2 {
+		t.Fatalf("want 2 spooled bodies, got %d", len(sps))
+	}
+
+	err = p.Push(context.Background(), ReportKind, "host3", map[string]string{"hostname": "host3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if col.count() != 3 {
+		t.Fatalf("want 3 bodies, got %d", col.count())
+	}
+	for i, want := range []string{"host1", "N/A_1", "host3"} {
+		if col.bodies[i]["hostname"] != want {
+			t.Errorf("want %s, got %+v", want, col.bodies[i])
+		}
+		if col.headers[i].Get("X-Mox-Host") != want {
+			t.Errorf("want %s, got %s", want, col.headers[i].Get("X-Mox-Host"))
+		}
+	}
+
+	sps, err = p.spooled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sps) != 0 {
+		t.Errorf("want an empty spool, got %d", len(sps))
+	}
+}
+
+func TestSpoolLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// nothing listens on it
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	p, err := New(Options{URL: url, Retries: -1, SpoolDir: dir, MaxSpool: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		p.Push(context.Background(), ReportKind, fmt.Sprintf("host%d", i), map[string]string{})
+	}
+
+	sps, err := p.spooled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sps) != 2 {
+		t.Fatalf("want 2 spooled bodies, got %d", len(sps))
+	}
+	if sps[0].host != "host1" || sps[1].host != "host2" {
+		t.Errorf("want host1 and host2, got %s and %s", sps[0].host, sps[1].host)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPEM, keyPEM, err := newClientCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := filepath.Join(dir, "client.crt")
+	key := filepath.Join(dir, "client.key")
+	ca := filepath.Join(dir, "ca.crt")
+	ioutil.WriteFile(cert, certPEM, 0600)
+	ioutil.WriteFile(key, keyPEM, 0600)
+
+	clients := x509.NewCertPool()
+	clients.AppendCertsFromPEM(certPEM)
+
+	col := new(collector)
+	srv := httptest.NewUnstartedServer(col)
+	srv.TLS = &tls.Config{ClientCAs: clients, ClientAuth: tls.RequireAndVerifyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)
+
+	tests := []struct {
+		opts    Options
+		wantErr bool
+	}{
+		{Options{URL: srv.URL, Retries: -1, CACert: ca, Cert: cert, Key: key}, false},
+		{Options{URL: srv.URL, Retries: -1, CACert: ca}, true},
+		{Options{URL: srv.URL, Retries: -1, Cert: cert, Key: key}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			p, err := New(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Push(context.Background(), ReportKind, "", map[string]string{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		opts    Options
+		wantErr bool
+	}{
+		{Options{URL: "http://collector/mox"}, false},
+		{Options{URL: ""}, true},
+		{Options{URL: "ftp://collector/mox"}, true},
+		{Options{URL: "https://collector/mox", Cert: "client.crt"}, true},
+		{Options{URL: "https://collector/mox", CACert: "/nonexistent"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			_, err := New(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func newClientCert() ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mox"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kder, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder}), nil
+}
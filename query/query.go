@@ -0,0 +1,366 @@
+// Package query extracts values from a report by a path of json field names
+//
+//	bmc.ipaddr
+//	storage.nvmeControllers[*].serialNumber
+//	storage.nvmeControllers[0].serialNumber
+//	network.ethControllers[*].interfaces[name=eth0].hwaddr
+//	storage.raidControllers[*].logDrives[raidLv!=RAID1].name
+//
+// A path is checked against the schema of model.Report, so a misspelled field is an error even if the report lacks it.
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/schema"
+)
+
+type selectorKind int
+
+const (
+	allSelector selectorKind = iota
+	indexSelector
+	filterSelector
+)
+
+// selector picks elements of an array, [*], [1] and [field=value] or [field!=value]
+type selector struct {
+	kind   selectorKind
+	index  int
+	field  []string
+	value  string
+	negate bool
+}
+
+type step struct {
+	name      string
+	selectors []selector
+}
+
+// Path represents a parsed path
+type Path struct {
+	raw   string
+	steps []step
+}
+
+// Parse parses a path and checks it against the schema of the report
+func Parse(s string) (*Path, error) {
+	p := new(Path)
+	p.raw = s
+
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	for {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		st := step{name: rest[:end]}
+		if st.name == "" {
+			return nil, fmt.Errorf("%s: empty field name", s)
+		}
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			rb := strings.Index(rest, "]")
+			if rb < 0 {
+				return nil, fmt.Errorf("%s: missing ]", s)
+			}
+			sel, err := parseSelector(rest[1:rb])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", s, err)
+			}
+			st.selectors = append(st.selectors, sel)
+			rest = rest[rb+1:]
+		}
+		p.steps = append(p.steps, st)
+
+		if rest == "" {
+			break
+		}
+		if !strings.HasPrefix(rest, ".") {
+			return nil, fmt.Errorf("%s: unexpected %q", s, rest)
+		}
+		rest = rest[1:]
+	}
+
+	err := p.check()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseSelector(s string) (selector, error) {
+	if s == "*" {
+		return selector{kind: allSelector}, nil
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return selector{kind: indexSelector, index: i}, nil
+	}
+
+	// the operator is the first one after the key, the value may have = or != in it
+	sel := selector{kind: filterSelector}
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return sel, fmt.Errorf("invalid selector: [%s]", s)
+	}
+	key, value := s[:i], s[i+1:]
+	if strings.HasSuffix(key, "!") {
+		key = strings.TrimSuffix(key, "!")
+		sel.negate = true
+	}
+	if strings.TrimSpace(key) == "" {
+		return sel, fmt.Errorf("invalid selector: [%s]", s)
+	}
+	sel.field = strings.Split(strings.TrimSpace(key), ".")
+	sel.value = strings.Trim(strings.TrimSpace(value), `"'`)
+	return sel, nil
+}
+
+// Root returns the top-level field of the path
+func (p *Path) Root() string {
+	return p.steps[0].name
+}
+
+// Multi returns whether the path may match more than one value
+func (p *Path) Multi() bool {
+	for _, st := range p.steps {
+		for _, sel := range st.selectors {
+			if sel.kind != indexSelector {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Path) String() string {
+	return p.raw
+}
+
+// Get returns values at the path in the report, numbers are json.Number and objects are map[string]interface{}
+func (p *Path) Get(r *model.Report) ([]interface{}, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err = dec.Decode(&root)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := []interface{}{root}
+	for _, st := range p.steps {
+		var next []interface{}
+		for _, v := range vals {
+			if c, ok := child(v, st.name); ok {
+				next = append(next, c)
+			}
+		}
+
+		for _, sel := range st.selectors {
+			var picked []interface{}
+			for _, v := range next {
+				picked = append(picked, sel.pick(v)...)
+			}
+			next = picked
+		}
+		vals = next
+	}
+
+	return vals, nil
+}
+
+func child(v interface{}, name string) (interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	c, ok := m[name]
+	return c, ok && c != nil
+}
+
+// pick returns elements of an array (or values of an object for [*]) selected
+func (sel selector) pick(v interface{}) []interface{} {
+	if m, ok := v.(map[string]interface{}); ok && sel.kind == allSelector {
+		var keys []string
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var vals []interface{}
+		for _, k := range keys {
+			vals = append(vals, m[k])
+		}
+		return vals
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	switch sel.kind {
+	case allSelector:
+		return list
+	case indexSelector:
+		i := sel.index
+		if i < 0 {
+			i += len(list)
+		}
+		if i < 0 || i >= len(list) {
+			return nil
+		}
+		return []interface{}{list[i]}
+	}
+
+	var vals []interface{}
+	for _, e := range list {
+		f := e
+		for _, name := range sel.field {
+			f, _ = child(f, name)
+		}
+		if (Format(f) == sel.value) != sel.negate {
+			vals = append(vals, e)
+		}
+	}
+	return vals
+}
+
+// Format returns a scalar as it is and an object or an array as json
+func Format(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// check checks fields in the path against the schema of the report
+func (p *Path) check() error {
+	s := schema.Generate()
+	defs := node(s["$defs"])
+
+	cur := resolve(s, defs)
+	var walked []string
+	for _, st := range p.steps {
+		next, err := field(cur, st.name, defs)
+		if err != nil {
+			return fmt.Errorf("%s: %s", pathString(walked), err)
+		}
+		walked = append(walked, st.name)
+		cur = next
+
+		for _, sel := range st.selectors {
+			elem, ok := element(cur, sel, defs)
+			if !ok {
+				return fmt.Errorf("%s: not an array", pathString(walked))
+			}
+			if sel.kind == filterSelector {
+				f := elem
+				for _, name := range sel.field {
+					f, err = field(f, name, defs)
+					if err != nil {
+						return fmt.Errorf("%s: %s", pathString(walked), err)
+					}
+				}
+			}
+			cur = elem
+		}
+	}
+	return nil
+}
+
+func pathString(walked []string) string {
+	if len(walked) == 0 {
+		return "report"
+	}
+	return strings.Join(walked, ".")
+}
+
+func node(v interface{}) schema.Node {
+	switch n := v.(type) {
+	case schema.Node:
+		return n
+	case map[string]interface{}:
+		return n
+	}
+	return nil
+}
+
+// resolve follows a reference and takes the non-null alternative
+func resolve(n schema.Node, defs schema.Node) schema.Node {
+	for {
+		if ref, ok := n["$ref"].(string); ok {
+			n = node(defs[strings.TrimPrefix(ref, "#/$defs/")])
+			continue
+		}
+		if alts, ok := n["anyOf"].([]schema.Node); ok && len(alts) > 0 {
+			n = alts[0]
+			continue
+		}
+		return n
+	}
+}
+
+func field(n schema.Node, name string, defs schema.Node) (schema.Node, error) {
+	if props := node(n["properties"]); props != nil {
+		if f, ok := props[name]; ok {
+			return resolve(node(f), defs), nil
+		}
+
+		var names []string
+		for k := range props {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown field %s (available: %s)", name, strings.Join(names, ", "))
+	}
+	if ap := node(n["additionalProperties"]); ap != nil {
+		// a key of a map
+		return resolve(ap, defs), nil
+	}
+	if len(n) == 0 {
+		// any json, e.g. data of a plugin
+		return n, nil
+	}
+	return nil, fmt.Errorf("%s is not a field of an object", name)
+}
+
+func element(n schema.Node, sel selector, defs schema.Node) (schema.Node, bool) {
+	if items := node(n["items"]); items != nil {
+		return resolve(items, defs), true
+	}
+	if ap := node(n["additionalProperties"]); ap != nil && sel.kind == allSelector {
+		return resolve(ap, defs), true
+	}
+	if len(n) == 0 {
+		return n, true
+	}
+	return nil, false
+}
@@ -0,0 +1,153 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func testReport() *model.Report {
+	nvme := func(sn, m string, size uint64) *model.NVMeController {
+		n := new(model.NVMeController)
+		n.SerialNumber = sn
+		n.Model = m
+		n.Size = size
+		return n
+	}
+
+	return &model.Report{
+		Hostname: "web01",
+		BMC:      &model.BMC{IPAddr: "192.0.2.10", MaskSize: 24},
+		Storage: &model.StorageReport{
+			NVMeControllers: []*model.NVMeController{
+				nvme("S1", "PM1725b", 18446744073709551615),
+				nvme("S2", "PM1725b", 1),
+				nvme("S3", "P4510", 1),
+			},
+		},
+		Network: &model.NetworkReport{
+			EthControllers: []*model.EthController{
+				{Interfaces: []*model.NetInterface{{Name: "eth0", HWAddr: "00:11:22:33:44:55"}, {Name: "eth1", HWAddr: "00:11:22:33:44:56"}}},
+			},
+		},
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		path  string
+		want  []string
+		multi bool
+	}{
+		{"hostname", []string{"web01"}, false},
+		{"bmc.ipaddr", []string{"192.0.2.10"}, false},
+		{"bmc.masksize", []string{"24"}, false},
+		{"bmc.gateway", nil, false},
+		{"storage.nvmeControllers[*].serialNumber", []string{"S1", "S2", "S3"}, true},
+		{"storage.nvmeControllers[0].serialNumber", []string{"S1"}, false},
+		{"storage.nvmeControllers[-1].serialNumber", []string{"S3"}, false},
+		{"storage.nvmeControllers[5].serialNumber", nil, false},
+		{"storage.nvmeControllers[model=PM1725b].serialNumber", []string{"S1", "S2"}, true},
+		{"storage.nvmeControllers[model!=PM1725b].serialNumber", []string{"S3"}, true},
+		{"storage.nvmeControllers[0].size", []string{"18446744073709551615"}, false},
+		{"storage.nvmeControllers[0].location", []string{`{"bus":0,"device":0,"domain":0,"function":0}`}, false},
+		{"network.ethControllers[*].interfaces[name=eth1].hwaddr", []string{"00:11:22:33:44:56"}, true},
+		{"accelerator.gpus[*].serialNumber", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := Parse(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vals, err := p.Get(testReport())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, v := range vals {
+				got = append(got, Format(v))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+			if p.Multi() != tt.multi {
+				t.Errorf("multi got: %t, want: %t", p.Multi(), tt.multi)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path string
+		root string
+		err  bool
+	}{
+		{"bmc.ipaddr", "bmc", false},
+		{"storage.nvmeControllers[*].currentLink.speed", "storage", false},
+		{"storage.nvmeControllers[location.bus=59].name", "storage", false},
+		{"sar.eth0[*].values", "sar", false},
+		{"", "", true},
+		{"bmc.ip", "", true},
+		{"bmc..ipaddr", "", true},
+		{"storage.nvmeControllers[*", "", true},
+		{"storage.nvmeControllers[*]serialNumber", "", true},
+		{"storage.nvmeControllers[serial=S1].name", "", true},
+		{"bmc[*]", "", true},
+		{"hostname.name", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			p, err := Parse(tt.path)
+			if (err != nil) != tt.err {
+				t.Fatalf("got: %v, expect error: %t", err, tt.err)
+			}
+			if err == nil && p.Root() != tt.root {
+				t.Errorf("got: %s, want: %s", p.Root(), tt.root)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		in     string
+		field  []string
+		value  string
+		negate bool
+		err    bool
+	}{
+		{"model=PM1725b", []string{"model"}, "PM1725b", false, false},
+		{"model!=PM1725b", []string{"model"}, "PM1725b", true, false},
+		{"location.bus=59", []string{"location", "bus"}, "59", false, false},
+		{"model='PM1725b'", []string{"model"}, "PM1725b", false, false},
+		// the operator is the first one after the key
+		{"model=A!=B", []string{"model"}, "A!=B", false, false},
+		{"model!=A=B", []string{"model"}, "A=B", true, false},
+		{"model=a=b", []string{"model"}, "a=b", false, false},
+		{"model", nil, "", false, true},
+		{"=A", nil, "", false, true},
+		{"!=A", nil, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			sel, err := parseSelector(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("got: %v, expect error: %t", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(sel.field, tt.field) || sel.value != tt.value || sel.negate != tt.negate {
+				t.Errorf("got: field=%v value=%s negate=%t, want: field=%v value=%s negate=%t", sel.field, sel.value, sel.negate, tt.field, tt.value, tt.negate)
+			}
+		})
+	}
+}
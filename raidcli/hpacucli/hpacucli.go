@@ -1,6 +1,7 @@
 package hpacucli
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/moxspec/moxspec/loglet"
@@ -12,26 +13,32 @@ var pathList = []string{
 	"/usr/sbin/hpssacli",
 }
 
-var clipath string
-
 var log *loglet.Logger
 
 func init() {
 	log = loglet.NewLogger("hpacucli")
-	clipath, _ = util.ScanPathList(pathList)
 }
 
 // Available returns whether raid command is available
 func Available() bool {
-	if clipath == "" {
-		return false
-	}
-	return true
+	return cliPath() != ""
+}
+
+// cliPath returns the path of the command
+// it is looked up on every call since the runner or the path may be replaced
+func cliPath() string {
+	p, _ := util.LookPathList(util.PathList(util.HPACUCLIPath, pathList))
+	return p
 }
 
 // GetControllers retruns hpacucli controllers
 func GetControllers() ([]*Controller, error) {
-	res, err := raidcli.Run(clipath, "controller", "all", "show")
+	return GetControllersWithContext(context.Background())
+}
+
+// GetControllersWithContext retruns hpacucli controllers, the command is killed when the context is done
+func GetControllersWithContext(ctx context.Context) ([]*Controller, error) {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "controller", "all", "show")
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +47,12 @@ func GetControllers() ([]*Controller, error) {
 
 // Decode makes Adapter satisfy the mox.Decoder interface
 func (c *Controller) Decode() error {
-	res, err := raidcli.Run(clipath, "controller", fmt.Sprintf("slot=%s", c.Slot), "show", "config", "detail")
+	return c.DecodeContext(context.Background())
+}
+
+// DecodeContext makes Adapter satisfy the mox.ContextDecoder interface
+func (c *Controller) DecodeContext(ctx context.Context) error {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "controller", fmt.Sprintf("slot=%s", c.Slot), "show", "config", "detail")
 	if err != nil {
 		return err
 	}
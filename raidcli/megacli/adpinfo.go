@@ -1,14 +1,15 @@
 package megacli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/moxspec/moxspec/raidcli"
 )
 
-func setAdpInfo(c *Controller) error {
-	res, err := raidcli.Run(clipath, "-AdpAllInfo", fmt.Sprintf("-a%d", c.Number), "-NoLog")
+func setAdpInfo(ctx context.Context, c *Controller) error {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "-AdpAllInfo", fmt.Sprintf("-a%d", c.Number), "-NoLog")
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package megacli
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,8 +17,8 @@ var lvmap = map[string]raidcli.Level{
 	"Primary-1, Secondary-3, RAID Level Qualifier-0": raidcli.RAID10,
 }
 
-func getLDList(num int) ([]*LogDrive, error) {
-	res, err := raidcli.Run(clipath, "-LDPDInfo", fmt.Sprintf("-a%d", num), "-NoLog")
+func getLDList(ctx context.Context, num int) ([]*LogDrive, error) {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "-LDPDInfo", fmt.Sprintf("-a%d", num), "-NoLog")
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package megacli
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/moxspec/moxspec/loglet"
@@ -12,26 +13,32 @@ var pathList = []string{
 	"/opt/MegaRAID/MegaCli/MegaCli64",
 }
 
-var clipath string
-
 var log *loglet.Logger
 
 func init() {
 	log = loglet.NewLogger("megacli")
-	clipath, _ = util.ScanPathList(pathList)
 }
 
 // Available returns whether raid command is available
 func Available() bool {
-	if clipath == "" {
-		return false
-	}
-	return true
+	return cliPath() != ""
+}
+
+// cliPath returns the path of the command
+// it is looked up on every call since the runner or the path may be replaced
+func cliPath() string {
+	p, _ := util.LookPathList(util.PathList(util.MegaCLIPath, pathList))
+	return p
 }
 
 // GetControllers retruns megaraid controllers
 func GetControllers() ([]*Controller, error) {
-	res, err := raidcli.Run(clipath, "-AdpGetPciInfo", "-aAll", "-NoLog")
+	return GetControllersWithContext(context.Background())
+}
+
+// GetControllersWithContext retruns megaraid controllers, the command is killed when the context is done
+func GetControllersWithContext(ctx context.Context) ([]*Controller, error) {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "-AdpGetPciInfo", "-aAll", "-NoLog")
 	if err != nil {
 		return nil, err
 	}
@@ -40,12 +47,17 @@ func GetControllers() ([]*Controller, error) {
 
 // Decode makes Adapter satisfy the mox.Decoder interface
 func (c *Controller) Decode() error {
-	err := setAdpInfo(c)
+	return c.DecodeContext(context.Background())
+}
+
+// DecodeContext makes Adapter satisfy the mox.ContextDecoder interface
+func (c *Controller) DecodeContext(ctx context.Context) error {
+	err := setAdpInfo(ctx, c)
 	if err != nil {
 		return err
 	}
 
-	lds, err := getLDList(c.Number)
+	lds, err := getLDList(ctx, c.Number)
 	if err != nil {
 		return err
 	}
@@ -63,7 +75,7 @@ func (c *Controller) Decode() error {
 	c.logDeriveMap = ldmap
 
 	// scan unconfigured drives
-	apd, err := getAllPD(c.Number)
+	apd, err := getAllPD(ctx, c.Number)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package megacli
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -8,8 +9,8 @@ import (
 	"github.com/moxspec/moxspec/raidcli"
 )
 
-func getAllPD(num int) ([]*PhyDrive, error) {
-	res, err := raidcli.Run(clipath, "-PDList", fmt.Sprintf("-a%d", num), "-NoLog")
+func getAllPD(ctx context.Context, num int) ([]*PhyDrive, error) {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "-PDList", fmt.Sprintf("-a%d", num), "-NoLog")
 	if err != nil {
 		return nil, err
 	}
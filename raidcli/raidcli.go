@@ -1,6 +1,7 @@
 package raidcli
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strconv"
@@ -45,6 +46,12 @@ func Run(clipath string, arg ...string) (string, error) {
 	return util.Exec(clipath, arg...)
 }
 
+// RunWithContext runs given external command, it is killed when the context is done
+func RunWithContext(ctx context.Context, clipath string, arg ...string) (string, error) {
+	log.Debugf("running %s %s", clipath, strings.Join(arg, " "))
+	return util.ExecWithContext(ctx, clipath, arg...)
+}
+
 // SplitKeyVal returns key-value pair separated by given delimier
 func SplitKeyVal(line, delim string) (key string, val string, err error) {
 	log.Debug(line)
@@ -1,6 +1,7 @@
 package sas3ircu
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -13,26 +14,32 @@ var pathList = []string{
 	"/usr/sbin/sas3ircu",
 }
 
-var clipath string
-
 var log *loglet.Logger
 
 func init() {
 	log = loglet.NewLogger("sas3ircu")
-	clipath, _ = util.ScanPathList(pathList)
 }
 
 // Available returns whether raid command is available
 func Available() bool {
-	if clipath == "" {
-		return false
-	}
-	return true
+	return cliPath() != ""
+}
+
+// cliPath returns the path of the command
+// it is looked up on every call since the runner or the path may be replaced
+func cliPath() string {
+	p, _ := util.LookPathList(util.PathList(util.SAS3IRCUPath, pathList))
+	return p
 }
 
 // GetControllers retruns sas3ircu controllers
 func GetControllers() ([]*Controller, error) {
-	res, err := raidcli.Run(clipath, "LIST")
+	return GetControllersWithContext(context.Background())
+}
+
+// GetControllersWithContext retruns sas3ircu controllers, the command is killed when the context is done
+func GetControllersWithContext(ctx context.Context) ([]*Controller, error) {
+	res, err := raidcli.RunWithContext(ctx, cliPath(), "LIST")
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +48,14 @@ func GetControllers() ([]*Controller, error) {
 
 // Decode makes Adapter satisfy the mox.Decoder interface
 func (c *Controller) Decode() error {
+	return c.DecodeContext(context.Background())
+}
+
+// DecodeContext makes Adapter satisfy the mox.ContextDecoder interface
+func (c *Controller) DecodeContext(ctx context.Context) error {
 	var err error
 
-	res, err := raidcli.Run(clipath, fmt.Sprintf("%d", c.Number), "DISPLAY")
+	res, err := raidcli.RunWithContext(ctx, cliPath(), fmt.Sprintf("%d", c.Number), "DISPLAY")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,116 @@
+// Package redact hides identifying fields in a report so that it can be shared outside
+//
+// Fields are marked by the struct tag `mox:"sensitive"` in model (e.g. serial numbers, MACs, IP addresses and the hostname).
+// A string is replaced with a token derived from its value, so the same serial number always maps to the same token
+// and components can still be told apart. Other sensitive fields (e.g. []net.IP) are cleared.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+const (
+	tagKey    = "mox"
+	sensitive = "sensitive"
+	prefix    = "redacted-"
+	tokenLen  = 12
+)
+
+// Redactor replaces sensitive values with tokens
+type Redactor struct {
+	key []byte
+}
+
+// NewRedactor creates a redactor, tokens differ by the key
+// without a key anyone can check a guessed value (e.g. a MAC address) against a token
+func NewRedactor(key string) *Redactor {
+	rd := new(Redactor)
+	rd.key = []byte(key)
+	return rd
+}
+
+// Token returns the token of the value, an empty value stays empty
+func (rd *Redactor) Token(v string) string {
+	if v == "" {
+		return ""
+	}
+	h := hmac.New(sha256.New, rd.key)
+	h.Write([]byte(v))
+	return prefix + hex.EncodeToString(h.Sum(nil))[:tokenLen]
+}
+
+// Report redacts the report in place
+func (rd *Redactor) Report(r *model.Report) {
+	if r == nil {
+		return
+	}
+	rd.walk(reflect.ValueOf(r).Elem())
+}
+
+// Report redacts the report in place with the key
+func Report(r *model.Report, key string) {
+	NewRedactor(key).Report(r)
+}
+
+func (rd *Redactor) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			rd.walk(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			rd.walk(v.Index(i))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := v.MapIndex(k)
+			// map values are not addressable, a copy is redacted and put back
+			c := reflect.New(e.Type()).Elem()
+			c.Set(e)
+			rd.walk(c)
+			v.SetMapIndex(k, c)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if isSensitive(t.Field(i)) {
+				rd.redact(f)
+				continue
+			}
+			rd.walk(f)
+		}
+	}
+}
+
+func (rd *Redactor) redact(v reflect.Value) {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(rd.Token(v.String()))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < v.Len(); i++ {
+			rd.redact(v.Index(i))
+		}
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+func isSensitive(f reflect.StructField) bool {
+	for _, o := range strings.Split(f.Tag.Get(tagKey), ",") {
+		if o == sensitive {
+			return true
+		}
+	}
+	return false
+}
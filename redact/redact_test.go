@@ -0,0 +1,123 @@
+package redact
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		key  string
+		a    string
+		b    string
+		same bool
+	}{
+		{"", "S3EVNX0K", "S3EVNX0K", true},
+		{"", "S3EVNX0K", "S3EVNX0L", false},
+		{"secret", "S3EVNX0K", "S3EVNX0K", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			rd := NewRedactor(tt.key)
+			a, b := rd.Token(tt.a), rd.Token(tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("got: %s and %s, same: %t", a, b, tt.same)
+			}
+			if !strings.HasPrefix(a, prefix) || len(a) != len(prefix)+tokenLen {
+				t.Errorf("unexpected token: %s", a)
+			}
+		})
+	}
+
+	if NewRedactor("a").Token("S3EVNX0K") == NewRedactor("b").Token("S3EVNX0K") {
+		t.Errorf("tokens should differ by the key")
+	}
+	if NewRedactor("").Token("") != "" {
+		t.Errorf("an empty value should stay empty")
+	}
+}
+
+func TestReport(t *testing.T) {
+	drv := new(model.Drive)
+	drv.Name = "sda"
+	drv.Model = "MZ7LH480"
+	drv.SerialNumber = "S45PNA0M"
+
+	pd := new(model.PhyDrive)
+	pd.Model = "MZ7LH480"
+	pd.SerialNumber = "S45PNA0M"
+	pd.WWN = "5002538e00000000"
+
+	r := &model.Report{
+		Hostname: "web01.example.com",
+		System:   &model.System{Manufacturer: "Dell Inc.", SerialNumber: "ABC1234"},
+		BMC:      &model.BMC{MAC: "00:11:22:33:44:55", IPAddr: "192.0.2.10", Netmask: "255.255.255.0"},
+		Storage: &model.StorageReport{
+			AHCIControllers: []*model.AHCIController{{Drives: []*model.Drive{drv}}},
+			RAIDControllers: []*model.RAIDController{{PassthroughDrives: []*model.PhyDrive{pd}}},
+		},
+		Network: &model.NetworkReport{
+			BondInterfaces: []*model.BondInterface{
+				{
+					Name: "bond0",
+					LinkAttrs: model.LinkAttrs{
+						HWAddr:  "00:11:22:33:44:66",
+						IPAddrs: []*model.IPAddress{{Addr: "192.0.2.20", MaskSize: 24}},
+					},
+					BondAttrs: model.BondAttrs{ArpIPTargets: []net.IP{net.ParseIP("192.0.2.1")}},
+				},
+			},
+		},
+		Extensions: map[string]*model.Extension{
+			"ocp": {
+				Data: []byte(`{"serial": "OCP0001"}`),
+				Diag: []*model.ExtensionDiag{{ID: "board1", Status: model.ExtensionWarning, Details: []string{"OCP0001 is hot"}}},
+			},
+		},
+	}
+
+	Report(r, "")
+
+	rd := NewRedactor("")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"hostname", r.Hostname, rd.Token("web01.example.com")},
+		{"system serial", r.System.SerialNumber, rd.Token("ABC1234")},
+		{"manufacturer", r.System.Manufacturer, "Dell Inc."},
+		{"bmc mac", r.BMC.MAC, rd.Token("00:11:22:33:44:55")},
+		{"bmc ip", r.BMC.IPAddr, rd.Token("192.0.2.10")},
+		{"bmc netmask", r.BMC.Netmask, "255.255.255.0"},
+		{"drive name", drv.Name, "sda"},
+		{"drive model", drv.Model, "MZ7LH480"},
+		{"drive serial", drv.SerialNumber, rd.Token("S45PNA0M")},
+		{"pd serial", pd.SerialNumber, drv.SerialNumber},
+		{"pd wwn", pd.WWN, rd.Token("5002538e00000000")},
+		{"bond mac", r.Network.BondInterfaces[0].LinkAttrs.HWAddr, rd.Token("00:11:22:33:44:66")},
+		{"bond ip", r.Network.BondInterfaces[0].LinkAttrs.IPAddrs[0].Addr, rd.Token("192.0.2.20")},
+		{"extension diag id", r.Extensions["ocp"].Diag[0].ID, "board1"},
+		{"extension diag details", r.Extensions["ocp"].Diag[0].Details[0], rd.Token("OCP0001 is hot")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got: %s, want: %s", tt.got, tt.want)
+			}
+		})
+	}
+
+	if ts := r.Network.BondInterfaces[0].BondAttrs.ArpIPTargets; ts != nil {
+		t.Errorf("arp ip targets should be cleared, got: %v", ts)
+	}
+	if d := r.Extensions["ocp"].Data; d != nil {
+		t.Errorf("extension data should be cleared, got: %s", d)
+	}
+}
@@ -0,0 +1,213 @@
+// Package schema generates the JSON Schema of the report printed by mox show -j
+//
+// Every named type in model becomes a definition under $defs, fields follow the rules of encoding/json
+// (json tags, omitempty and promoted fields of embedded structs).
+// Fields without omitempty are required, nil pointers, slices and maps among them are allowed to be null.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// Draft is the JSON Schema dialect of the output
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Node represents a JSON Schema
+type Node map[string]interface{}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type generator struct {
+	defs Node
+}
+
+// Generate returns the JSON Schema of model.Report
+func Generate() Node {
+	g := new(generator)
+	g.defs = make(Node)
+
+	root := g.node(reflect.TypeOf(model.Report{}))
+	root["$schema"] = Draft
+	root["title"] = fmt.Sprintf("mox report %s", model.SchemaVersion)
+	root["$defs"] = g.defs
+	return root
+}
+
+// JSON returns the JSON Schema of model.Report as indented JSON
+func JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(Generate(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// node returns the schema of the type, named structs are referred to in $defs
+func (g *generator) node(t reflect.Type) Node {
+	if t.Implements(jsonMarshaler) {
+		// any json, e.g. data of a plugin
+		return Node{}
+	}
+	if t.Implements(textMarshaler) || reflect.PtrTo(t).Implements(textMarshaler) {
+		return Node{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.node(t.Elem())
+	case reflect.Bool:
+		return Node{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Node{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Node{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Node{"type": "number"}
+	case reflect.String:
+		return Node{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes bytes in base64
+			return Node{"type": "string", "contentEncoding": "base64"}
+		}
+		return Node{"type": "array", "items": g.node(t.Elem())}
+	case reflect.Map:
+		return Node{"type": "object", "additionalProperties": g.node(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			// reserved before walking fields so that recursive types terminate
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return Node{"$ref": "#/$defs/" + t.Name()}
+	}
+
+	return Node{}
+}
+
+// object returns the schema of the struct
+func (g *generator) object(t reflect.Type) Node {
+	props := make(Node)
+	var required []string
+
+	for _, f := range Fields(t) {
+		n := g.node(f.Type)
+		if !f.OmitEmpty && nullable(f.Type) {
+			n = Node{"anyOf": []Node{n, {"type": "null"}}}
+		}
+		props[f.Name] = n
+
+		if !f.OmitEmpty {
+			required = append(required, f.Name)
+		}
+	}
+
+	o := Node{"type": "object", "properties": props}
+	if len(required) > 0 {
+		o["required"] = required
+	}
+	return o
+}
+
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// Field represents a field of a struct as encoding/json writes it
+type Field struct {
+	Name      string
+	Type      reflect.Type
+	Index     []int // for reflect.Value.FieldByIndex, it may go through an embedded pointer
+	OmitEmpty bool
+}
+
+// Fields returns fields of the struct as encoding/json writes them
+// a field of an embedded struct is promoted unless a shallower one has the same name
+func Fields(t reflect.Type) []Field {
+	var list []Field
+	depths := make(map[string]int)
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			idx := append(append([]int{}, index...), i)
+
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			name, opts := tag, ""
+			if n := strings.Index(tag, ","); n >= 0 {
+				name, opts = tag[:n], tag[n+1:]
+			}
+
+			ft := sf.Type
+			if sf.Anonymous && name == "" {
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, idx)
+					continue
+				}
+			}
+			if sf.PkgPath != "" {
+				// unexported
+				continue
+			}
+
+			if name == "" {
+				name = sf.Name
+			}
+			if d, ok := depths[name]; ok && d <= len(idx) {
+				continue
+			}
+			depths[name] = len(idx)
+
+			list = append(list, Field{
+				Name:      name,
+				Type:      ft,
+				Index:     idx,
+				OmitEmpty: hasOption(opts, "omitempty"),
+			})
+		}
+	}
+	walk(t, nil)
+
+	// a shallower field may come after a deeper one with the same name
+	var uniq []Field
+	for _, f := range list {
+		if depths[f.Name] == len(f.Index) {
+			uniq = append(uniq, f)
+			depths[f.Name] = -1
+		}
+	}
+	return uniq
+}
+
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
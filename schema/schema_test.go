@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+var update = flag.Bool("update", false, "write the schema of the current version to testdata")
+
+// The schema of every released version is kept in testdata as report-<version>.json.
+// A change of model needs a new minor version if it only adds fields, otherwise a new major version.
+func TestSchemaIsReleased(t *testing.T) {
+	b, err := JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", fmt.Sprintf("report-%s.json", model.SchemaVersion))
+	if *update {
+		err = ioutil.WriteFile(path, b, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s; raise model.SchemaVersion and run go test ./schema -update", err)
+	}
+	if !bytes.Equal(b, want) {
+		t.Errorf("the schema differs from %s; raise model.SchemaVersion and run go test ./schema -update", path)
+	}
+}
+
+func TestSchemaIsCompatible(t *testing.T) {
+	cur := decode(t, Generate())
+	major := strings.SplitN(model.SchemaVersion, ".", 2)[0]
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "report-*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		ver := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "report-"), ".json")
+		if strings.SplitN(ver, ".", 2)[0] != major {
+			continue
+		}
+
+		t.Run(ver, func(t *testing.T) {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			old := make(Node)
+			err = json.Unmarshal(b, &old)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range incompatibilities(old, cur) {
+				t.Errorf("%s (a new major version is needed)", p)
+			}
+		})
+	}
+}
+
+// incompatibilities returns fields removed, changed or made optional since the old schema
+func incompatibilities(old, cur Node) []string {
+	var found []string
+	oldDefs, _ := old["$defs"].(map[string]interface{})
+	curDefs, _ := cur["$defs"].(map[string]interface{})
+
+	for name, od := range oldDefs {
+		cd, ok := curDefs[name].(map[string]interface{})
+		if !ok {
+			found = append(found, fmt.Sprintf("%s: removed", name))
+			continue
+		}
+		op, _ := od.(map[string]interface{})["properties"].(map[string]interface{})
+		cp, _ := cd["properties"].(map[string]interface{})
+		for prop, os := range op {
+			cs, ok := cp[prop]
+			if !ok {
+				found = append(found, fmt.Sprintf("%s.%s: removed", name, prop))
+				continue
+			}
+			if !reflect.DeepEqual(os, cs) {
+				found = append(found, fmt.Sprintf("%s.%s: changed", name, prop))
+			}
+		}
+
+		req := make(map[string]bool)
+		for _, r := range list(cd["required"]) {
+			req[r] = true
+		}
+		for _, r := range list(od.(map[string]interface{})["required"]) {
+			if !req[r] {
+				found = append(found, fmt.Sprintf("%s.%s: no longer required", name, r))
+			}
+		}
+	}
+	return found
+}
+
+func list(v interface{}) []string {
+	var l []string
+	vs, _ := v.([]interface{})
+	for _, s := range vs {
+		l = append(l, fmt.Sprint(s))
+	}
+	return l
+}
+
+// decode makes a generated schema comparable with one read from a file
+func decode(t *testing.T, n Node) Node {
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := make(Node)
+	err = json.Unmarshal(b, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestIncompatibilities(t *testing.T) {
+	old := Node{"$defs": map[string]interface{}{
+		"Drive": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"name":   map[string]interface{}{"type": "string"},
+				"blocks": map[string]interface{}{"type": "integer"},
+			},
+			"required": []interface{}{"name"},
+		},
+	}}
+
+	tests := []struct {
+		props    map[string]interface{}
+		required []interface{}
+		want     int
+	}{
+		{map[string]interface{}{"name": map[string]interface{}{"type": "string"}, "blocks": map[string]interface{}{"type": "integer"}}, []interface{}{"name"}, 0},
+		{map[string]interface{}{"name": map[string]interface{}{"type": "string"}, "blocks": map[string]interface{}{"type": "integer"}, "model": map[string]interface{}{"type": "string"}}, []interface{}{"name"}, 0},
+		{map[string]interface{}{"name": map[string]interface{}{"type": "string"}}, []interface{}{"name"}, 1},
+		{map[string]interface{}{"name": map[string]interface{}{"type": "string"}, "blocks": map[string]interface{}{"type": "string"}}, []interface{}{"name"}, 1},
+		{map[string]interface{}{"name": map[string]interface{}{"type": "string"}, "blocks": map[string]interface{}{"type": "integer"}}, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			cur := Node{"$defs": map[string]interface{}{
+				"Drive": map[string]interface{}{"properties": tt.props, "required": tt.required},
+			}}
+			if got := incompatibilities(old, cur); len(got) != tt.want {
+				t.Errorf("got: %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	type inner struct {
+		Name  string `json:"name"`
+		Model string `json:"model,omitempty"`
+	}
+	type extra struct {
+		Serial string `json:"serial,omitempty"`
+	}
+	type outer struct {
+		inner
+		Name    string `json:"displayName"`
+		Model   string `json:"model"`
+		Skipped string `json:"-"`
+		Plain   int
+		private int
+		*extra
+	}
+
+	var got []string
+	for _, f := range Fields(reflect.TypeOf(outer{})) {
+		got = append(got, fmt.Sprintf("%s%v:%t", f.Name, f.Index, f.OmitEmpty))
+	}
+
+	want := []string{"name[0 0]:false", "displayName[1]:false", "model[2]:false", "Plain[4]:false", "serial[6 0]:true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestReportIsValid(t *testing.T) {
+	s := decode(t, Generate())
+	if s["$ref"] != "#/$defs/Report" {
+		t.Fatalf("unexpected root: %v", s["$ref"])
+	}
+
+	defs := s["$defs"].(map[string]interface{})
+	for _, name := range []string{"Report", "NVMeController", "PhyDrive", "NetInterface", "GPU", "DecodeError"} {
+		if _, ok := defs[name]; !ok {
+			t.Errorf("%s is not defined", name)
+		}
+	}
+
+	props := defs["Report"].(map[string]interface{})["properties"].(map[string]interface{})
+	if props["schemaVersion"] == nil {
+		t.Errorf("schemaVersion is not in the report")
+	}
+}
@@ -0,0 +1,306 @@
+// Package server keeps a report up to date and serves it over HTTP
+//
+// Endpoints:
+//
+//	GET  /report     the whole report
+//	GET  /<section>  a section of the report (e.g. /storage, /network)
+//	GET  /diag       diagnoses of the components, deltas are taken since the previous report kept in memory
+//	GET  /metrics    health and wear counters as OpenMetrics text if accepted, or as the Prometheus text format
+//	POST /refresh    refreshes the counters at once, ?full=1 collects the whole inventory
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moxspec/moxspec/diag"
+	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/metrics"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/mox"
+)
+
+var log *loglet.Logger
+
+func init() {
+	log = loglet.NewLogger("server")
+}
+
+// These are default intervals
+const (
+	DefaultRefreshInterval = time.Minute
+	DefaultFullInterval    = time.Hour
+)
+
+// Options represents options for a Server
+type Options struct {
+	// Collect is used to collect the report
+	Collect mox.Options
+	// RefreshInterval is how often the counters are refreshed, zero means the default
+	RefreshInterval time.Duration
+	// FullInterval is how often the whole inventory is collected, zero means the default
+	FullInterval time.Duration
+	// DiagPolicy is used to diagnose the report, nil means the default
+	DiagPolicy *diag.Policy
+}
+
+// Server keeps a report up to date and serves it
+type Server struct {
+	opts    Options
+	collect func(context.Context, mox.Options) (*model.Report, error)
+	refresh func(context.Context, *model.Report, mox.Options) (*model.Report, error)
+
+	mu        sync.RWMutex
+	report    *model.Report
+	diagnosis *diag.Diagnosis
+	// state keeps counters of the previous report for thresholds on deltas
+	state *diag.State
+
+	requests chan *request
+}
+
+type request struct {
+	full bool
+	done chan error
+}
+
+// New creates and initializes a Server
+func New(opts Options) *Server {
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = DefaultRefreshInterval
+	}
+	if opts.FullInterval <= 0 {
+		opts.FullInterval = DefaultFullInterval
+	}
+	if opts.DiagPolicy == nil {
+		opts.DiagPolicy = diag.DefaultPolicy()
+	}
+
+	s := new(Server)
+	s.opts = opts
+	s.collect = mox.Collect
+	s.refresh = mox.Refresh
+	s.requests = make(chan *request)
+	return s
+}
+
+// Run collects the report and keeps it up to date until ctx is done
+// collections never overlap since the decoders share the process-wide state
+func (s *Server) Run(ctx context.Context) {
+	s.update(ctx, true)
+
+	rt := time.NewTicker(s.opts.RefreshInterval)
+	defer rt.Stop()
+	ft := time.NewTicker(s.opts.FullInterval)
+	defer ft.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rt.C:
+			s.update(ctx, false)
+		case <-ft.C:
+			s.update(ctx, true)
+		case req := <-s.requests:
+			req.done <- s.update(ctx, req.full)
+		}
+	}
+}
+
+func (s *Server) update(ctx context.Context, full bool) error {
+	prev := s.Report()
+
+	var r *model.Report
+	var err error
+	if full || prev == nil {
+		log.Debug("collecting the whole inventory")
+		r, err = s.collect(ctx, s.opts.Collect)
+	} else {
+		log.Debug("refreshing counters")
+		r, err = s.refresh(ctx, prev, s.opts.Collect)
+	}
+
+	// a partial report is still newer than the cached one
+	// it is diagnosed at once, so that deltas are taken between reports rather than requests
+	if r != nil {
+		s.mu.Lock()
+		d, next := s.opts.DiagPolicy.DiagnoseSince(r, s.state)
+		s.report = r
+		s.diagnosis = d
+		s.state = next
+		s.mu.Unlock()
+	}
+
+	if err != nil {
+		log.Warn(err.Error())
+	}
+	return err
+}
+
+// Report returns the cached report, nil until the first collection finishes
+func (s *Server) Report() *model.Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.report
+}
+
+// Diagnosis returns the diagnosis of the cached report, nil until the first collection finishes
+func (s *Server) Diagnosis() *diag.Diagnosis {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.diagnosis
+}
+
+// Handler returns the HTTP handler serving the endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/report", s.get(func(r *model.Report) interface{} {
+		return r
+	}))
+	mux.HandleFunc("/diag", s.get(func(r *model.Report) interface{} {
+		return s.Diagnosis()
+	}))
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	for _, sec := range mox.AllSections() {
+		sec := sec
+		mux.HandleFunc("/"+string(sec), s.get(func(r *model.Report) interface{} {
+			return section(r, sec)
+		}))
+	}
+	mux.HandleFunc("/refresh", s.handleRefresh)
+
+	return mux
+}
+
+func (s *Server) get(view func(*model.Report) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r := s.Report()
+		if r == nil {
+			http.Error(w, "the report is not collected yet", http.StatusServiceUnavailable)
+			return
+		}
+
+		writeJSON(w, view(r))
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r := s.Report()
+	if r == nil {
+		http.Error(w, "the report is not collected yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Prometheus asks for OpenMetrics only if it is enabled, others read the Prometheus text format
+	if strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", metrics.ContentType)
+		metrics.WriteFormat(w, r, metrics.OpenMetrics)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.PrometheusContentType)
+	metrics.WriteFormat(w, r, metrics.Prometheus)
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	full := false
+	switch strings.ToLower(req.URL.Query().Get("full")) {
+	case "", "0", "false":
+	default:
+		full = true
+	}
+
+	rq := &request{full: full, done: make(chan error, 1)}
+	select {
+	case s.requests <- rq:
+	case <-req.Context().Done():
+		return
+	}
+
+	var err error
+	select {
+	case err = <-rq.done:
+	case <-req.Context().Done():
+		return
+	}
+
+	r := s.Report()
+	if r == nil {
+		http.Error(w, fmt.Sprintf("failed to collect the report: %s", err), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		// the report may be partial, the error is told in a header not to break clients
+		w.Header().Set("X-Mox-Error", err.Error())
+	}
+
+	writeJSON(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	jb, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jb)
+}
+
+// section returns a report which has only the given section
+func section(r *model.Report, sec mox.Section) *model.Report {
+	sr := new(model.Report)
+	sr.Version = r.Version
+	sr.Timestamp = r.Timestamp
+	sr.Datetime = r.Datetime
+
+	switch sec {
+	case mox.SystemSection:
+		sr.System = r.System
+		sr.Chassis = r.Chassis
+		sr.Firmware = r.Firmware
+		sr.Baseboard = r.Baseboard
+	case mox.ProcessorSection:
+		sr.Processor = r.Processor
+	case mox.MemorySection:
+		sr.Memory = r.Memory
+	case mox.StorageSection:
+		sr.Storage = r.Storage
+	case mox.NetworkSection:
+		sr.Network = r.Network
+	case mox.AcceleratorSection:
+		sr.Accelerator = r.Accelerator
+	case mox.PowerSupplySection:
+		sr.PowerSupply = r.PowerSupply
+	case mox.BMCSection:
+		sr.BMC = r.BMC
+	case mox.PCISection:
+		sr.PCIDevice = r.PCIDevice
+	case mox.ExtensionSection:
+		sr.Extensions = r.Extensions
+	}
+
+	return sr
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moxspec/moxspec/diag"
+	"github.com/moxspec/moxspec/metrics"
+	"github.com/moxspec/moxspec/model"
+	"github.com/moxspec/moxspec/mox"
+)
+
+type fakeCollector struct {
+	fulls     int32
+	refreshes int32
+}
+
+func (f *fakeCollector) collect(ctx context.Context, opts mox.Options) (*model.Report, error) {
+	n := atomic.AddInt32(&f.fulls, 1)
+
+	r := new(model.Report)
+	r.System = &model.System{ProductName: "R1"}
+	r.Storage = &model.StorageReport{NVMeControllers: []*model.NVMeController{{Model: "PM1725b"}}}
+	r.Network = &model.NetworkReport{EthControllers: []*model.EthController{}}
+	r.Timestamp = int64(n)
+	return r, nil
+}
+
+func (f *fakeCollector) refresh(ctx context.Context, prev *model.Report, opts mox.Options) (*model.Report, error) {
+	atomic.AddInt32(&f.refreshes, 1)
+
+	r := new(model.Report)
+	*r = *prev
+	r.Timestamp = prev.Timestamp + 100
+	return r, nil
+}
+
+func newTestServer(opts Options) (*Server, *fakeCollector) {
+	f := new(fakeCollector)
+	s := New(opts)
+	s.collect = f.collect
+	s.refresh = f.refresh
+	return s, f
+}
+
+func TestHandlerBeforeCollection(t *testing.T) {
+	s, _ := newTestServer(Options{})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got: %d, expect: %d", res.StatusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s, f := newTestServer(Options{RefreshInterval: time.Hour, FullInterval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// wait for the first collection
+	for i := 0; s.Report() == nil; i++ {
+		if i > 100 {
+			t.Fatal("the report is not collected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		check  func(r map[string]interface{}) error
+	}{
+		{"GET", "/report", http.StatusOK, func(r map[string]interface{}) error {
+			if r["system"] == nil || r["storage"] == nil {
+				return fmt.Errorf("system and storage should be present")
+			}
+			return nil
+		}},
+		{"GET", "/storage", http.StatusOK, func(r map[string]interface{}) error {
+			if r["system"] != nil || r["storage"] == nil || r["timestamp"] == nil {
+				return fmt.Errorf("only storage should be present")
+			}
+			return nil
+		}},
+		{"GET", "/system", http.StatusOK, func(r map[string]interface{}) error {
+			if r["system"] == nil || r["storage"] != nil {
+				return fmt.Errorf("only system should be present")
+			}
+			return nil
+		}},
+		{"GET", "/diag", http.StatusOK, func(r map[string]interface{}) error {
+			if r["healthy"] != true {
+				return fmt.Errorf("should be healthy")
+			}
+			return nil
+		}},
+		{"GET", "/metrics", http.StatusOK, nil},
+		{"POST", "/report", http.StatusMethodNotAllowed, nil},
+		{"GET", "/refresh", http.StatusMethodNotAllowed, nil},
+		{"GET", "/nothing", http.StatusNotFound, nil},
+		{"POST", "/refresh", http.StatusOK, func(r map[string]interface{}) error {
+			if r["timestamp"] != float64(101) {
+				return fmt.Errorf("counters should be refreshed, got timestamp: %v", r["timestamp"])
+			}
+			return nil
+		}},
+		{"POST", "/refresh?full=1", http.StatusOK, func(r map[string]interface{}) error {
+			if r["timestamp"] != float64(2) {
+				return fmt.Errorf("the inventory should be collected, got timestamp: %v", r["timestamp"])
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.method, tt.path), func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.code {
+				t.Fatalf("got: %d, expect: %d", res.StatusCode, tt.code)
+			}
+			if tt.check == nil {
+				return
+			}
+
+			var r map[string]interface{}
+			err = json.NewDecoder(res.Body).Decode(&r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.check(r); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if atomic.LoadInt32(&f.fulls) != 2 || atomic.LoadInt32(&f.refreshes) != 1 {
+		t.Errorf("got: %d full, %d refresh, expect: 2 full, 1 refresh", f.fulls, f.refreshes)
+	}
+}
+
+func TestMetricsFormat(t *testing.T) {
+	s, _ := newTestServer(Options{RefreshInterval: time.Hour, FullInterval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	for i := 0; s.Report() == nil; i++ {
+		if i > 100 {
+			t.Fatal("the report is not collected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		accept string
+		expect string
+	}{
+		{"", metrics.PrometheusContentType},
+		{"text/plain;version=0.0.4;q=0.3,*/*;q=0.2", metrics.PrometheusContentType},
+		{"application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5", metrics.ContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			req, err := http.NewRequest("GET", ts.URL+"/metrics", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if ct := res.Header.Get("Content-Type"); ct != tt.expect {
+				t.Errorf("got: %s, expect: %s", ct, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRunIntervals(t *testing.T) {
+	s, f := newTestServer(Options{RefreshInterval: 10 * time.Millisecond, FullInterval: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	s.Run(ctx)
+
+	if atomic.LoadInt32(&f.fulls) != 1 {
+		t.Errorf("full got: %d, expect: 1", f.fulls)
+	}
+	if atomic.LoadInt32(&f.refreshes) < 2 {
+		t.Errorf("refresh got: %d, expect: 2 or more", f.refreshes)
+	}
+}
+
+func TestDiagDelta(t *testing.T) {
+	p, err := diag.ParsePolicy([]byte("thresholds: {memory_ce: {critical: 10, delta: true}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(Options{DiagPolicy: p})
+
+	var ce uint64 = 5000
+	var ts int64 = 1000
+	report := func() *model.Report {
+		ts += 60
+		return &model.Report{
+			Timestamp: ts,
+			Memory: &model.MemoryReport{Controllers: []*model.MemoryController{
+				{Name: "mc0", CSRows: []*model.ChipSelectRow{{Name: "csrow0", CECount: ce}}},
+			}},
+		}
+	}
+	s.collect = func(ctx context.Context, opts mox.Options) (*model.Report, error) {
+		return report(), nil
+	}
+	s.refresh = func(ctx context.Context, prev *model.Report, opts mox.Options) (*model.Report, error) {
+		return report(), nil
+	}
+
+	// a delta can not be taken on the first report
+	s.update(context.Background(), true)
+	if d := s.Diagnosis(); d == nil || !d.Healthy {
+		t.Fatalf("should be healthy, got: %+v", d)
+	}
+
+	ce += 20
+	s.update(context.Background(), false)
+	if d := s.Diagnosis(); d == nil || d.Healthy {
+		t.Errorf("the increase should be critical, got: %+v", d)
+	}
+
+	// requests do not move the state forward
+	for i := 0; i < 2; i++ {
+		if d := s.Diagnosis(); d.Healthy {
+			t.Errorf("the diagnosis should be kept until the next report, got: %+v", d)
+		}
+	}
+
+	s.update(context.Background(), false)
+	if d := s.Diagnosis(); !d.Healthy {
+		t.Errorf("no increase since the previous report, got: %+v", d)
+	}
+}
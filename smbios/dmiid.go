@@ -0,0 +1,84 @@
+package smbios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/moxspec/moxspec/util"
+)
+
+const sysfsDMIID = "/sys/class/dmi/id"
+
+// decodeDMIID decodes the records which the kernel gives without privileges
+// serial numbers are readable only by root, so they are usually left empty
+func (s *Spec) decodeDMIID() error {
+	dir := util.RootPath(sysfsDMIID)
+	if !util.Exists(dir) {
+		return fmt.Errorf("%s was not found", sysfsDMIID)
+	}
+
+	attr := func(name string) string {
+		v, err := util.LoadString(filepath.Join(dir, name))
+		if err != nil {
+			log.Debug(err)
+		}
+		return v
+	}
+
+	s.Sysfs = true
+	s.Records = make(map[uint8][]*Structure)
+	add := func(t uint8, d Data) {
+		s.Records[t] = append(s.Records[t], &Structure{Header: &Header{Type: t}, Data: d})
+	}
+
+	add(biosInformation, &BIOS{
+		Vendor:      util.ShortenVendorName(attr("bios_vendor")),
+		Version:     attr("bios_version"),
+		ReleaseDate: attr("bios_date"),
+	})
+	add(systemInformation, &System{
+		Manufacturer: util.ShortenVendorName(attr("sys_vendor")),
+		ProductName:  attr("product_name"),
+		Version:      attr("product_version"),
+		SerialNumber: attr("product_serial"),
+		SKUNumber:    attr("product_sku"),
+		Family:       attr("product_family"),
+	})
+	add(baseboardInformation, &Baseboard{
+		Manufacturer: util.ShortenVendorName(attr("board_vendor")),
+		Product:      attr("board_name"),
+		Version:      attr("board_version"),
+		SerialNumber: attr("board_serial"),
+		AssetTag:     attr("board_asset_tag"),
+	})
+	add(systemEnclosure, &Chassis{
+		Manufacturer:   util.ShortenVendorName(attr("chassis_vendor")),
+		Version:        attr("chassis_version"),
+		SerialNumber:   attr("chassis_serial"),
+		AssetTagNumber: attr("chassis_asset_tag"),
+	})
+
+	log.Debugf("decoded %s", sysfsDMIID)
+	return nil
+}
+
+// captureDMIID keeps /sys/class/dmi/id in a bundle along with the tables,
+// so that a bundle collected without root is still decoded from it
+func captureDMIID() {
+	fis, err := ioutil.ReadDir(sysfsDMIID)
+	if err != nil {
+		log.Debug(err)
+		return
+	}
+	for _, fi := range fis {
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		// loaded files are captured, the ones only root can read are left out
+		_, err := util.LoadBytes(filepath.Join(sysfsDMIID, fi.Name()))
+		if err != nil {
+			log.Debug(err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package smbios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moxspec/moxspec/util"
+)
+
+func TestDecodeUnprivileged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smbios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	id := filepath.Join(dir, "sys", "class", "dmi", "id")
+	err = os.MkdirAll(id, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[string]string{
+		"sys_vendor":     "Dell Inc.",
+		"product_name":   "PowerEdge R640",
+		"bios_vendor":    "Dell Inc.",
+		"bios_version":   "2.10.2",
+		"bios_date":      "02/24/2021",
+		"board_vendor":   "Dell Inc.",
+		"board_name":     "0H28RR",
+		"chassis_vendor": "Dell Inc.",
+	}
+	for name, v := range attrs {
+		ioutil.WriteFile(filepath.Join(id, name), []byte(v+"\n"), 0444)
+	}
+
+	util.SetRoot(dir)
+	defer util.SetRoot("")
+	defer util.SetPrivileged(true)
+
+	// the captured root has no tables, so that they are not read even with privileges
+	for _, privileged := range []bool{false, true} {
+		util.SetPrivileged(privileged)
+
+		s := NewDecoder()
+		err = s.Decode()
+		if err != nil {
+			t.Fatalf("privileged: %t, %s", privileged, err)
+		}
+
+		if !s.Sysfs {
+			t.Errorf("expect the records from sysfs")
+		}
+		if sy := s.GetSystem(); sy == nil || sy.ProductName != "PowerEdge R640" || sy.SerialNumber != "" {
+			t.Errorf("system got: %+v", sy)
+		}
+		if b := s.GetBIOS(); b == nil || b.Version != "2.10.2" || b.ReleaseDate != "02/24/2021" {
+			t.Errorf("bios got: %+v", b)
+		}
+		if b := s.GetBaseboard(); b == nil || b.Product != "0H28RR" {
+			t.Errorf("baseboard got: %+v", b)
+		}
+		if s.GetChassis() == nil {
+			t.Errorf("chassis got: nil")
+		}
+		if len(s.GetProcessor()) != 0 || len(s.GetMemoryDevice()) != 0 {
+			t.Errorf("processors and memory devices are not in sysfs")
+		}
+	}
+}
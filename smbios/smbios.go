@@ -1,10 +1,15 @@
 package smbios
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 
 	gosmbios "github.com/digitalocean/go-smbios/smbios"
+	"github.com/moxspec/moxspec/capture"
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
 )
 
 var log *loglet.Logger
@@ -19,6 +24,22 @@ type Spec struct {
 	Minor   int
 	Rev     int
 	Records map[uint8][]*Structure
+	Errors  []*RecordError
+	// Sysfs is true if the records were read from /sys/class/dmi/id since the tables require privileges
+	// or are missing in a captured root
+	// it gives only system, bios, baseboard and chassis
+	Sysfs bool
+}
+
+// RecordError represents a record which could not be decoded
+type RecordError struct {
+	Type   uint8
+	Handle uint16
+	Err    error
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("type %d, handle 0x%04x: %s", e.Type, e.Handle, e.Err)
 }
 
 // Version returns the version string of smbios
@@ -120,9 +141,48 @@ func NewDecoder() *Spec {
 	return new(Spec)
 }
 
+const (
+	sysfsEntryPoint = "/sys/firmware/dmi/tables/smbios_entry_point"
+	sysfsDMI        = "/sys/firmware/dmi/tables/DMI"
+)
+
+// stream opens the smbios tables
+// they are read from sysfs in a captured root, and also while capturing to keep the raw tables
+func stream() (io.ReadCloser, gosmbios.EntryPoint, error) {
+	if !util.Captured() && !(capture.Enabled() && util.Exists(sysfsDMI)) {
+		return gosmbios.Stream()
+	}
+
+	epb, err := util.LoadBytes(util.RootPath(sysfsEntryPoint))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ep, err := gosmbios.ParseEntryPoint(bytes.NewReader(epb))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dmi, err := util.LoadBytes(util.RootPath(sysfsDMI))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(dmi)), ep, nil
+}
+
 // Decode makes Spec satisfy the mox.Decoder interface
 func (s *Spec) Decode() error {
-	rc, ep, err := gosmbios.Stream()
+	if capture.Enabled() && !util.Captured() {
+		captureDMIID()
+	}
+
+	// a capture without the tables (e.g. collected without root) still has /sys/class/dmi/id
+	if !util.Privileged() || (util.Captured() && !util.Exists(util.RootPath(sysfsDMI))) {
+		return s.decodeDMIID()
+	}
+
+	rc, ep, err := stream()
 	if err != nil {
 		return fmt.Errorf("failed to open stream: %v", err)
 	}
@@ -185,6 +245,11 @@ func (s *Spec) Decode() error {
 
 		if err != nil {
 			log.Debug("could not decode record")
+			s.Errors = append(s.Errors, &RecordError{
+				Type:   st.Header.Type,
+				Handle: st.Header.Handle,
+				Err:    err,
+			})
 			continue
 		}
 		s.Records[st.Header.Type] = append(s.Records[st.Header.Type], st)
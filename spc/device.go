@@ -1,6 +1,11 @@
 package spc
 
-import "os"
+import (
+	"fmt"
+	"os"
+
+	"github.com/moxspec/moxspec/capture"
+)
 
 // DiskType represents Divice Protocol (SATA/SAS)
 type DiskType string
@@ -49,13 +54,19 @@ type Device struct {
 	UnsafeShutdownCount uint64
 	TotalLBAWritten     uint64
 	TotalLBARead        uint64
+	ReallocatedSectors  uint64
+	PendingSectors      uint64
 	SigSpeed            string
 	NegSpeed            string
 	ErrorRecords        []*SmartRecord
 	SelfTestSupport     bool
 	ErrorLoggingSupport bool
+	WearSupport         bool // LifeLeft and Used are reported
+	SpareSpaceSupport   bool
 
 	ioctlDeviceFilePath string
+	wearValues          map[byte]byte // the normalized values of the vendor specific wear attributes, see setWear
+	captureName         string
 	post                PostFunc
 	diskType            DiskType
 }
@@ -74,3 +85,21 @@ func NewDevice(post PostFunc, ioctlDeviceFilePath string, diskType DiskType) *De
 
 	return d
 }
+
+// SetCaptureName sets the name buffers are captured under
+// it distinguishes devices sharing an ioctl device file
+func (d *Device) SetCaptureName(name string) {
+	d.captureName = name
+}
+
+func (d *Device) send(fd *os.File, cdb []byte) ([]byte, error) {
+	buf, err := d.post(fd, cdb)
+	if err == nil && capture.Enabled() {
+		name := d.captureName
+		if name == "" {
+			name = d.ioctlDeviceFilePath
+		}
+		capture.Ioctl(name, fmt.Sprintf("cdb-%x", cdb), buf)
+	}
+	return buf, err
+}
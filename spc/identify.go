@@ -28,7 +28,7 @@ func (d *Device) decodeIdentify(fd *os.File) error {
 	// 7.12.3 Inputs
 	// http://www.t13.org/documents/UploadedDocuments/docs2016/di529r14-ATAATAPI_Command_Set_-_4.pdf
 	cdb := makeATAPThruCmd(0x00, 0x00, 0x00, 0x00, 0xEC)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return err
 	}
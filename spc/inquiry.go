@@ -17,7 +17,7 @@ func (d *Device) decodeInquiry(fd *os.File) error {
 	// https://www.seagate.com/files/staticfiles/support/docs/manual/Interface%20manuals/100293068j.pdf
 	// 3.6.1 INQUIRY command introduction
 	cdb := makeInquiryCmd(0x00, false, 0x60)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return err
 	}
@@ -28,7 +28,7 @@ func (d *Device) decodeInquiry(fd *os.File) error {
 
 	// 5.4.18 Supported Vital Product Data pages (00h)
 	cdb = makeInquiryCmd(0x00, true, 0xFC)
-	buf, err = d.post(fd, cdb)
+	buf, err = d.send(fd, cdb)
 	if err != nil {
 		return err
 	}
@@ -41,7 +41,7 @@ func (d *Device) decodeInquiry(fd *os.File) error {
 	if _, supported := supportedVPDs[0x80]; supported {
 		// 5.4.19 Unit Serial Number page (80h)
 		cdb = makeInquiryCmd(0x80, true, 0x18)
-		buf, err = d.post(fd, cdb)
+		buf, err = d.send(fd, cdb)
 		if err != nil {
 			return err
 		}
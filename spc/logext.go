@@ -23,7 +23,7 @@ func (d *Device) decodeLogExt(fd *os.File) error {
 	// 9.5 Device Statistics log (Log Address 04h)
 	// 9.5.4 General Statistics (log page 01h)
 	cdb := makeATAPThruCmd(0x00, 0x04, 0x01, 0x00, 0x2F)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return err
 	}
@@ -56,7 +56,7 @@ func (d *Device) readLogSenseSupportPages(fd *os.File) (map[byte]struct{}, error
 	// https://www.seagate.com/files/staticfiles/support/docs/manual/Interface%20manuals/100293068j.pdf
 	// 5.2.21 Supported Log Pages log page (00h/00h)
 	cdb := makeReadLogSenseCmd(pcCumulative+logSenseSupportLogPage, 0x00, 0x16)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +95,7 @@ func (d *Device) readLogSenseReadError(fd *os.File) (map[int]uint64, error) {
 	// https://www.seagate.com/files/staticfiles/support/docs/manual/Interface%20manuals/100293068j.pdf
 	// Table 299 Error counter log page codes
 	cdb := makeReadLogSenseCmd(pcCumulative+logSenseReadErrorPage, 0x00, 0x65)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +108,7 @@ func (d *Device) readLogSenseWriteError(fd *os.File) (map[int]uint64, error) {
 	// https://www.seagate.com/files/staticfiles/support/docs/manual/Interface%20manuals/100293068j.pdf
 	// Table 299 Error counter log page codes
 	cdb := makeReadLogSenseCmd(pcCumulative+logSenseWriteErrorPage, 0x00, 0x65)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return nil, err
 	}
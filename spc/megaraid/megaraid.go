@@ -33,7 +33,12 @@ func NewDecoder(ctl, dev int, diskType spc.DiskType) *spc.Device {
 		return post(fd, ctl, dev, cdb)
 	}
 
-	return spc.NewDevice(post, ioctlnodeFile, diskType)
+	d := spc.NewDevice(post, ioctlnodeFile, diskType)
+	if d != nil {
+		d.SetCaptureName(fmt.Sprintf("megaraid_c%d_d%d", ctl, dev))
+	}
+
+	return d
 }
 
 type iovBuf = [iovSize]byte
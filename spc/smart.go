@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"os"
+	"strings"
 )
 
 const (
@@ -35,7 +36,7 @@ func (d *Device) decodeSmart(fd *os.File) error {
 	// 7.44 SMART
 	// 7.44.2.3 Inputs
 	cdb := makeATAPThruCmd(0xD0, 0x00, 0x4F, 0xC2, 0xB0)
-	buf, err := d.post(fd, cdb)
+	buf, err := d.send(fd, cdb)
 	if err != nil {
 		return err
 	}
@@ -48,7 +49,7 @@ func (d *Device) decodeSmart(fd *os.File) error {
 	// 7.44 SMART
 	// 7.44.2.3 Inputs
 	cdb = makeATAPThruCmd(0xD1, 0x00, 0x4F, 0xC2, 0xB0)
-	buf, err = d.post(fd, cdb)
+	buf, err = d.send(fd, cdb)
 	if err != nil {
 		return err
 	}
@@ -77,6 +78,8 @@ func (d *Device) decodeSmart(fd *os.File) error {
 
 	log.Debug("scanning smart values")
 
+	d.wearValues = make(map[byte]byte)
+
 	for i := 0; i < len(attrs); i++ {
 		attr := attrs[i]
 		switch attr.id {
@@ -85,6 +88,7 @@ func (d *Device) decodeSmart(fd *os.File) error {
 				d.appendErrorRecord(attr, tDict[attr.id], "Raw Read Error Rate")
 			}
 		case 0x05: // 5. Reallocated Sectors Count
+			d.ReallocatedSectors = uint64(attr.raw)
 			if isUnhealthy(attr) {
 				d.appendErrorRecord(attr, tDict[attr.id], "Reallocated Sectors Count")
 			}
@@ -129,6 +133,8 @@ func (d *Device) decodeSmart(fd *os.File) error {
 			if max > 0 {
 				d.MaxTemp = max
 			}
+		case 0xB1, 0xE7, 0xE8, 0xE9: // vendor specific, see wearAttrs
+			d.wearValues[attr.id] = attr.current
 		case 0xC0: // 192. Power-off Retract Count or Unsafe Shutdown Count
 			if uint64(attr.raw) > d.UnsafeShutdownCount {
 				d.UnsafeShutdownCount = uint64(attr.raw)
@@ -153,6 +159,7 @@ func (d *Device) decodeSmart(fd *os.File) error {
 				d.appendErrorRecord(attr, tDict[attr.id], "Reallocation Event Count")
 			}
 		case 0xC5: // 197. Current Pending Sector Count
+			d.PendingSectors = uint64(attr.raw)
 			if isUnhealthy(attr) {
 				d.appendErrorRecord(attr, tDict[attr.id], "Current Pending Sector Count")
 			}
@@ -178,6 +185,52 @@ func (d *Device) decodeSmart(fd *os.File) error {
 	return nil
 }
 
+// wearAttrs are the vendor specific attributes reporting the wear, by the prefix of the model number
+// the same ids mean other things (or nothing) on the other drives, so that they are used only for these vendors
+var wearAttrs = []struct {
+	prefix   string
+	lifeLeft byte
+	spare    byte
+}{
+	{"SAMSUNG", 0xB1, 0},  // 177. Wear Leveling Count
+	{"INTEL", 0xE9, 0xE8}, // 233. Media Wearout Indicator, 232. Available Reserved Space
+	{"KINGSTON", 0xE7, 0}, // 231. SSD Life Left
+}
+
+// setWear sets the wear from the vendor specific attributes if the model is known to report them
+// it runs after the model number is read
+func (d *Device) setWear() {
+	model := strings.ToUpper(d.ModelNumber)
+	for _, w := range wearAttrs {
+		if !strings.HasPrefix(model, w.prefix) {
+			continue
+		}
+
+		if v, ok := d.wearValues[w.lifeLeft]; ok {
+			d.setLifeLeft(v)
+		}
+		if v, ok := d.wearValues[w.spare]; ok && w.spare != 0 && v <= 100 {
+			d.SpareSpace = v
+			d.SpareSpaceSupport = true
+		}
+		return
+	}
+}
+
+// setLifeLeft keeps the lowest life left among the wear attributes a drive reports
+// the normalized value of them counts down from 100
+func (d *Device) setLifeLeft(v byte) {
+	if v > 100 {
+		return
+	}
+	if d.WearSupport && v >= d.LifeLeft {
+		return
+	}
+	d.LifeLeft = v
+	d.Used = 100 - v
+	d.WearSupport = true
+}
+
 func newSmartRecord(id byte, c, w byte, r int64, t byte, name string) *SmartRecord {
 	s := new(SmartRecord)
 	s.ID = id
@@ -260,3 +260,73 @@ func TestReadRawVal(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLifeLeft(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		left byte
+		used byte
+		ok   bool
+	}{
+		{[]byte{}, 0, 0, false},
+		{[]byte{99}, 99, 1, true},
+		{[]byte{99, 97}, 97, 3, true},
+		{[]byte{97, 99}, 97, 3, true},
+		{[]byte{0}, 0, 100, true},
+		{[]byte{200}, 0, 0, false},
+		{[]byte{200, 98}, 98, 2, true},
+	}
+
+	for _, test := range tests {
+		tt := test
+
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			d := new(Device)
+			for _, v := range tt.in {
+				d.setLifeLeft(v)
+			}
+			if d.LifeLeft != tt.left || d.Used != tt.used || d.WearSupport != tt.ok {
+				t.Errorf("got: left=%d, used=%d, ok=%t, expect: left=%d, used=%d, ok=%t", d.LifeLeft, d.Used, d.WearSupport, tt.left, tt.used, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSetWear(t *testing.T) {
+	tests := []struct {
+		model  string
+		values map[byte]byte
+		used   byte
+		ok     bool
+		spare  byte
+		spok   bool
+	}{
+		{"SAMSUNG MZ7LM960HMJP-00005", map[byte]byte{0xB1: 99}, 1, true, 0, false},
+		{"Samsung SSD 860 EVO 500GB", map[byte]byte{0xB1: 90, 0xE9: 50}, 10, true, 0, false},
+		{"INTEL SSDSC2BB480G7", map[byte]byte{0xE9: 97, 0xE8: 99}, 3, true, 99, true},
+		{"INTEL SSDSC2BB480G7", map[byte]byte{0xE9: 200, 0xE8: 200}, 0, false, 0, false},
+		{"KINGSTON SA400S37240G", map[byte]byte{0xE7: 95}, 5, true, 0, false},
+		// the same ids are not the wear on the other drives
+		{"Micron_5200_MTFDDAK960TDD", map[byte]byte{0xB1: 10, 0xE7: 10, 0xE8: 10, 0xE9: 10}, 0, false, 0, false},
+		{"CT500MX500SSD1", map[byte]byte{0xE9: 30}, 0, false, 0, false},
+		{"", map[byte]byte{0xB1: 10, 0xE9: 10}, 0, false, 0, false},
+		{"INTEL SSDSC2BB480G7", nil, 0, false, 0, false},
+	}
+
+	for _, test := range tests {
+		tt := test
+
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			d := new(Device)
+			d.ModelNumber = tt.model
+			d.wearValues = tt.values
+			d.setWear()
+			if d.Used != tt.used || d.WearSupport != tt.ok {
+				t.Errorf("got: used=%d, ok=%t, expect: used=%d, ok=%t", d.Used, d.WearSupport, tt.used, tt.ok)
+			}
+			if d.SpareSpace != tt.spare || d.SpareSpaceSupport != tt.spok {
+				t.Errorf("got: spare=%d, ok=%t, expect: spare=%d, ok=%t", d.SpareSpace, d.SpareSpaceSupport, tt.spare, tt.spok)
+			}
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package spc
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/moxspec/moxspec/loglet"
+	"github.com/moxspec/moxspec/util"
 )
 
+const ioctlDefaultTimeout = 30 * time.Second
+
 var log *loglet.Logger
 
 func init() {
@@ -15,7 +21,74 @@ func init() {
 
 // Decode shapes SCSI/ATA device via mox.Decoder interface
 func (d *Device) Decode() error {
+	return d.decode()
+}
+
+// DecodeContext shapes SCSI/ATA device via mox.ContextDecoder interface
+// an ioctl can not be interrupted, so DecodeContext returns without waiting for it when the context is done
+// or after ioctlDefaultTimeout if the context has no deadline
+func (d *Device) DecodeContext(ctx context.Context) error {
+	return d.run(ctx, d.decode)
+}
+
+// DecodeCountersContext reads only the counters of a device decoded before
+// (SMART and the device statistics of a SATA drive, the error counters of a SAS drive)
+// a SATA drive is identified again since the wear attributes depend on its model
+func (d *Device) DecodeCountersContext(ctx context.Context) error {
+	return d.run(ctx, d.decodeCounters)
+}
+
+// busy holds the devices whose ioctl has been abandoned and is still running
+// a device is not read again until it returns, so that a hung device does not pile up goroutines
+var (
+	busyMu sync.Mutex
+	busy   = make(map[string]bool)
+)
+
+func (d *Device) run(ctx context.Context, decode func() error) error {
+	var err error
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ioctlDefaultTimeout)
+		defer cancel()
+	}
+
+	busyMu.Lock()
+	if busy[d.ioctlDeviceFilePath] {
+		busyMu.Unlock()
+		return fmt.Errorf("%s: the previous ioctl has not returned yet", d.ioctlDeviceFilePath)
+	}
+	busy[d.ioctlDeviceFilePath] = true
+	busyMu.Unlock()
 
+	done := make(chan bool, 1)
+	go func() {
+		err = decode()
+
+		busyMu.Lock()
+		delete(busy, d.ioctlDeviceFilePath)
+		busyMu.Unlock()
+
+		done <- true
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %s", d.ioctlDeviceFilePath, ctx.Err())
+	case <-done:
+	}
+
+	return err
+}
+
+func (d *Device) open(decode func(*os.File) error) error {
+	if util.Captured() {
+		return util.ErrLiveOnly
+	}
+	if !util.Privileged() {
+		return util.ErrPrivileged
+	}
 	if d.ioctlDeviceFilePath == "" {
 		return fmt.Errorf("ioctl device is empty")
 	}
@@ -26,11 +99,56 @@ func (d *Device) Decode() error {
 	}
 	defer fd.Close()
 
-	err = d.decodeInquiry(fd)
+	return decode(fd)
+}
+
+func (d *Device) decode() error {
+	return d.open(func(fd *os.File) error {
+		err := d.decodeInquiry(fd)
+		if err != nil {
+			return err
+		}
+
+		err = d.readCounters(fd)
+		if err != nil {
+			return err
+		}
+
+		if d.diskType == SATADisk {
+			return d.decodeWear(fd)
+		}
+		return nil
+	})
+}
+
+func (d *Device) decodeCounters() error {
+	return d.open(func(fd *os.File) error {
+		err := d.readCounters(fd)
+		if err != nil {
+			return err
+		}
+
+		if d.diskType == SATADisk {
+			return d.decodeWear(fd)
+		}
+		return nil
+	})
+}
+
+// decodeWear reads the model number the SMART wear attributes depend on, see setWear
+func (d *Device) decodeWear(fd *os.File) error {
+	err := d.decodeIdentify(fd)
 	if err != nil {
 		return err
 	}
 
+	d.setWear()
+	return nil
+}
+
+func (d *Device) readCounters(fd *os.File) error {
+	var err error
+
 	if d.diskType == SASDisk {
 		err = d.decodeLogSense(fd)
 		if err != nil {
@@ -48,11 +166,6 @@ func (d *Device) Decode() error {
 		if err != nil {
 			return err
 		}
-
-		err = d.decodeIdentify(fd)
-		if err != nil {
-			return err
-		}
 	}
 
 	return nil
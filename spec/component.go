@@ -0,0 +1,295 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// props represents fields of a component, label is used to point out the component in results
+type props struct {
+	label  string
+	values map[string]string
+}
+
+type kind struct {
+	fields  []string
+	extract func(r *model.Report) []*props
+}
+
+func (k kind) has(field string) bool {
+	for _, f := range k.fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func newProps(label string, kvs ...interface{}) *props {
+	p := new(props)
+	p.label = label
+	p.values = make(map[string]string)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		p.values[fmt.Sprint(kvs[i])] = fmt.Sprint(kvs[i+1])
+	}
+	return p
+}
+
+// sizeGB returns the size in GB (10^9 bytes) as mox shows it
+func sizeGB(b uint64) string {
+	return strconv.FormatFloat(float64(b)/1000/1000/1000, 'f', -1, 64)
+}
+
+func pciProps(p model.PCIBaseSpec) []interface{} {
+	return []interface{}{
+		"slot", p.PCIID(),
+		"vendorID", fmt.Sprintf("%04x", p.VendorID),
+		"deviceID", fmt.Sprintf("%04x", p.DeviceID),
+		"vendorName", p.VendorName,
+		"deviceName", p.DeviceName,
+		"driver", p.Driver,
+	}
+}
+
+// numericFields are compared as numbers by min and max, the others as versions
+var numericFields = map[string]bool{
+	"coreCount":       true,
+	"threadCount":     true,
+	"size":            true,
+	"speed":           true,
+	"configuredSpeed": true,
+	"interfaces":      true,
+	"drives":          true,
+	"capacity":        true,
+}
+
+var pciFields = []string{"slot", "vendorID", "deviceID", "vendorName", "deviceName", "driver"}
+
+var kinds = map[string]*kind{
+	"system": {
+		fields: []string{"manufacturer", "productName"},
+		extract: func(r *model.Report) []*props {
+			if r.System == nil {
+				return nil
+			}
+			return []*props{newProps("system", "manufacturer", r.System.Manufacturer, "productName", r.System.ProductName)}
+		},
+	},
+	"firmware": {
+		fields: []string{"type", "vendor", "version", "releaseDate"},
+		extract: func(r *model.Report) []*props {
+			f := r.Firmware
+			if f == nil {
+				return nil
+			}
+			return []*props{newProps("firmware", "type", f.Type, "vendor", f.Vendor, "version", f.Version, "releaseDate", f.ReleaseDate)}
+		},
+	},
+	"bmc": {
+		fields: []string{"type", "firmware"},
+		extract: func(r *model.Report) []*props {
+			if r.BMC == nil {
+				return nil
+			}
+			return []*props{newProps("bmc", "type", r.BMC.Type, "firmware", r.BMC.Firmware)}
+		},
+	},
+	"processor": {
+		fields: []string{"socket", "manufacturer", "productName", "coreCount", "threadCount"},
+		extract: func(r *model.Report) []*props {
+			if r.Processor == nil {
+				return nil
+			}
+			var ps []*props
+			for _, p := range r.Processor.Packages {
+				ps = append(ps, newProps(p.Socket,
+					"socket", p.Socket,
+					"manufacturer", p.Manufacturer,
+					"productName", p.ProductName,
+					"coreCount", p.CoreCount,
+					"threadCount", p.ThreadCount,
+				))
+			}
+			return ps
+		},
+	},
+	"memory": {
+		fields: []string{"locator", "manufacturer", "partNumber", "type", "formFactor", "size", "speed", "configuredSpeed"},
+		extract: func(r *model.Report) []*props {
+			if r.Memory == nil {
+				return nil
+			}
+			var ps []*props
+			for _, m := range r.Memory.Modules {
+				ps = append(ps, newProps(m.Locator,
+					"locator", m.Locator,
+					"manufacturer", m.Manufacturer,
+					"partNumber", m.PartNumber,
+					"type", m.Type,
+					"formFactor", m.FormFactor,
+					"size", sizeGB(m.Size),
+					"speed", m.Speed,
+					"configuredSpeed", m.ConfiguredSpeed,
+				))
+			}
+			return ps
+		},
+	},
+	"nic": {
+		fields: append([]string{"firmware", "interfaces"}, pciFields...),
+		extract: func(r *model.Report) []*props {
+			if r.Network == nil {
+				return nil
+			}
+			var ps []*props
+			for _, e := range r.Network.EthControllers {
+				var fw string
+				if len(e.Interfaces) > 0 {
+					fw = e.Interfaces[0].FirmwareVersion
+				}
+				kvs := append([]interface{}{"firmware", fw, "interfaces", len(e.Interfaces)}, pciProps(e.PCIBaseSpec)...)
+				ps = append(ps, newProps(e.PCIID(), kvs...))
+			}
+			return ps
+		},
+	},
+	"nvme": {
+		fields: append([]string{"model", "firmware", "size"}, pciFields...),
+		extract: func(r *model.Report) []*props {
+			if r.Storage == nil {
+				return nil
+			}
+			var ps []*props
+			for _, n := range r.Storage.NVMeControllers {
+				kvs := append([]interface{}{"model", n.Model, "firmware", n.Firmware, "size", sizeGB(n.Size)}, pciProps(n.PCIBaseSpec)...)
+				ps = append(ps, newProps(n.Name, kvs...))
+			}
+			return ps
+		},
+	},
+	"drive": {
+		fields: []string{"model", "firmware", "size", "transport"},
+		extract: func(r *model.Report) []*props {
+			if r.Storage == nil {
+				return nil
+			}
+			var drives []*model.Drive
+			for _, a := range r.Storage.AHCIControllers {
+				drives = append(drives, a.Drives...)
+			}
+			for _, v := range r.Storage.VirtControllers {
+				drives = append(drives, v.Drives...)
+			}
+			var ps []*props
+			for _, d := range drives {
+				ps = append(ps, newProps(d.Name, "model", d.Model, "firmware", d.Firmware, "size", sizeGB(d.Size), "transport", d.Transport))
+			}
+			return ps
+		},
+	},
+	"raid": {
+		fields: append([]string{"productName", "firmware", "bios", "battery"}, pciFields...),
+		extract: func(r *model.Report) []*props {
+			if r.Storage == nil {
+				return nil
+			}
+			var ps []*props
+			for _, c := range r.Storage.RAIDControllers {
+				kvs := append([]interface{}{"productName", c.ProductName, "firmware", c.Firmware, "bios", c.BIOS, "battery", c.Battery}, pciProps(c.PCIBaseSpec)...)
+				ps = append(ps, newProps(c.PCIID(), kvs...))
+			}
+			return ps
+		},
+	},
+	"logdrive": {
+		fields: []string{"raidLv", "status", "size", "drives"},
+		extract: func(r *model.Report) []*props {
+			if r.Storage == nil {
+				return nil
+			}
+			var ps []*props
+			for _, c := range r.Storage.RAIDControllers {
+				for _, ld := range c.LogDrives {
+					ps = append(ps, newProps(fmt.Sprintf("%s %s", c.AdapterID, ld.GroupLabel),
+						"raidLv", ld.RAIDLv,
+						"status", ld.Status,
+						"size", sizeGB(ld.Size),
+						"drives", len(ld.PhyDrives),
+					))
+				}
+			}
+			return ps
+		},
+	},
+	"phydrive": {
+		fields: []string{"model", "firmware", "size", "status", "ssd"},
+		extract: func(r *model.Report) []*props {
+			if r.Storage == nil {
+				return nil
+			}
+			var ps []*props
+			for _, c := range r.Storage.RAIDControllers {
+				var pds []*model.PhyDrive
+				for _, ld := range c.LogDrives {
+					pds = append(pds, ld.PhyDrives...)
+				}
+				pds = append(pds, c.UnconfDrives...)
+				pds = append(pds, c.PassthroughDrives...)
+
+				for _, pd := range pds {
+					ps = append(ps, newProps(fmt.Sprintf("%s [%s]", c.AdapterID, pd.Pos()),
+						"model", pd.Model,
+						"firmware", pd.Firmware,
+						"size", sizeGB(pd.Size),
+						"status", pd.Status,
+						"ssd", pd.SolidStateDrive,
+					))
+				}
+			}
+			return ps
+		},
+	},
+	"gpu": {
+		fields: append([]string{"productName", "bios"}, pciFields...),
+		extract: func(r *model.Report) []*props {
+			if r.Accelerator == nil {
+				return nil
+			}
+			var ps []*props
+			for _, g := range r.Accelerator.GPUs {
+				kvs := append([]interface{}{"productName", g.ProductName, "bios", g.BIOS}, pciProps(g.PCIBaseSpec)...)
+				ps = append(ps, newProps(g.PCIID(), kvs...))
+			}
+			return ps
+		},
+	},
+	"psu": {
+		fields: []string{"manufacturer", "productName", "modelPartNumber", "capacity", "present", "plugged"},
+		extract: func(r *model.Report) []*props {
+			var ps []*props
+			for i, p := range r.PowerSupply {
+				ps = append(ps, newProps(fmt.Sprintf("PSU%d", i),
+					"manufacturer", p.Manufacturer,
+					"productName", p.ProductName,
+					"modelPartNumber", p.ModelPartNumber,
+					"capacity", p.Capacity,
+					"present", p.Present,
+					"plugged", p.Plugged,
+				))
+			}
+			return ps
+		},
+	},
+	"pci": {
+		fields: pciFields,
+		extract: func(r *model.Report) []*props {
+			var ps []*props
+			for _, p := range r.PCIDevice {
+				ps = append(ps, newProps(p.PCIID(), pciProps(*p)...))
+			}
+			return ps
+		},
+	},
+}
@@ -0,0 +1,302 @@
+// Package spec verifies a report against a golden hardware spec
+//
+// A spec is a list of checks written in YAML:
+//
+//	checks:
+//	  - name: cpu
+//	    component: processor
+//	    count: 2
+//	    fields:
+//	      productName: {regex: "Gold 6248R"}
+//	  - component: memory
+//	    count: {min: 12, max: 24}
+//	    fields:
+//	      size: 32
+//	      speed: {min: 2933}
+//	  - component: nic
+//	    where:
+//	      deviceName: {regex: "ConnectX-5"}
+//	    fields:
+//	      firmware: {min: 16.26.1040}
+//
+// A check selects every component of the given type which satisfies the where clause,
+// then compares the number of them with count and every one of them with fields.
+// An expectation is either a plain value (equality) or a set of eq, ne, regex, in, min and max.
+// min and max compare numeric fields (e.g. size, speed and count) numerically,
+// and the others such as versions (e.g. 16.26.1040, 2.10) segment by segment.
+package spec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Spec represents a golden hardware spec
+type Spec struct {
+	Checks []*Check `yaml:"checks"`
+}
+
+// Check represents expectations for a type of component
+type Check struct {
+	Name      string             `yaml:"name"`
+	Component string             `yaml:"component"`
+	Where     map[string]*Expect `yaml:"where"`
+	Count     *Expect            `yaml:"count"`
+	Fields    map[string]*Expect `yaml:"fields"`
+}
+
+// Label returns the name of the check, or the component if it has no name
+func (c Check) Label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Component
+}
+
+// Expect represents an expectation for a value
+type Expect struct {
+	Eq    *string  `yaml:"eq"`
+	Ne    *string  `yaml:"ne"`
+	Regex *string  `yaml:"regex"`
+	In    []string `yaml:"in"`
+	Min   *string  `yaml:"min"`
+	Max   *string  `yaml:"max"`
+
+	re      *regexp.Regexp
+	numeric bool
+}
+
+// UnmarshalYAML makes Expect satisfy the yaml.Unmarshaler interface, a plain value means equality
+func (e *Expect) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v string
+	if err := unmarshal(&v); err == nil {
+		e.Eq = &v
+		return nil
+	}
+
+	type plain Expect
+	return unmarshal((*plain)(e))
+}
+
+// Load reads a spec from the file
+func Load(path string) (*Spec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return s, nil
+}
+
+// Parse parses a spec written in YAML
+func Parse(b []byte) (*Spec, error) {
+	s := new(Spec)
+	err := yaml.UnmarshalStrict(b, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.Checks) == 0 {
+		return nil, fmt.Errorf("no checks")
+	}
+
+	for i, c := range s.Checks {
+		if c == nil {
+			return nil, fmt.Errorf("check %d is empty", i+1)
+		}
+		err = c.validate()
+		if err != nil {
+			return nil, fmt.Errorf("check %d (%s): %s", i+1, c.Label(), err)
+		}
+	}
+
+	return s, nil
+}
+
+func (c *Check) validate() error {
+	kind, ok := kinds[c.Component]
+	if !ok {
+		return fmt.Errorf("unknown component: %s (available: %s)", c.Component, strings.Join(Components(), ", "))
+	}
+
+	if c.Count == nil && len(c.Fields) == 0 {
+		return fmt.Errorf("neither count nor fields is given")
+	}
+
+	if c.Count != nil {
+		if err := c.Count.compile(true); err != nil {
+			return fmt.Errorf("count: %s", err)
+		}
+	}
+
+	for _, m := range []map[string]*Expect{c.Where, c.Fields} {
+		for name, e := range m {
+			if !kind.has(name) {
+				return fmt.Errorf("unknown field of %s: %s (available: %s)", c.Component, name, strings.Join(kind.fields, ", "))
+			}
+			if e == nil {
+				return fmt.Errorf("%s: empty expectation", name)
+			}
+			if err := e.compile(numericFields[name]); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// compile prepares the expectation, min and max of a numeric value are compared as numbers
+func (e *Expect) compile(numeric bool) error {
+	if e.Eq == nil && e.Ne == nil && e.Regex == nil && len(e.In) == 0 && e.Min == nil && e.Max == nil {
+		return fmt.Errorf("empty expectation")
+	}
+	e.numeric = numeric
+
+	if e.Regex == nil {
+		return nil
+	}
+
+	re, err := regexp.Compile(*e.Regex)
+	if err != nil {
+		return err
+	}
+	e.re = re
+	return nil
+}
+
+// Match returns whether the value satisfies every condition
+func (e Expect) Match(v string) bool {
+	if e.Eq != nil && v != *e.Eq {
+		return false
+	}
+	if e.Ne != nil && v == *e.Ne {
+		return false
+	}
+	if e.re != nil && !e.re.MatchString(v) {
+		return false
+	}
+	if len(e.In) > 0 {
+		found := false
+		for _, in := range e.In {
+			if v == in {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if e.Min != nil && e.compare(v, *e.Min) < 0 {
+		return false
+	}
+	if e.Max != nil && e.compare(v, *e.Max) > 0 {
+		return false
+	}
+	return true
+}
+
+// compare compares versions segment by segment unless the value is numeric
+// since 2.10 is newer than 2.9 while it is less as a number
+func (e Expect) compare(a, b string) int {
+	if e.numeric {
+		return compareValue(a, b)
+	}
+	return compareVersion(a, b)
+}
+
+// String returns the expectation in a readable form
+func (e Expect) String() string {
+	var conds []string
+	if e.Eq != nil {
+		conds = append(conds, fmt.Sprintf("= %s", *e.Eq))
+	}
+	if e.Ne != nil {
+		conds = append(conds, fmt.Sprintf("!= %s", *e.Ne))
+	}
+	if e.Regex != nil {
+		conds = append(conds, fmt.Sprintf("~ /%s/", *e.Regex))
+	}
+	if len(e.In) > 0 {
+		conds = append(conds, fmt.Sprintf("in [%s]", strings.Join(e.In, ", ")))
+	}
+	if e.Min != nil {
+		conds = append(conds, fmt.Sprintf(">= %s", *e.Min))
+	}
+	if e.Max != nil {
+		conds = append(conds, fmt.Sprintf("<= %s", *e.Max))
+	}
+	return strings.Join(conds, ", ")
+}
+
+// compareValue compares numbers numerically, and anything else such as versions segment by segment
+func compareValue(a, b string) int {
+	fa, aerr := strconv.ParseFloat(a, 64)
+	fb, berr := strconv.ParseFloat(b, 64)
+	if aerr == nil && berr == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	return compareVersion(a, b)
+}
+
+var segmentPattern = regexp.MustCompile(`[0-9]+|[^0-9.\-_ ]+`)
+
+// compareVersion compares versions such as 16.26.1040 and 4.1.2a
+func compareVersion(a, b string) int {
+	as := segmentPattern.FindAllString(a, -1)
+	bs := segmentPattern.FindAllString(b, -1)
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.ParseUint(as[i], 10, 64)
+		bn, berr := strconv.ParseUint(bs[i], 10, 64)
+		if aerr == nil && berr == nil {
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// Components returns the names of components which can be checked
+func Components() []string {
+	var names []string
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
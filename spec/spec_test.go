@@ -0,0 +1,144 @@
+package spec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+func TestCompareValue(t *testing.T) {
+	tests := []struct {
+		a  string
+		b  string
+		ex int
+	}{
+		{"2666", "2933", -1},
+		{"2933", "2933", 0},
+		{"32", "16", 1},
+		{"0.5", "0.25", 1},
+		{"16.26.1040", "16.26.1040", 0},
+		{"16.26.1040", "16.27.1016", -1},
+		{"16.26.1040", "16.9.1000", 1},
+		{"24.21.0-0012", "24.21.0-0009", 1},
+		{"4.1.2a", "4.1.2b", -1},
+		{"4.1", "4.1.2", -1},
+		{"", "1", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			got := compareValue(tt.a, tt.b)
+			if got != tt.ex {
+				t.Errorf("got: %d, expect: %d", got, tt.ex)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in  string
+		err bool
+	}{
+		{"checks:\n  - component: memory\n    count: 24\n", false},
+		{"checks:\n  - component: nic\n    fields:\n      firmware: {min: 16.26.1040}\n", false},
+		{"checks:\n  - component: memory\n    fields:\n      speed: {min: 2666, max: 3200}\n      type: {in: [DDR4, DDR5]}\n", false},
+		{"checks: []\n", true},
+		{"checks:\n  - component: floppy\n    count: 1\n", true},
+		{"checks:\n  - component: memory\n", true},
+		{"checks:\n  - component: memory\n    fields:\n      color: red\n", true},
+		{"checks:\n  - component: memory\n    fields:\n      partNumber: {regex: \"[\"}\n", true},
+		{"checks:\n  - component: memory\n    fields:\n      speed: {over: 1}\n", true},
+		{"checks:\n  - component: memory\n    fields:\n      speed: {}\n", true},
+		{"chekcs:\n  - component: memory\n", true},
+		{"checks:\n  - \n", true},
+		{"checks:\n  - component: memory\n    count: 24\n  -\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			if (err != nil) != tt.err {
+				t.Errorf("got: %v, expect error: %t", err, tt.err)
+			}
+		})
+	}
+}
+
+func testReport() *model.Report {
+	r := new(model.Report)
+	r.Firmware = &model.Firmware{Version: "2.10"}
+	r.Processor = &model.ProcessorReport{Packages: []*model.Package{
+		{Socket: "CPU1", ProductName: "Intel(R) Xeon(R) Gold 6248R CPU @ 3.00GHz"},
+		{Socket: "CPU2", ProductName: "Intel(R) Xeon(R) Gold 6248R CPU @ 3.00GHz"},
+	}}
+
+	var mods []*model.MemoryModule
+	for i := 0; i < 4; i++ {
+		m := new(model.MemoryModule)
+		m.Locator = fmt.Sprintf("A%d", i)
+		m.Type = "DDR4"
+		m.Speed = 2933
+		m.Size = 32 * 1000 * 1000 * 1000
+		mods = append(mods, m)
+	}
+	mods[3].Speed = 2666
+	r.Memory = &model.MemoryReport{Modules: mods}
+
+	e := new(model.EthController)
+	e.DeviceName = "MT27800 Family [ConnectX-5]"
+	e.Interfaces = []*model.NetInterface{{Name: "eth0", FirmwareVersion: "16.26.1040"}}
+	r.Network = &model.NetworkReport{EthControllers: []*model.EthController{e}}
+
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		in     string
+		pass   bool
+		detail string
+	}{
+		{"checks:\n  - component: processor\n    count: 2\n    fields:\n      productName: {regex: Gold 6248R}\n", true, ""},
+		{"checks:\n  - component: processor\n    count: 1\n", false, "count = 2, expected = 1"},
+		{"checks:\n  - component: memory\n    count: {min: 4, max: 24}\n    fields:\n      size: 32\n", true, ""},
+		{"checks:\n  - component: memory\n    fields:\n      speed: {min: 2933}\n", false, "A3: speed = 2666, expected >= 2933"},
+		{"checks:\n  - component: memory\n    where:\n      speed: 2666\n    count: 1\n", true, ""},
+		{"checks:\n  - component: nic\n    where:\n      deviceName: {regex: ConnectX-5}\n    fields:\n      firmware: {min: 16.26.1040}\n", true, ""},
+		{"checks:\n  - component: nic\n    fields:\n      firmware: {min: 16.27.1016}\n", false, "0000:00:00.0: firmware = 16.26.1040, expected >= 16.27.1016"},
+		{"checks:\n  - component: firmware\n    fields:\n      version: {min: 2.9}\n", true, ""},
+		{"checks:\n  - component: firmware\n    fields:\n      version: {max: 2.9}\n", false, "firmware: version = 2.10, expected <= 2.9"},
+		{"checks:\n  - component: firmware\n    fields:\n      version: {min: 2.11}\n", false, "firmware: version = 2.10, expected >= 2.11"},
+		{"checks:\n  - component: gpu\n    fields:\n      productName: {regex: A100}\n", false, "no gpu found"},
+		{"checks:\n  - component: gpu\n    count: 0\n    fields:\n      productName: {regex: A100}\n", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			s, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res := s.Verify(testReport())
+			if res.Passed() != tt.pass {
+				for _, o := range res.Outcomes {
+					t.Logf("%+v", o)
+				}
+				t.Errorf("got: %t, expect: %t", res.Passed(), tt.pass)
+			}
+
+			if tt.detail == "" {
+				return
+			}
+
+			for _, o := range res.Outcomes {
+				if !o.Pass && o.Detail == tt.detail {
+					return
+				}
+			}
+			t.Errorf("no outcome with detail: %s", tt.detail)
+		})
+	}
+}
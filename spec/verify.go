@@ -0,0 +1,110 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/moxspec/moxspec/model"
+)
+
+// Outcome represents a result of an expectation
+type Outcome struct {
+	Check  string `json:"check"`
+	Pass   bool   `json:"pass"`
+	Detail string `json:"detail"`
+}
+
+// Result represents results of all checks in a spec
+type Result struct {
+	Outcomes []*Outcome `json:"outcomes"`
+}
+
+// Passed returns whether every expectation is satisfied
+func (r Result) Passed() bool {
+	for _, o := range r.Outcomes {
+		if !o.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Result) append(check string, pass bool, format string, a ...interface{}) {
+	r.Outcomes = append(r.Outcomes, &Outcome{Check: check, Pass: pass, Detail: fmt.Sprintf(format, a...)})
+}
+
+// Verify evaluates the spec against the report
+func (s Spec) Verify(r *model.Report) *Result {
+	res := new(Result)
+	if r == nil {
+		r = new(model.Report)
+	}
+
+	for _, c := range s.Checks {
+		c.verify(r, res)
+	}
+
+	return res
+}
+
+func (c Check) verify(r *model.Report, res *Result) {
+	label := c.Label()
+
+	var selected []*props
+	for _, p := range kinds[c.Component].extract(r) {
+		if p.satisfies(c.Where) {
+			selected = append(selected, p)
+		}
+	}
+
+	if c.Count != nil {
+		n := strconv.Itoa(len(selected))
+		res.append(label, c.Count.Match(n), "count = %s, expected %s", n, c.Count)
+	}
+
+	if len(c.Fields) == 0 {
+		return
+	}
+
+	if len(selected) == 0 {
+		// an empty selection must be allowed explicitly with count
+		if c.Count == nil {
+			res.append(label, false, "no %s found", c.Component)
+		}
+		return
+	}
+
+	var names []string
+	for name := range c.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e := c.Fields[name]
+
+		failed := 0
+		for _, p := range selected {
+			v := p.values[name]
+			if e.Match(v) {
+				continue
+			}
+			failed++
+			res.append(label, false, "%s: %s = %s, expected %s", p.label, name, v, e)
+		}
+
+		if failed == 0 {
+			res.append(label, true, "%s %s (%d/%d)", name, e, len(selected), len(selected))
+		}
+	}
+}
+
+func (p props) satisfies(where map[string]*Expect) bool {
+	for name, e := range where {
+		if !e.Match(p.values[name]) {
+			return false
+		}
+	}
+	return true
+}
@@ -13,3 +13,14 @@ func ScanPathList(list []string) (string, error) {
 	}
 	return "", fmt.Errorf("not found")
 }
+
+// LookPathList returns the command which was found by first from the list via the runner
+func LookPathList(list []string) (string, error) {
+	for _, l := range list {
+		p, err := LookPath(l)
+		if err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("not found")
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/moxspec/moxspec/capture"
 )
 
 // FilterPrefixedFiles returns filtered regular files which has given prefix
@@ -44,3 +46,92 @@ func FilterFiles(path string, filter func(os.FileInfo) bool) []string {
 
 	return list
 }
+
+// Readlink returns the destination of the symlink named by path
+func Readlink(path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err == nil && capture.Enabled() {
+		if p, ok := captureParents(path); ok {
+			capture.Symlink(TrimRoot(p), target)
+		}
+	}
+	return target, err
+}
+
+// EvalSymlinks returns the path name after the evaluation of any symlinks
+func EvalSymlinks(path string) (string, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		Readlink(path)
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+// CaptureFile captures a file along with the symlinks on its path,
+// so that it is reachable through the same path under a captured root
+func CaptureFile(path string, data []byte) {
+	if !capture.Enabled() {
+		return
+	}
+
+	p, ok := captureParents(path)
+	if !ok {
+		return
+	}
+
+	p, ok = captureLink(p)
+	if !ok {
+		return
+	}
+
+	capture.File(TrimRoot(p), data)
+}
+
+// captureParents captures the symlinks among the directories of path
+// and returns path with those directories resolved
+func captureParents(path string) (string, bool) {
+	if !Captured() && !filepath.IsAbs(path) {
+		return "", false
+	}
+
+	p := Root()
+	if p == "" {
+		p = "/"
+	}
+
+	dir := strings.Trim(TrimRoot(filepath.Dir(path)), "/")
+	if dir != "" {
+		for _, c := range strings.Split(dir, "/") {
+			var ok bool
+			p, ok = captureLink(filepath.Join(p, c))
+			if !ok {
+				return "", false
+			}
+		}
+	}
+
+	return filepath.Join(p, filepath.Base(path)), true
+}
+
+// captureLink captures p if it is a symlink and returns the resolved path
+// the directories of p must be resolved already
+func captureLink(p string) (string, bool) {
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return "", false
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return p, true
+	}
+
+	target, err := os.Readlink(p)
+	if err != nil {
+		return "", false
+	}
+	capture.Symlink(TrimRoot(p), target)
+
+	p, err = filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", false
+	}
+	return p, true
+}
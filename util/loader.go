@@ -12,17 +12,24 @@ import (
 const loaderDefaultTimeout = time.Second * 5
 
 // LoadBytesWithContext reads the file named by path and returns the contents as []byte
+// the default timeout is applied when the context has no deadline
 func LoadBytesWithContext(ctx context.Context, path string) ([]byte, error) {
 	if !Exists(path) {
 		return nil, fmt.Errorf("not found %s", path)
 	}
 
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, loaderDefaultTimeout)
+		defer cancel()
+	}
+
 	var (
 		bs  []byte
 		err error
 	)
 
-	done := make(chan bool)
+	done := make(chan bool, 1)
 	go func() {
 		bs, err = ioutil.ReadFile(path)
 		done <- true
@@ -34,6 +41,10 @@ func LoadBytesWithContext(ctx context.Context, path string) ([]byte, error) {
 	case <-done:
 	}
 
+	if err == nil {
+		CaptureFile(path, bs)
+	}
+
 	return bs, err
 }
 
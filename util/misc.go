@@ -2,13 +2,17 @@ package util
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/moxspec/moxspec/capture"
 )
 
+const execDefaultTimeout = 30 * time.Second
+
 // Exists returns if the file named by path exists
 func Exists(path string) bool {
 	_, err := os.Stat(path)
@@ -32,22 +36,31 @@ func Executable(path string) bool {
 
 // Exec runs specified command and returns output as string
 func Exec(c string, arg ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	return ExecWithContext(context.Background(), c, arg...)
+}
 
-	cmd := exec.CommandContext(ctx, c, arg...)
+// ExecWithContext runs specified command and returns output as string
+// the command is killed when the context is done, or after execDefaultTimeout if the context has no deadline
+func ExecWithContext(ctx context.Context, c string, arg ...string) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, execDefaultTimeout)
+		defer cancel()
+	}
 
-	out, err := cmd.Output()
+	res, err := CurrentRunner().Run(ctx, c, arg...)
 
-	if err != nil {
-		return string(out), err
+	if err != nil && ctx.Err() != nil {
+		return "", fmt.Errorf("%s: %s", c, ctx.Err())
 	}
 
-	if ctx.Err() != nil {
-		return "", ctx.Err()
+	capture.Command(c, arg, res.Stdout, err)
+
+	if err != nil {
+		return res.Stdout, err
 	}
 
-	return string(out), nil
+	return res.Stdout, nil
 }
 
 // DumpBinary returns the string that represents the value as binary array
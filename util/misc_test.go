@@ -1,10 +1,12 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestDumpBinary(t *testing.T) {
@@ -94,3 +96,26 @@ func TestIPv4MaskSize(t *testing.T) {
 		})
 	}
 }
+
+func TestExecWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ExecWithContext(ctx, "sleep", "10")
+	if err == nil {
+		t.Errorf("error should be not nil")
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("the command should be killed when the context is done")
+	}
+
+	out, err := ExecWithContext(context.Background(), "echo", "mox")
+	if err != nil {
+		t.Errorf("error should be nil, got: %s", err)
+	}
+	if out != "mox\n" {
+		t.Errorf("got: %q, expect: %q", out, "mox\n")
+	}
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"sort"
+	"sync"
+)
+
+// These name the tool and device paths which can be overridden
+const (
+	PCIIDsPath    = "pci.ids"
+	IPMIDevPath   = "ipmidev"
+	IPMIToolPath  = "ipmitool"
+	NvidiaSMIPath = "nvidia-smi"
+	MegaCLIPath   = "megacli"
+	HPACUCLIPath  = "hpacucli"
+	SAS3IRCUPath  = "sas3ircu"
+)
+
+// PathNames returns the names of the paths which can be overridden
+func PathNames() []string {
+	names := []string{
+		PCIIDsPath,
+		IPMIDevPath,
+		IPMIToolPath,
+		NvidiaSMIPath,
+		MegaCLIPath,
+		HPACUCLIPath,
+		SAS3IRCUPath,
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	pathsMu sync.RWMutex
+	paths   map[string]string
+)
+
+// SetPaths overrides the built-in paths by name, it affects the whole process
+// nil resets them to the built-in ones
+func SetPaths(ps map[string]string) {
+	pathsMu.Lock()
+	defer pathsMu.Unlock()
+
+	paths = make(map[string]string)
+	for name, p := range ps {
+		if p != "" {
+			paths[name] = p
+		}
+	}
+}
+
+// Path returns the overridden path of name, or def if not overridden
+func Path(name, def string) string {
+	pathsMu.RLock()
+	defer pathsMu.RUnlock()
+
+	if p, ok := paths[name]; ok {
+		return p
+	}
+	return def
+}
+
+// PathList returns the overridden path of name alone, or defs if not overridden
+func PathList(name string, defs []string) []string {
+	pathsMu.RLock()
+	defer pathsMu.RUnlock()
+
+	if p, ok := paths[name]; ok {
+		return []string{p}
+	}
+	return defs
+}
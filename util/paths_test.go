@@ -0,0 +1,39 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	defer SetPaths(nil)
+
+	defs := []string{"/opt/MegaRAID/MegaCli/MegaCli64"}
+	tests := []struct {
+		paths map[string]string
+		path  string
+		list  []string
+	}{
+		{nil, "ipmitool", defs},
+		{map[string]string{IPMIToolPath: "/usr/local/bin/ipmitool"}, "/usr/local/bin/ipmitool", defs},
+		{map[string]string{MegaCLIPath: "/usr/sbin/megacli"}, "ipmitool", []string{"/usr/sbin/megacli"}},
+		{map[string]string{IPMIToolPath: ""}, "ipmitool", defs},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			SetPaths(tt.paths)
+
+			got := Path(IPMIToolPath, "ipmitool")
+			if got != tt.path {
+				t.Errorf("Path got: %s, expect: %s", got, tt.path)
+			}
+
+			list := PathList(MegaCLIPath, defs)
+			if !reflect.DeepEqual(list, tt.list) {
+				t.Errorf("PathList got: %v, expect: %v", list, tt.list)
+			}
+		})
+	}
+}
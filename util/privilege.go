@@ -0,0 +1,30 @@
+package util
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	privilegeMu sync.RWMutex
+	// unprivileged is true if decoders must not use what needs root privileges
+	unprivileged bool
+)
+
+// ErrPrivileged is returned by decoders which need root privileges (e.g. MSR, SG_IO, RAID utilities)
+var ErrPrivileged = errors.New("requires privileges")
+
+// SetPrivileged tells decoders whether they may use what needs root privileges
+// it affects the whole process, decoders assume privileges unless told otherwise
+func SetPrivileged(p bool) {
+	privilegeMu.Lock()
+	defer privilegeMu.Unlock()
+	unprivileged = !p
+}
+
+// Privileged returns false if decoders must skip what needs root privileges
+func Privileged() bool {
+	privilegeMu.RLock()
+	defer privilegeMu.RUnlock()
+	return !unprivileged
+}
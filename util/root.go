@@ -0,0 +1,62 @@
+package util
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	rootMu sync.RWMutex
+	// root is the directory a captured sysfs/procfs/dev tree is placed in, empty means the live system
+	root string
+)
+
+// ErrLiveOnly is returned by decoders which need the live system (e.g. ioctl, syscall, external commands)
+var ErrLiveOnly = errors.New("not available in a captured root")
+
+// SetRoot makes decoders read the captured tree under dir instead of the live system
+// it affects the whole process, an empty dir or "/" resets it to the live system
+func SetRoot(dir string) {
+	if dir != "" {
+		dir = filepath.Clean(dir)
+	}
+	if dir == "/" {
+		dir = ""
+	}
+
+	rootMu.Lock()
+	defer rootMu.Unlock()
+	root = dir
+}
+
+// Root returns the directory set by SetRoot
+func Root() string {
+	rootMu.RLock()
+	defer rootMu.RUnlock()
+	return root
+}
+
+// Captured returns true if decoders read a captured tree
+func Captured() bool {
+	return Root() != ""
+}
+
+// RootPath returns the path under the root directory
+func RootPath(path string) string {
+	r := Root()
+	if r == "" {
+		return path
+	}
+	return filepath.Join(r, path)
+}
+
+// TrimRoot returns the path as seen on the captured system
+func TrimRoot(path string) string {
+	r := Root()
+	if r == "" || !strings.HasPrefix(path, r+"/") {
+		return path
+	}
+	return strings.TrimPrefix(path, r)
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRootPath(t *testing.T) {
+	defer SetRoot("")
+
+	tests := []struct {
+		root string
+		in   string
+		path string
+		trim string
+	}{
+		{"", "/sys/bus/pci/devices", "/sys/bus/pci/devices", "/sys/bus/pci/devices"},
+		{"/", "/sys/bus/pci/devices", "/sys/bus/pci/devices", "/sys/bus/pci/devices"},
+		{"/snap/host1/", "/sys/bus/pci/devices", "/snap/host1/sys/bus/pci/devices", "/sys/bus/pci/devices"},
+		{"snap", "/proc/modules", "snap/proc/modules", "/proc/modules"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt), func(t *testing.T) {
+			SetRoot(tt.root)
+
+			got := RootPath(tt.in)
+			if got != tt.path {
+				t.Errorf("RootPath got: %s, expect: %s", got, tt.path)
+			}
+
+			got = TrimRoot(got)
+			if got != tt.trim {
+				t.Errorf("TrimRoot got: %s, expect: %s", got, tt.trim)
+			}
+		})
+	}
+}
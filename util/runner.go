@@ -0,0 +1,146 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// waitDelay is how long the output is read after the command exits or is killed
+// children left running may hold it open forever
+const waitDelay = time.Second
+
+// CmdResult represents the result of an external command
+type CmdResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Runner runs external commands, it is replaced to record or replay them
+// Run returns a non-nil result even if the command fails
+type Runner interface {
+	Run(ctx context.Context, name string, arg ...string) (*CmdResult, error)
+	LookPath(file string) (string, error)
+}
+
+type execRunner struct{}
+
+// Run runs the command and returns its result, the command is killed when the context is done
+// the command runs in its own process group, so that children it left are killed with it
+func (execRunner) Run(ctx context.Context, name string, arg ...string) (*CmdResult, error) {
+	return run(ctx, 0, name, arg...)
+}
+
+// ErrOutputLimit is returned when a command writes more than the limit to stdout
+var ErrOutputLimit = errors.New("output exceeds the limit")
+
+// RunLimited runs the command on this system like Run, regardless of the runner set by SetRunner
+// the command is killed and ErrOutputLimit is returned once it writes more than limit bytes to stdout
+func RunLimited(ctx context.Context, limit int, name string, arg ...string) (*CmdResult, error) {
+	return run(ctx, limit, name, arg...)
+}
+
+// limitedBuffer is a buffer which refuses to grow over the limit, zero means no limit
+// it does not embed bytes.Buffer, whose ReadFrom would let io.Copy bypass Write
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && b.buf.Len()+len(p) > b.limit {
+		b.exceeded = true
+		return 0, ErrOutputLimit
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+func run(ctx context.Context, limit int, name string, arg ...string) (*CmdResult, error) {
+	var stderr bytes.Buffer
+	stdout := &limitedBuffer{limit: limit}
+
+	cmd := exec.CommandContext(ctx, name, arg...)
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	if (errors.Is(err, exec.ErrWaitDelay) || errors.Is(err, ctx.Err())) && cmd.ProcessState != nil && cmd.ProcessState.Success() {
+		// the command exited and the output is complete, only its children held it
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		err = nil
+	}
+
+	if stdout.exceeded {
+		// children of the command may still be writing to the closed pipe
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		err = ErrOutputLimit
+	}
+
+	res := &CmdResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	return res, err
+}
+
+// LookPath searches the command in PATH, an absolute path is checked as it is
+func (execRunner) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+var (
+	runnerMu sync.RWMutex
+	runner   Runner = execRunner{}
+)
+
+// SetRunner replaces the runner of external commands, nil resets it to the one running them on this system
+func SetRunner(r Runner) {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+
+	if r == nil {
+		r = execRunner{}
+	}
+	runner = r
+}
+
+// CurrentRunner returns the runner of external commands
+func CurrentRunner() Runner {
+	runnerMu.RLock()
+	defer runnerMu.RUnlock()
+	return runner
+}
+
+// CanExec returns true if external commands give the data of the decoded system
+// commands on this system have nothing to do with a captured root, unless they are replayed
+func CanExec() bool {
+	if !Captured() {
+		return true
+	}
+	_, live := CurrentRunner().(execRunner)
+	return !live
+}
+
+// LookPath searches the command via the runner
+func LookPath(file string) (string, error) {
+	return CurrentRunner().LookPath(file)
+}